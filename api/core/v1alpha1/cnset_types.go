@@ -17,7 +17,9 @@ package v1alpha1
 import (
 	recon "github.com/matrixorigin/controller-runtime/pkg/reconciler"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 type CNRole string
@@ -52,18 +54,91 @@ type CNSetBasic struct {
 	// +optional
 	NodePort *int32 `json:"nodePort,omitempty"`
 
+	// ExternalTrafficPolicy is the external traffic policy of cn service when ServiceType is
+	// NodePort or LoadBalancer, Local preserves the client IP and avoids the extra hop to other nodes.
+	// Defaults to Cluster if not specified.
+	// +optional
+	// +kubebuilder:validation:Enum=Cluster;Local
+	ExternalTrafficPolicy corev1.ServiceExternalTrafficPolicyType `json:"externalTrafficPolicy,omitempty"`
+
 	// CacheVolume is the desired local cache volume for CNSet,
-	// node storage will be used if not specified
+	// node storage will be used if not specified.
+	// The volume of each pod is retained across pod restarts, use a node-local storage class
+	// (e.g. local PV with WaitForFirstConsumer binding) to pin the pod to its node so that
+	// the cache can be reused after the pod is rescheduled.
 	// +optional
 	CacheVolume *Volume `json:"cacheVolume,omitempty"`
 
+	// SpillVolume is the volume used by CN to spill temporary data of large queries,
+	// it is exposed to MO as the temporary directory through the TMPDIR env.
+	// Temporary data will be spilled to the node storage if not specified
+	// +optional
+	SpillVolume *SpillVolume `json:"spillVolume,omitempty"`
+
+	// Frontend is the config of the MySQL protocol frontend of CN,
+	// the MO built-in values are used if not specified
+	// +optional
+	Frontend *FrontendConfig `json:"frontend,omitempty"`
+
+	// ScaleStrategy controls how the CN pods are removed on scale-down, the DN and LogService
+	// sets are always scaled down conservatively and do not expose this option
+	// +optional
+	ScaleStrategy *ScaleStrategy `json:"scaleStrategy,omitempty"`
+
+	// NetworkAttachment attaches the pods to a secondary network through Multus, the CN
+	// advertises its address on the secondary network so that the data-plane traffic goes through it
+	// +optional
+	NetworkAttachment *NetworkAttachment `json:"networkAttachment,omitempty"`
+
+	// CacheSharingMode is how the CN pods share the cache of the shared storage, default to Local.
+	// Distributed requires MO 1.1 or later.
+	// +kubebuilder:validation:Enum=Local;Distributed
+	// +optional
+	CacheSharingMode CacheSharingMode `json:"cacheSharingMode,omitempty"`
+
 	SharedStorageCache SharedStorageCache `json:"sharedStorageCache,omitempty"`
 }
 
+// CacheSharingMode is how the CN pods share the cache of the shared storage
+type CacheSharingMode string
+
+const (
+	// CacheSharingModeLocal caches the data in each CN pod independently
+	CacheSharingModeLocal CacheSharingMode = "Local"
+	// CacheSharingModeDistributed lets a CN pod read the data cached by the other CN pods,
+	// which improves the cache hit rate for large working sets
+	CacheSharingModeDistributed CacheSharingMode = "Distributed"
+)
+
+// FrontendConfig limits the requests served by the CN frontend
+type FrontendConfig struct {
+	// MaxMessageSize is the max size of a MySQL protocol message accepted by the frontend,
+	// which also bounds the size of a SQL statement sent by the clients
+	// +optional
+	MaxMessageSize *resource.Quantity `json:"maxMessageSize,omitempty"`
+}
+
+// ScaleStrategy is the strategy to scale the pods of a set
+type ScaleStrategy struct {
+	// MaxUnavailable is the maximum number of pods that can be unavailable during scaling, so that
+	// the pods can be removed in parallel. The value can be an absolute number (e.g. 5) or a
+	// percentage of the desired pods (e.g. 10%), the percentage is rounded down.
+	// +optional
+	MaxUnavailable *intstr.IntOrString `json:"maxUnavailable,omitempty"`
+}
+
 // CNSetStatus Figure out what status should be exposed
 type CNSetStatus struct {
 	ConditionalStatus `json:",inline"`
 	FailoverStatus    `json:",inline"`
+
+	// ResolvedImage is the image that the CN StatefulSet currently runs, i.e. spec.image
+	// after the overlay is applied
+	ResolvedImage string `json:"resolvedImage,omitempty"`
+
+	// PodSummary counts the pods of this set by their phases
+	// +optional
+	PodSummary *PodSummary `json:"podSummary,omitempty"`
 }
 
 type CNSetDeps struct {
@@ -79,6 +154,7 @@ type CNSetDeps struct {
 // +kubebuilder:object:root=true
 // +kubebuilder:printcolumn:name="Image",type="string",JSONPath=".spec.image"
 // +kubebuilder:printcolumn:name="Replicas",type="integer",JSONPath=".spec.replicas"
+// +kubebuilder:printcolumn:name="Running",type="integer",JSONPath=".status.podSummary.running"
 // +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
 
 // A CNSet is a resource that represents a set of MO's CN instances
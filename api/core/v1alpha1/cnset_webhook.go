@@ -17,30 +17,32 @@ package v1alpha1
 import (
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	ctrl "sigs.k8s.io/controller-runtime"
-	"sigs.k8s.io/controller-runtime/pkg/webhook"
 )
 
-func (r *CNSet) setupWebhookWithManager(mgr ctrl.Manager) error {
+func (r *CNSet) setupWebhookWithManager(mgr ctrl.Manager, w *policyWebhook) error {
 	return ctrl.NewWebhookManagedBy(mgr).
 		For(r).
+		WithDefaulter(w).
+		WithValidator(w).
 		Complete()
 }
 
 // +kubebuilder:webhook:path=/mutate-core-matrixorigin-io-v1alpha1-cnset,mutating=true,failurePolicy=fail,sideEffects=None,groups=core.matrixorigin.io,resources=cnsets,verbs=create;update,versions=v1alpha1,name=mcnset.kb.io,admissionReviewVersions={v1,v1beta1}
 
-var _ webhook.Defaulter = &CNSet{}
+var _ policyObject = &CNSet{}
 
-// Default implements webhook.Defaulter so a webhook will be registered for the type
-func (r *CNSet) Default() {
-	r.Spec.CNSetBasic.Default()
+// Default implements policyObject so the type is defaulted by the policyWebhook
+func (r *CNSet) Default(p *WebhookPolicy) {
+	r.Spec.CNSetBasic.Default(p)
 	if r.Spec.Role == "" {
 		r.Spec.Role = CNRoleTP
 	}
 }
 
-func (r *CNSetBasic) Default() {
+func (r *CNSetBasic) Default(p *WebhookPolicy) {
 	if r.ServiceType == "" {
 		r.ServiceType = corev1.ServiceTypeClusterIP
 	}
@@ -51,33 +53,50 @@ func (r *CNSetBasic) Default() {
 		r.SharedStorageCache.MemoryCacheSize = &size
 	}
 	if r.CacheVolume != nil && r.SharedStorageCache.DiskCacheSize == nil {
-		// default disk cache size to the cache volume size
-		r.SharedStorageCache.DiskCacheSize = &r.CacheVolume.Size
+		// default disk cache size to the cache volume size minus the headroom
+		r.SharedStorageCache.DiskCacheSize = defaultDiskCacheSize(r.CacheVolume.Size, p)
 	}
 }
 
 // +kubebuilder:webhook:path=/validate-core-matrixorigin-io-v1alpha1-cnset,mutating=false,failurePolicy=fail,sideEffects=None,groups=core.matrixorigin.io,resources=cnsets,verbs=create;update,versions=v1alpha1,name=vcnset.kb.io,admissionReviewVersions={v1,v1beta1}
 
-var _ webhook.Validator = &CNSet{}
+// ValidateCreate implements policyObject so the type is validated by the policyWebhook
+func (r *CNSet) ValidateCreate(p *WebhookPolicy) error {
+	errs := r.validate()
+	errs = append(errs, validateCacheSize(r.Spec.Image, cacheSizeOf(r.Spec.CacheVolume, &r.Spec.SharedStorageCache), nil, r.ObjectMeta, p, field.NewPath("spec"))...)
+	errs = append(errs, r.Spec.CNSetBasic.validateCacheVolumeRequired(p, field.NewPath("spec"))...)
+	return invalidOrNil(errs, r)
+}
 
-// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
-func (r *CNSet) ValidateCreate() error {
+func (r *CNSet) ValidateUpdate(o runtime.Object, p *WebhookPolicy) error {
+	old := o.(*CNSet)
+	errs := r.validate()
+	errs = append(errs, validateCacheSize(r.Spec.Image, cacheSizeOf(r.Spec.CacheVolume, &r.Spec.SharedStorageCache),
+		cacheSizeOf(old.Spec.CacheVolume, &old.Spec.SharedStorageCache), r.ObjectMeta, p, field.NewPath("spec"))...)
+	errs = append(errs, validateNameOverrideUpdate(r.Spec.NameOverride, old.Spec.NameOverride, field.NewPath("spec").Child("nameOverride"))...)
+	errs = append(errs, validateHeadlessServiceUpdate(&r.Spec.PodSet, &old.Spec.PodSet, field.NewPath("spec").Child("headlessService"))...)
+	return invalidOrNil(errs, r)
+}
+
+func (r *CNSet) validate() field.ErrorList {
 	var errs field.ErrorList
 	errs = append(errs, validateLogSetRef(&r.Deps.LogSetRef, field.NewPath("deps"))...)
 	errs = append(errs, r.Spec.CNSetBasic.ValidateCreate()...)
 	errs = append(errs, validateMainContainer(&r.Spec.MainContainer, field.NewPath("spec"))...)
-	return invalidOrNil(errs, r)
+	errs = append(errs, validateOverlay(r.Spec.Overlay, field.NewPath("spec").Child("overlay"))...)
+	errs = append(errs, r.Spec.PodSet.validate(r.Spec.Overlay, r.Spec.TopologyEvenSpread, field.NewPath("spec"))...)
+	errs = append(errs, validateConfig(r.Spec.Config, r.Spec.Image, r.ObjectMeta, field.NewPath("spec").Child("config"))...)
+	errs = append(errs, validateCacheSharingMode(r.Spec.CacheSharingMode, r.Spec.Image, field.NewPath("spec"))...)
+	return errs
 }
 
-func (r *CNSet) ValidateUpdate(old runtime.Object) error {
-	if err := r.ValidateCreate(); err != nil {
-		return err
+// validateCacheVolumeRequired rejects the CN without a cache volume if it is required by the policy,
+// which is only enforced on creation so that the existing CNs are not blocked from being updated
+func (r *CNSetBasic) validateCacheVolumeRequired(p *WebhookPolicy, parent *field.Path) field.ErrorList {
+	if r.CacheVolume != nil || !p.RequireCNCacheVolume {
+		return nil
 	}
-	return nil
-}
-
-func (r *CNSet) ValidateDelete() error {
-	return nil
+	return field.ErrorList{field.Required(parent.Child("cacheVolume"), "cacheVolume is required by the operator policy, CN without a cache volume caches data on the node storage")}
 }
 
 func (r *CNSetBasic) ValidateCreate() field.ErrorList {
@@ -91,5 +110,66 @@ func (r *CNSetBasic) ValidateCreate() field.ErrorList {
 	if r.NodePort != nil && r.ServiceType == corev1.ServiceTypeClusterIP {
 		errs = append(errs, field.Invalid(field.NewPath("spec").Child("nodePort"), r.NodePort, "cannot set node port when serviceType is ClusterIP"))
 	}
+	switch r.ExternalTrafficPolicy {
+	case "":
+	case corev1.ServiceExternalTrafficPolicyTypeCluster, corev1.ServiceExternalTrafficPolicyTypeLocal:
+		if r.ServiceType == corev1.ServiceTypeClusterIP {
+			errs = append(errs, field.Invalid(field.NewPath("spec").Child("externalTrafficPolicy"), r.ExternalTrafficPolicy, "cannot set external traffic policy when serviceType is ClusterIP"))
+		}
+	default:
+		errs = append(errs, field.NotSupported(field.NewPath("spec").Child("externalTrafficPolicy"), r.ExternalTrafficPolicy, []string{
+			string(corev1.ServiceExternalTrafficPolicyTypeCluster),
+			string(corev1.ServiceExternalTrafficPolicyTypeLocal),
+		}))
+	}
+	if r.SpillVolume != nil {
+		errs = append(errs, validateSpillVolume(r.SpillVolume, field.NewPath("spec").Child("spillVolume"))...)
+	}
+	errs = append(errs, validateExtraServiceArgs(r.ExtraServiceArgs, field.NewPath("spec").Child("extraServiceArgs"))...)
+	errs = append(errs, validateNameOverride(r.NameOverride, field.NewPath("spec").Child("nameOverride"))...)
+	errs = append(errs, validateSysctls(r.Sysctls, r.AllowUnsafeSysctls, field.NewPath("spec").Child("sysctls"))...)
+	errs = append(errs, validateVolumeMetadata(r.VolumeMetadata, field.NewPath("spec").Child("volumeMetadata"))...)
+	errs = append(errs, validateAntiAffinityPolicy(&r.PodSet, r.NodeSelector, field.NewPath("spec"))...)
+	if r.Frontend != nil {
+		errs = append(errs, r.Frontend.validate(field.NewPath("spec").Child("frontend"))...)
+	}
+	if r.ScaleStrategy != nil {
+		errs = append(errs, r.ScaleStrategy.validate(field.NewPath("spec").Child("scaleStrategy"))...)
+	}
+	errs = append(errs, validateNetworkAttachment(r.NetworkAttachment, field.NewPath("spec").Child("networkAttachment"))...)
+	errs = append(errs, validateHeadlessService(&r.PodSet, false, field.NewPath("spec").Child("headlessService"))...)
+	errs = append(errs, validateConfigFileNames(&r.PodSet, field.NewPath("spec"))...)
+	errs = append(errs, validateServiceAccountName(r.ServiceAccountName, field.NewPath("spec").Child("serviceAccountName"))...)
+	errs = append(errs, validateStuckPodPolicy(r.StuckPodPolicy, field.NewPath("spec").Child("stuckPodPolicy"))...)
 	return errs
 }
+
+func (f *FrontendConfig) validate(parent *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	if f.MaxMessageSize != nil && f.MaxMessageSize.Sign() <= 0 {
+		errs = append(errs, field.Invalid(parent.Child("maxMessageSize"), f.MaxMessageSize.String(), "must be positive"))
+	}
+	return errs
+}
+
+func (s *ScaleStrategy) validate(parent *field.Path) field.ErrorList {
+	v := s.MaxUnavailable
+	if v == nil {
+		return nil
+	}
+	path := parent.Child("maxUnavailable")
+	if v.Type == intstr.Int {
+		if v.IntVal < 1 {
+			return field.ErrorList{field.Invalid(path, v.IntVal, "must be positive")}
+		}
+		return nil
+	}
+	percent, err := intstr.GetScaledValueFromIntOrPercent(v, 100, false)
+	if err != nil {
+		return field.ErrorList{field.Invalid(path, v.StrVal, "must be an integer or a percentage (e.g. 10%)")}
+	}
+	if percent < 1 || percent > 100 {
+		return field.ErrorList{field.Invalid(path, v.StrVal, "must be a percentage between 1% and 100%")}
+	}
+	return nil
+}
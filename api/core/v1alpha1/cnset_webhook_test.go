@@ -18,6 +18,7 @@ import (
 	"context"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -55,4 +56,98 @@ var _ = Describe("CNSet Webhook", func() {
 		}
 		Expect(k8sClient.Create(context.TODO(), v06)).To(Succeed())
 	})
+	It("should validate sysctls", func() {
+		cn := &CNSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "cn-" + randomString(5),
+				Namespace: "default",
+			},
+			Spec: CNSetSpec{
+				CNSetBasic: CNSetBasic{
+					PodSet: PodSet{
+						Replicas: 2,
+						MainContainer: MainContainer{
+							Image: "test",
+						},
+						Sysctls: []corev1.Sysctl{{Name: "net.core.somaxconn", Value: "65535"}},
+					},
+				},
+			},
+			Deps: CNSetDeps{
+				LogSetRef: LogSetRef{
+					ExternalLogSet: &ExternalLogSet{
+						HAKeeperEndpoint: "test:32001",
+					},
+				},
+			},
+		}
+		Expect(k8sClient.Create(context.TODO(), cn)).ToNot(Succeed(), "unsafe sysctls must be explicitly allowed")
+		cn.Spec.Sysctls = []corev1.Sysctl{{Name: "vm.swappiness", Value: "0"}}
+		cn.Spec.AllowUnsafeSysctls = true
+		Expect(k8sClient.Create(context.TODO(), cn)).ToNot(Succeed(), "non-namespaced sysctls are not allowed")
+		cn.Spec.Sysctls = []corev1.Sysctl{{Name: "net.core.somaxconn", Value: "65535"}}
+		Expect(k8sClient.Create(context.TODO(), cn)).To(Succeed())
+	})
+	It("should validate command wrapper", func() {
+		cn := &CNSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "cn-" + randomString(5),
+				Namespace: "default",
+			},
+			Spec: CNSetSpec{
+				CNSetBasic: CNSetBasic{
+					PodSet: PodSet{
+						Replicas: 2,
+						MainContainer: MainContainer{
+							Image: "test",
+						},
+					},
+				},
+				Overlay: &Overlay{
+					MainContainerOverlay: MainContainerOverlay{
+						CommandWrapper: []string{"perf", "record", "--"},
+					},
+				},
+			},
+			Deps: CNSetDeps{
+				LogSetRef: LogSetRef{
+					ExternalLogSet: &ExternalLogSet{
+						HAKeeperEndpoint: "test:32001",
+					},
+				},
+			},
+		}
+		Expect(k8sClient.Create(context.TODO(), cn)).ToNot(Succeed(), "command wrapper must be an absolute path")
+		cn.Spec.Overlay.CommandWrapper[0] = "/usr/bin/perf"
+		Expect(k8sClient.Create(context.TODO(), cn)).To(Succeed())
+	})
+	It("should validate external traffic policy", func() {
+		cn := &CNSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "cn-" + randomString(5),
+				Namespace: "default",
+			},
+			Spec: CNSetSpec{
+				CNSetBasic: CNSetBasic{
+					PodSet: PodSet{
+						Replicas: 2,
+						MainContainer: MainContainer{
+							Image: "test",
+						},
+					},
+					ExternalTrafficPolicy: corev1.ServiceExternalTrafficPolicyTypeLocal,
+				},
+			},
+			Deps: CNSetDeps{
+				LogSetRef: LogSetRef{
+					ExternalLogSet: &ExternalLogSet{
+						HAKeeperEndpoint: "test:32001",
+					},
+				},
+			},
+		}
+		Expect(k8sClient.Create(context.TODO(), cn)).ToNot(Succeed(), "external traffic policy is not allowed for ClusterIP service")
+		cn.Spec.ServiceType = corev1.ServiceTypeLoadBalancer
+		Expect(k8sClient.Create(context.TODO(), cn)).To(Succeed())
+	})
 })
@@ -19,11 +19,18 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
 	"time"
 )
 
 const (
 	reasonEmpty = "empty"
+
+	// defaultNetworkInterface is the name of the first secondary interface attached by Multus
+	defaultNetworkInterface = "net1"
+
+	defaultImagePullDeadline = 10 * time.Minute
+	defaultStuckPodTimeout   = 5 * time.Minute
 )
 
 func (c *ConditionalStatus) SetCondition(condition metav1.Condition) {
@@ -80,7 +87,7 @@ func (o *Overlay) OverlayPodSpec(pod *corev1.PodSpec) {
 		})
 	}
 	if o.Affinity != nil {
-		pod.Affinity = o.Affinity
+		pod.Affinity = mergeAffinity(pod.Affinity, o.Affinity)
 	}
 	if o.ServiceAccountName != "" {
 		pod.ServiceAccountName = o.ServiceAccountName
@@ -91,9 +98,6 @@ func (o *Overlay) OverlayPodSpec(pod *corev1.PodSpec) {
 	if o.ImagePullSecrets != nil {
 		pod.ImagePullSecrets = o.ImagePullSecrets
 	}
-	if o.Affinity != nil {
-		pod.Affinity = o.Affinity
-	}
 	if o.Tolerations != nil {
 		pod.Tolerations = o.Tolerations
 	}
@@ -132,6 +136,39 @@ func (o *Overlay) OverlayPodSpec(pod *corev1.PodSpec) {
 	}
 }
 
+// mergeAffinity merges the overlay affinity to the generated one, the overlay wins for
+// node affinity and pod affinity while the pod anti-affinity terms of both are kept
+func mergeAffinity(generated, overlay *corev1.Affinity) *corev1.Affinity {
+	merged := overlay.DeepCopy()
+	if generated == nil {
+		return merged
+	}
+	if merged.NodeAffinity == nil {
+		merged.NodeAffinity = generated.NodeAffinity
+	}
+	if merged.PodAffinity == nil {
+		merged.PodAffinity = generated.PodAffinity
+	}
+	if anti := generated.PodAntiAffinity; anti != nil {
+		if merged.PodAntiAffinity == nil {
+			merged.PodAntiAffinity = &corev1.PodAntiAffinity{}
+		}
+		merged.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution = append(anti.RequiredDuringSchedulingIgnoredDuringExecution,
+			merged.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution...)
+		merged.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution = append(anti.PreferredDuringSchedulingIgnoredDuringExecution,
+			merged.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution...)
+	}
+	return merged
+}
+
+// GetAntiAffinityPolicy returns the anti-affinity policy of the set, or the given default if not set
+func (p *PodSet) GetAntiAffinityPolicy(d AntiAffinityPolicy) AntiAffinityPolicy {
+	if p.AntiAffinityPolicy == "" {
+		return d
+	}
+	return p.AntiAffinityPolicy
+}
+
 func (o *Overlay) OverlayMainContainer(c *corev1.Container) {
 	if o == nil {
 		return
@@ -142,9 +179,12 @@ func (o *Overlay) OverlayMainContainer(c *corev1.Container) {
 	}
 	if mc.Command != nil {
 		c.Command = mc.Command
+	} else if mc.CommandWrapper != nil {
+		c.Command = append(append([]string{}, mc.CommandWrapper...), c.Command...)
 	}
 	if mc.Args != nil {
-		c.Args = mc.Args
+		// keep the args generated from extraServiceArgs
+		c.Args = append(append([]string{}, c.Args...), mc.Args...)
 	}
 	if mc.EnvFrom != nil {
 		c.EnvFrom = mc.EnvFrom
@@ -160,9 +200,15 @@ func (o *Overlay) OverlayMainContainer(c *corev1.Container) {
 	if mc.LivenessProbe != nil {
 		c.LivenessProbe = mc.LivenessProbe
 	}
+	if mc.StartupProbe != nil {
+		c.StartupProbe = mc.StartupProbe
+	}
 	if mc.Lifecycle != nil {
 		c.Lifecycle = mc.Lifecycle
 	}
+	if mc.MainContainerSecurityContext != nil {
+		c.SecurityContext = mc.MainContainerSecurityContext
+	}
 	if mc.VolumeMounts != nil {
 		c.VolumeMounts = util.UpsertListByKey(c.VolumeMounts, o.VolumeMounts, func(v corev1.VolumeMount) string {
 			return v.Name
@@ -170,11 +216,31 @@ func (o *Overlay) OverlayMainContainer(c *corev1.Container) {
 	}
 }
 
+// SecurityContext returns the security context of the main container, nil if not hardened
+func (s *ContainerSecurityContext) SecurityContext() *corev1.SecurityContext {
+	if s == nil {
+		return nil
+	}
+	sc := &corev1.SecurityContext{
+		RunAsNonRoot:             pointer.Bool(true),
+		RunAsUser:                s.RunAsUser,
+		RunAsGroup:               s.RunAsGroup,
+		AllowPrivilegeEscalation: pointer.Bool(false),
+		Capabilities: &corev1.Capabilities{
+			Drop: []corev1.Capability{"ALL"},
+		},
+	}
+	if s.ReadOnlyRootFilesystem {
+		sc.ReadOnlyRootFilesystem = pointer.Bool(true)
+	}
+	return sc
+}
+
 func (s *FailoverStatus) StoresFailedFor(d time.Duration) []Store {
 	var stores []Store
 
 	for _, store := range s.FailedStores {
-		if time.Now().Sub(store.LastTransitionTime.Time) >= d {
+		if store.Forced || time.Now().Sub(store.LastTransitionTime.Time) >= d {
 			stores = append(stores, store)
 		}
 	}
@@ -182,6 +248,17 @@ func (s *FailoverStatus) StoresFailedFor(d time.Duration) []Store {
 	return stores
 }
 
+// Add accumulates the counts of the other summary, a nil summary is ignored
+func (s *PodSummary) Add(o *PodSummary) {
+	if o == nil {
+		return
+	}
+	s.Running += o.Running
+	s.Pending += o.Pending
+	s.Failed += o.Failed
+	s.Terminating += o.Terminating
+}
+
 func findMainContainer(containers []corev1.Container) *corev1.Container {
 	for _, c := range containers {
 		if c.Name == ContainerMain {
@@ -190,3 +267,41 @@ func findMainContainer(containers []corev1.Container) *corev1.Container {
 	}
 	return nil
 }
+
+func (p *PodSet) GetImagePullDeadline() time.Duration {
+	if p.ImagePullDeadline == nil {
+		return defaultImagePullDeadline
+	}
+	return p.ImagePullDeadline.Duration
+}
+
+func (p *PodSet) GetPublishNotReadyAddresses() bool {
+	if p.PublishNotReadyAddresses == nil {
+		return true
+	}
+	return *p.PublishNotReadyAddresses
+}
+
+// GetTimeout returns the timeout of a stuck pod, default to 5m
+func (p *StuckPodPolicy) GetTimeout() time.Duration {
+	if p.Timeout == nil {
+		return defaultStuckPodTimeout
+	}
+	return p.Timeout.Duration
+}
+
+// GetHeadlessClusterIP returns the cluster IP of the headless service of the set, default to None
+func (p *PodSet) GetHeadlessClusterIP() string {
+	if p.HeadlessService == nil || p.HeadlessService.ClusterIP == nil {
+		return corev1.ClusterIPNone
+	}
+	return *p.HeadlessService.ClusterIP
+}
+
+// GetInterface returns the network interface of the attachment, default to net1
+func (n *NetworkAttachment) GetInterface() string {
+	if n.Interface == "" {
+		return defaultNetworkInterface
+	}
+	return n.Interface
+}
@@ -15,6 +15,7 @@
 package v1alpha1
 
 import (
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -38,6 +39,32 @@ const (
 	ContainerMain = "main"
 )
 
+// AntiAffinityPolicy is the policy of the pod anti-affinity among the pods of a set
+type AntiAffinityPolicy string
+
+const (
+	// AntiAffinityPolicyRequired schedules the pods of a set to different nodes, pods will
+	// be pending if there are not enough nodes
+	AntiAffinityPolicyRequired AntiAffinityPolicy = "Required"
+	// AntiAffinityPolicyPreferred spreads the pods of a set to different nodes in best effort
+	AntiAffinityPolicyPreferred AntiAffinityPolicy = "Preferred"
+	// AntiAffinityPolicyNone generates no pod anti-affinity
+	AntiAffinityPolicyNone AntiAffinityPolicy = "None"
+)
+
+// PodUpdatePolicy is the policy to update pods during a rolling update
+type PodUpdatePolicy string
+
+const (
+	// PodUpdatePolicyInPlaceIfPossible updates the pods in place if possible, otherwise recreates the pods
+	PodUpdatePolicyInPlaceIfPossible PodUpdatePolicy = "InPlaceIfPossible"
+	// PodUpdatePolicyReCreate always recreates the pods
+	PodUpdatePolicyReCreate PodUpdatePolicy = "ReCreate"
+	// PodUpdatePolicyInPlaceOnly only updates the pods in place, changes that cannot be
+	// applied in place are rejected
+	PodUpdatePolicyInPlaceOnly PodUpdatePolicy = "InPlaceOnly"
+)
+
 type ConditionalStatus struct {
 	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
@@ -55,12 +82,153 @@ type PodSet struct {
 	// +optional
 	TopologyEvenSpread []string `json:"topologySpread,omitempty"`
 
+	// TopologySpreadPolicy tunes the topology spread constraints generated for TopologyEvenSpread,
+	// the constraints count the pods of this set only if set
+	// +optional
+	TopologySpreadPolicy *TopologySpreadPolicy `json:"topologySpreadPolicy,omitempty"`
+
 	// +optional
 	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
 
+	// ServiceAccountName is the name of the ServiceAccount to run the pods of this set, e.g. to access
+	// the shared storage with a cloud identity bound to the ServiceAccount.
+	// .overlay.serviceAccountName takes precedence if set.
+	// +optional
+	ServiceAccountName string `json:"serviceAccountName,omitempty"`
+
+	// AntiAffinityPolicy controls the pod anti-affinity among the pods of this set at node granularity,
+	// default to Preferred, not applicable to WebUI. Required must be set explicitly since the pods
+	// are pending if there are fewer nodes than replicas. The sets created by an operator version that
+	// does not generate the anti-affinity keep having none until the policy is set, so that upgrading
+	// the operator does not roll their pods. The generated anti-affinity is merged with .overlay.affinity
+	// +kubebuilder:validation:Enum=Required;Preferred;None
+	// +optional
+	AntiAffinityPolicy AntiAffinityPolicy `json:"antiAffinityPolicy,omitempty"`
+
+	// VolumeMetadata is added to the persistent volume claims generated for this set, e.g. to make
+	// the volumes discoverable by backup tools. Changes are not applied to the existing volume claims.
+	// Not applicable to WebUI
+	// +optional
+	VolumeMetadata *VolumeMetadata `json:"volumeMetadata,omitempty"`
+
+	// SeparateEntrypointConfigMap puts the generated entrypoint script into a dedicated ConfigMap
+	// instead of the ConfigMap of the config file, which makes the script inspectable on its own and
+	// lets the config and the entrypoint be rolled out independently.
+	// Not applicable to WebUI
+	// +optional
+	SeparateEntrypointConfigMap bool `json:"separateEntrypointConfigMap,omitempty"`
+
+	// ConfigFileName overrides the name of the config file, which is also its key in the ConfigMap,
+	// default to config.toml for CN and DN, and logservice.toml for LogService.
+	// Not applicable to WebUI
+	// +optional
+	ConfigFileName string `json:"configFileName,omitempty"`
+
+	// EntrypointFileName overrides the name of the entrypoint script, which is also its key in the
+	// ConfigMap, default to start.sh.
+	// Not applicable to WebUI
+	// +optional
+	EntrypointFileName string `json:"entrypointFileName,omitempty"`
+
+	// ContainerSecurityContext hardens the main container to run as a non-root user with all the
+	// capabilities dropped, .overlay.mainContainerSecurityContext takes precedence if set.
+	// Not applicable to WebUI
+	// +optional
+	ContainerSecurityContext *ContainerSecurityContext `json:"containerSecurityContext,omitempty"`
+
+	// TmpVolume mounts a writable emptyDir volume at /tmp of the main container, which is required
+	// by the entrypoint if the root filesystem is read-only.
+	// Not applicable to WebUI
+	// +optional
+	TmpVolume *TmpVolume `json:"tmpVolume,omitempty"`
+
+	// EphemeralStorage declares the ephemeral storage usage of the main container, so that the
+	// pods are not evicted unexpectedly when the node disk is under pressure.
+	// Not applicable to WebUI
+	// +optional
+	EphemeralStorage *EphemeralStorage `json:"ephemeralStorage,omitempty"`
+
+	// StuckPodPolicy force-deletes the pods stuck terminating on an unreachable node, so that the
+	// rolling-update is not blocked by a failed node. Disabled if not specified.
+	// Only applicable to CN and DN
+	// +optional
+	StuckPodPolicy *StuckPodPolicy `json:"stuckPodPolicy,omitempty"`
+
+	// DataDir is the name of the directory under the data volume that stores the local data of MO,
+	// default to "data". This is useful to keep the layout of an existing deployment when migrating
+	// it to the operator. Changing it on an existing set leaves the previous data unused.
+	// Not applicable to WebUI
+	// +optional
+	DataDir string `json:"dataDir,omitempty"`
+
+	// Sysctls are the namespaced sysctls set to the pods of this set, e.g. net.core.somaxconn.
+	// Unsafe sysctls must be allowed by the kubelet via --allowed-unsafe-sysctls, otherwise
+	// the pods will be rejected by the kubelet, and require AllowUnsafeSysctls to be set.
+	// This will be overridden by .overlay.securityContext
+	// +optional
+	Sysctls []corev1.Sysctl `json:"sysctls,omitempty"`
+
+	// AllowUnsafeSysctls explicitly allows unsafe sysctls in Sysctls, which requires
+	// the unsafe sysctls to be allowed by the kubelet of the nodes
+	// +optional
+	AllowUnsafeSysctls bool `json:"allowUnsafeSysctls,omitempty"`
+
 	// Config is the raw config for pods
 	Config *TomlConfig `json:"config,omitempty"`
 
+	// UpdateStrategyType is the update strategy type of the StatefulSet of this set,
+	// with OnDelete, changes are only applied to a pod when the pod is deleted manually.
+	// Default to RollingUpdate, not applicable to WebUI.
+	// +kubebuilder:validation:Enum=RollingUpdate;OnDelete
+	// +optional
+	UpdateStrategyType appsv1.StatefulSetUpdateStrategyType `json:"updateStrategyType,omitempty"`
+
+	// PodUpdatePolicy is how the pods are updated in a rolling update, updating pods in place
+	// (e.g. image upgrades) preserves the volumes and the cache of the pods.
+	// The pods are not gated by the InPlaceUpdateReady readiness gate under ReCreate, the CN
+	// and DN pods are gated by the matrixorigin.io/serving condition instead so that they can
+	// still be drained.
+	// Default to InPlaceIfPossible, not applicable to WebUI.
+	// +kubebuilder:validation:Enum=InPlaceIfPossible;ReCreate;InPlaceOnly
+	// +optional
+	PodUpdatePolicy PodUpdatePolicy `json:"podUpdatePolicy,omitempty"`
+
+	// RevisionHistoryLimit is the number of the old revisions of the StatefulSet (or the Deployment
+	// of WebUI) of this set to retain for rollback, default to 10
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	RevisionHistoryLimit *int32 `json:"revisionHistoryLimit,omitempty"`
+
+	// ImagePullDeadline is how long the containers of a scheduled pod may wait for their images
+	// before the ImagePullSlow condition of this set is set to True, default to 10m.
+	// Image pull errors are reported by the ImagePullFailed condition regardless of the deadline.
+	// +optional
+	ImagePullDeadline *metav1.Duration `json:"imagePullDeadline,omitempty"`
+
+	// ExtraServiceArgs are extra arguments appended to the command line of the MO service
+	// after the operator generated arguments, e.g. ["-debug-http=:6060"]
+	// +optional
+	ExtraServiceArgs []string `json:"extraServiceArgs,omitempty"`
+
+	// PublishNotReadyAddresses controls whether the headless service of this set publishes
+	// the addresses of not-ready pods, pods can resolve each other before being ready if enabled.
+	// Default to true, not applicable to WebUI.
+	// +optional
+	PublishNotReadyAddresses *bool `json:"publishNotReadyAddresses,omitempty"`
+
+	// HeadlessService customizes the networking of the headless service of this set for the CNI
+	// or service mesh setups that require non-default service settings, not applicable to WebUI
+	// +optional
+	HeadlessService *HeadlessServicePolicy `json:"headlessService,omitempty"`
+
+	// NameOverride overrides the base name of the resources generated for this set,
+	// default to <name>-<component>. Generated names that exceed the Kubernetes length
+	// limits of their kinds are truncated and appended with a hash. Must be unique among the
+	// sets of a MatrixOneCluster. Immutable after creation.
+	// +kubebuilder:validation:MaxLength=63
+	// +optional
+	NameOverride string `json:"nameOverride,omitempty"`
+
 	// If enabled, use the Pod dns name as the Pod identity
 	DNSBasedIdentity bool `json:"dnsBasedIdentity,omitempty"`
 
@@ -69,6 +237,21 @@ type PodSet struct {
 	ClusterDomain string `json:"clusterDomain,omitempty"`
 }
 
+// HeadlessServicePolicy customizes the service that the pods of a set are discovered by
+type HeadlessServicePolicy struct {
+	// ClusterIP is the cluster IP of the service, default to None which makes the service headless.
+	// An empty string makes it a regular service with an allocated cluster IP, a regular service does
+	// not publish the DNS records of the pods and thus can not be used with dnsBasedIdentity or by
+	// LogSet. Immutable after creation.
+	// +optional
+	ClusterIP *string `json:"clusterIP,omitempty"`
+
+	// IPFamilyPolicy is the IP family policy of the service
+	// +kubebuilder:validation:Enum=SingleStack;PreferDualStack;RequireDualStack
+	// +optional
+	IPFamilyPolicy *corev1.IPFamilyPolicy `json:"ipFamilyPolicy,omitempty"`
+}
+
 // MainContainer is the description of the main container of a Pod
 type MainContainer struct {
 	// Image is the docker image of the main container
@@ -83,6 +266,14 @@ type MainContainerOverlay struct {
 	// +optional
 	Command []string `json:"command,omitempty"`
 
+	// CommandWrapper is prepended to the command of the main container to wrap the generated
+	// entrypoint, e.g. running MO under a profiler. The first element must be an absolute path.
+	// Ignored if command is set.
+	// +optional
+	CommandWrapper []string `json:"commandWrapper,omitempty"`
+
+	// Args are appended to the args of the main container, which are generated from
+	// extraServiceArgs of the set
 	// +optional
 	Args []string `json:"args,omitempty"`
 
@@ -113,6 +304,10 @@ type MainContainerOverlay struct {
 
 	// +optional
 	Lifecycle *corev1.Lifecycle `json:"lifecycle,omitempty"`
+
+	// MainContainerSecurityContext replaces the security context of the main container
+	// +optional
+	MainContainerSecurityContext *corev1.SecurityContext `json:"mainContainerSecurityContext,omitempty"`
 }
 
 // Overlay allows advanced customization of the pod spec in the set
@@ -185,6 +380,118 @@ type Volume struct {
 	MemoryCacheSize *resource.Quantity `json:"memoryCacheSize,omitempty"`
 }
 
+// ContainerSecurityContext runs the main container as a non-root user, drops all the
+// capabilities and disallows privilege escalation
+type ContainerSecurityContext struct {
+	// RunAsUser is the UID to run the main container, must not be 0. The user of the image
+	// is used if not specified, which must be a numeric non-root user
+	// +optional
+	RunAsUser *int64 `json:"runAsUser,omitempty"`
+
+	// RunAsGroup is the GID to run the main container, the group of the image is used if not specified
+	// +optional
+	RunAsGroup *int64 `json:"runAsGroup,omitempty"`
+
+	// ReadOnlyRootFilesystem mounts the root filesystem of the main container as read-only,
+	// the entrypoint writes temporary files so .tmpVolume must be set or a writable volume must be
+	// mounted at /tmp through the overlay
+	// +optional
+	ReadOnlyRootFilesystem bool `json:"readOnlyRootFilesystem,omitempty"`
+}
+
+// TmpVolume is the writable emptyDir volume mounted at /tmp of the main container
+type TmpVolume struct {
+	// Medium is the storage medium of the volume, Memory mounts a tmpfs which counts against
+	// the memory limit of the container. The node storage is used if not specified
+	// +kubebuilder:validation:Enum="";Memory
+	// +optional
+	Medium corev1.StorageMedium `json:"medium,omitempty"`
+
+	// SizeLimit is the size limit of the volume, required if the medium is Memory
+	// +optional
+	SizeLimit *resource.Quantity `json:"sizeLimit,omitempty"`
+}
+
+// StuckPodPolicy is the policy to force-delete the pods stuck terminating. A force-deleted pod is removed
+// from the API server without the confirmation of the kubelet, the containers may keep running if the
+// node recovers, so only the pods on a node that is gone or has stopped reporting for a while are deleted.
+type StuckPodPolicy struct {
+	// Timeout is how long a pod must have been terminating, and its node unreachable, before the pod is
+	// force-deleted, must not be less than 1m, default to 5m
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+}
+
+// NetworkAttachment is a secondary network of the pods, which is attached by Multus
+type NetworkAttachment struct {
+	// Name is the name of the NetworkAttachmentDefinition, in the form of [namespace/]name
+	// +required
+	Name string `json:"name"`
+
+	// Interface is the name of the network interface in the pods, default to net1. The address of
+	// the interface is resolved by the ip command of the image on startup
+	// +optional
+	Interface string `json:"interface,omitempty"`
+}
+
+// EphemeralStorage is the default ephemeral storage request and limit of the main container
+type EphemeralStorage struct {
+	// Request is the ephemeral-storage request of the main container, the request in
+	// .resources takes precedence
+	// +optional
+	Request *resource.Quantity `json:"request,omitempty"`
+
+	// Limit is the ephemeral-storage limit of the main container, the limit in .resources takes
+	// precedence. The tmp volume on the node storage is bounded by the limit if its sizeLimit
+	// is not specified.
+	// +optional
+	Limit *resource.Quantity `json:"limit,omitempty"`
+}
+
+// TopologySpreadPolicy tunes the topology spread constraints of a set
+type TopologySpreadPolicy struct {
+	// MinDomains is the minimum number of domains of each topology key that the pods must spread
+	// across, e.g. 3 to spread a small set across 3 zones even if there are only 3 replicas.
+	// Requires WhenUnsatisfiable to be DoNotSchedule and the MinDomainsInPodTopologySpread
+	// feature gate of the cluster
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MinDomains *int32 `json:"minDomains,omitempty"`
+
+	// WhenUnsatisfiable is how the pods are scheduled if the spread cannot be satisfied,
+	// default to DoNotSchedule
+	// +kubebuilder:validation:Enum=DoNotSchedule;ScheduleAnyway
+	// +optional
+	WhenUnsatisfiable corev1.UnsatisfiableConstraintAction `json:"whenUnsatisfiable,omitempty"`
+}
+
+// VolumeMetadata is the metadata added to the persistent volume claims of a set
+type VolumeMetadata struct {
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// SpillVolume describes the node-local volume used to spill temporary data
+type SpillVolume struct {
+	// EmptyDir uses an emptyDir volume as the spill volume,
+	// mutual exclusive with HostPath
+	// +optional
+	EmptyDir *corev1.EmptyDirVolumeSource `json:"emptyDir,omitempty"`
+
+	// HostPath uses a directory on the node as the spill volume,
+	// mutual exclusive with EmptyDir, requires AllowHostPath to be true
+	// +optional
+	HostPath *corev1.HostPathVolumeSource `json:"hostPath,omitempty"`
+
+	// AllowHostPath explicitly allows the HostPath spill volume, since the Pods will
+	// have write access to the node filesystem
+	// +optional
+	AllowHostPath bool `json:"allowHostPath,omitempty"`
+}
+
 type SharedStorageProvider struct {
 	// S3 specifies an S3 bucket as the shared storage provider,
 	// mutual-exclusive with other providers.
@@ -226,6 +533,10 @@ type S3Provider struct {
 	// from the environment if not specified
 	// +optional
 	SecretRef *corev1.LocalObjectReference `json:"secretRef,omitempty"`
+	// CABundleSecretRef selects a PEM encoded CA bundle in a Secret, which is trusted in addition to
+	// the system CAs when connecting to the endpoint, e.g. a MinIO with a self-signed certificate
+	// +optional
+	CABundleSecretRef *corev1.SecretKeySelector `json:"caBundleSecretRef,omitempty"`
 }
 
 func (p *S3Provider) GetProviderType() S3ProviderType {
@@ -255,10 +566,27 @@ type ExternalLogSet struct {
 	// HAKeeperEndpoint of the ExternalLogSet
 	// +required
 	HAKeeperEndpoint string `json:"haKeeperEndpoint,omitempty"`
+
+	// WaitForReady holds the reconciliation of the set until the HAKeeperEndpoint is reachable,
+	// so that the pods are not bootstrapped against an unhealthy LogSet
+	// +optional
+	WaitForReady bool `json:"waitForReady,omitempty"`
 }
 
+// ForceFailoverAnnotation marks the store of the given pod name as failed and triggers the failover
+// of it immediately, which bypasses the store failure timeout. The annotation takes no effect once
+// the store is failed over and can be removed then.
+const ForceFailoverAnnotation = "matrixorigin.io/force-failover"
+
+// DrainAnnotation set to "true" on a CN or DN pod marks the pod not ready so that it is removed from
+// the service endpoints and receives no new connections, the pod is not considered failed meanwhile.
+// Removing the annotation makes the pod ready again.
+const DrainAnnotation = "matrixorigin.io/drain"
+
 type FailoverStatus struct {
 	AvailableStores []Store `json:"availableStores,omitempty"`
+	// SuspectedStores are the stores that are down but not yet confirmed to be failed
+	SuspectedStores []Store `json:"suspectedStores,omitempty"`
 	FailedStores    []Store `json:"failedStores,omitempty"`
 }
 
@@ -266,4 +594,18 @@ type Store struct {
 	PodName            string      `json:"podName,omitempty"`
 	Phase              string      `json:"phase,omitempty"`
 	LastTransitionTime metav1.Time `json:"lastTransition,omitempty"`
+	// Forced indicates the store is marked as failed by the ForceFailoverAnnotation
+	Forced bool `json:"forced,omitempty"`
+}
+
+// PodSummary counts the pods of a component by their phases
+type PodSummary struct {
+	// Running is the number of running pods
+	Running int32 `json:"running"`
+	// Pending is the number of pending pods, including the pods that are pulling images
+	Pending int32 `json:"pending"`
+	// Failed is the number of failed pods
+	Failed int32 `json:"failed"`
+	// Terminating is the number of pods that are being deleted
+	Terminating int32 `json:"terminating"`
 }
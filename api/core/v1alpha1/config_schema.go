@@ -0,0 +1,134 @@
+// Copyright 2023 Matrix Origin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	"sort"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+const (
+	// SkipConfigValidationAnnotation disables the validation of the config against the known MO config
+	// schema when set to "true", which is useful to try experimental config keys
+	SkipConfigValidationAnnotation = "matrixorigin.io/skip-config-validation"
+)
+
+// configSchema describes the known keys of the MO config, a nil schema of a key means
+// the value of the key is not further validated
+type configSchema map[string]configSchema
+
+// with returns a copy of the schema with the key added
+func (s configSchema) with(key string, sub configSchema) configSchema {
+	res := configSchema{key: sub}
+	for k, v := range s {
+		res[k] = v
+	}
+	return res
+}
+
+// baseConfigSchema is the schema of the MO config read by all the MO versions supported by this
+// operator. The service sections are allowed for all the services since a service may read the
+// sections of others (e.g. [cn.Engine] for DN).
+var baseConfigSchema = configSchema{
+	"service-type": nil,
+	"data-dir":     nil,
+	"log": {
+		"level":         nil,
+		"format":        nil,
+		"filename":      nil,
+		"max-size":      nil,
+		"max-days":      nil,
+		"max-backups":   nil,
+		"disable-store": nil,
+	},
+	"hakeeper-client": {
+		"service-addresses": nil,
+		"discovery-address": nil,
+		"allocate-id-batch": nil,
+	},
+	"fileservice":   nil,
+	"observability": nil,
+	"clock":         nil,
+	"limit":         nil,
+	"metacache":     nil,
+	"dn":            nil,
+	"cn":            nil,
+	"logservice":    nil,
+}
+
+// moConfigSchemas are the schemas of the MO config keyed by the first minor series of MO that
+// reads them, a version takes the schema of the latest series it belongs to
+var moConfigSchemas = map[string]configSchema{
+	"0.0": baseConfigSchema,
+	// the proxy service is introduced in 1.1
+	"1.1": baseConfigSchema.with("proxy", nil),
+}
+
+// configSchemaOf returns the config schema of the MO version, versions that are not semantic
+// (e.g. nightly builds) take the schema of the latest series
+func configSchemaOf(version string) configSchema {
+	var listed []string
+	for s := range moConfigSchemas {
+		listed = append(listed, s)
+	}
+	return moConfigSchemas[latestSeriesOf(version, listed)]
+}
+
+// validateConfig validates the keys of the config against the config schema of the MO version of
+// the image, the validation is skipped if the SkipConfigValidationAnnotation of the object is set
+func validateConfig(c *TomlConfig, image string, meta metav1.ObjectMeta, parent *field.Path) field.ErrorList {
+	if c == nil || meta.Annotations[SkipConfigValidationAnnotation] == "true" {
+		return nil
+	}
+	return validateConfigKeys(c.MP, configSchemaOf(imageTag(image)), parent)
+}
+
+func validateConfigKeys(m map[string]interface{}, schema configSchema, parent *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		path := parent.Child(k)
+		sub, ok := schema[k]
+		if !ok {
+			errs = append(errs, field.NotSupported(path, k, knownConfigKeys(schema)))
+			continue
+		}
+		if sub == nil {
+			continue
+		}
+		nested, ok := m[k].(map[string]interface{})
+		if !ok {
+			errs = append(errs, field.Invalid(path, m[k], "must be a table"))
+			continue
+		}
+		errs = append(errs, validateConfigKeys(nested, sub, path)...)
+	}
+	return errs
+}
+
+func knownConfigKeys(schema configSchema) []string {
+	var keys []string
+	for k := range schema {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -15,10 +15,20 @@
 package v1alpha1
 
 import (
+	"bytes"
+	"text/template"
+
 	recon "github.com/matrixorigin/controller-runtime/pkg/reconciler"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+const (
+	// defaultStartupProbeFailureThreshold and defaultStartupProbePeriodSeconds allow DN 30 minutes
+	// to recover from the WAL by default
+	defaultStartupProbeFailureThreshold = 180
+	defaultStartupProbePeriodSeconds    = 10
+)
+
 type DNSetSpec struct {
 	DNSetBasic `json:",inline"`
 
@@ -34,12 +44,137 @@ type DNSetBasic struct {
 	// +optional
 	CacheVolume *Volume `json:"cacheVolume,omitempty"`
 
+	// DataVolume is the desired volume for the local data of DNSet, separated from
+	// the cache volume, the local data shares the cache volume if not specified
+	// +optional
+	DataVolume *Volume `json:"dataVolume,omitempty"`
+
 	SharedStorageCache SharedStorageCache `json:"sharedStorageCache,omitempty"`
+
+	// StartupProbe tunes the startup probe of DN, which gives DN time to recover from the WAL
+	// before the liveness probe kicks in. The probe is replaced if .overlay.startupProbe is set.
+	// The sets created by an operator version that does not generate the probe have no probe until
+	// this field is set, so that upgrading the operator does not roll their pods
+	// +optional
+	StartupProbe *StartupProbe `json:"startupProbe,omitempty"`
+
+	// LockService tunes the lock service of DN, the MO built-in values are used if not specified
+	// +optional
+	LockService *LockServiceConfig `json:"lockService,omitempty"`
+
+	// Compaction tunes the background checkpoint of DN, which flushes the in-memory data and compacts
+	// the flushed blocks, the MO built-in values are used if not specified
+	// +optional
+	Compaction *CompactionConfig `json:"compaction,omitempty"`
+
+	// NetworkAttachment attaches the pods to a secondary network through Multus, the DN
+	// advertises its address on the secondary network so that the data-plane traffic goes through it
+	// +optional
+	NetworkAttachment *NetworkAttachment `json:"networkAttachment,omitempty"`
+
+	// AdvertiseAddressTemplate overrides the address that the DN advertises to the other components,
+	// which defaults to the DNS name of the pod (qualified by .clusterDomain if set). It is a go template
+	// rendered for each pod with the fields .PodName, .Ordinal and .Namespace, e.g.
+	// "dn-{{ .Ordinal }}.mo.example.com". The rendered address must be resolvable from the other
+	// components. Conflicts with networkAttachment.
+	// +optional
+	AdvertiseAddressTemplate string `json:"advertiseAddressTemplate,omitempty"`
+}
+
+// AdvertiseAddressVars are the fields available to the advertise address template
+type AdvertiseAddressVars struct {
+	PodName   string
+	Ordinal   string
+	Namespace string
+}
+
+// RenderAdvertiseAddress renders the advertise address template with the given fields
+func RenderAdvertiseAddress(tpl string, vars AdvertiseAddressVars) (string, error) {
+	t, err := template.New("advertise-address").Option("missingkey=error").Parse(tpl)
+	if err != nil {
+		return "", err
+	}
+	buf := new(bytes.Buffer)
+	if err := t.Execute(buf, vars); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// LockServiceConfig tunes the keep-alive and timeouts of the lock service
+type LockServiceConfig struct {
+	// KeepBindInterval is the interval to keep the binding of the lock tables alive, e.g. 1s
+	// +optional
+	KeepBindInterval *metav1.Duration `json:"keepBindInterval,omitempty"`
+
+	// KeepRemoteLockInterval is the interval to keep the remote locks alive, must be less
+	// than RemoteLockTimeout if both are set
+	// +optional
+	KeepRemoteLockInterval *metav1.Duration `json:"keepRemoteLockInterval,omitempty"`
+
+	// RemoteLockTimeout is the time after which a remote lock is released if it is not kept alive
+	// +optional
+	RemoteLockTimeout *metav1.Duration `json:"remoteLockTimeout,omitempty"`
+}
+
+// CompactionConfig throttles the background flush and compaction of DN against the foreground
+// workload, a larger interval or count means less frequent but larger background tasks
+type CompactionConfig struct {
+	// FlushInterval is the max interval to flush the in-memory data, must be in range [1s, 1h]
+	// +optional
+	FlushInterval *metav1.Duration `json:"flushInterval,omitempty"`
+
+	// ScanInterval is the interval to scan the dirty blocks to be flushed and compacted, must be
+	// in range [1s, 1h] and no more than FlushInterval if both are set
+	// +optional
+	ScanInterval *metav1.Duration `json:"scanInterval,omitempty"`
+
+	// MinCount is the min number of dirty blocks to trigger a background flush before the
+	// FlushInterval is reached, must be in range [1, 10000]
+	// +optional
+	MinCount *int32 `json:"minCount,omitempty"`
+}
+
+// StartupProbe tunes the generated startup probe of the main container, the container is
+// restarted if it has not started after failureThreshold * periodSeconds
+type StartupProbe struct {
+	// FailureThreshold is the number of consecutive failures of the probe before the container
+	// is restarted, default to 180
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	FailureThreshold *int32 `json:"failureThreshold,omitempty"`
+
+	// PeriodSeconds is the interval of the probe in seconds, default to 10
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	PeriodSeconds *int32 `json:"periodSeconds,omitempty"`
+}
+
+func (p *StartupProbe) GetFailureThreshold() int32 {
+	if p == nil || p.FailureThreshold == nil {
+		return defaultStartupProbeFailureThreshold
+	}
+	return *p.FailureThreshold
+}
+
+func (p *StartupProbe) GetPeriodSeconds() int32 {
+	if p == nil || p.PeriodSeconds == nil {
+		return defaultStartupProbePeriodSeconds
+	}
+	return *p.PeriodSeconds
 }
 
 type DNSetStatus struct {
 	ConditionalStatus `json:",inline"`
 	FailoverStatus    `json:",inline"`
+
+	// ResolvedImage is the image of the main container in the DN StatefulSet, which may
+	// differ from spec.image until the rolling update of the DN stores completes
+	ResolvedImage string `json:"resolvedImage,omitempty"`
+
+	// PodSummary counts the pods of this set by their phases
+	// +optional
+	PodSummary *PodSummary `json:"podSummary,omitempty"`
 }
 
 type DNSetDeps struct {
@@ -49,6 +184,7 @@ type DNSetDeps struct {
 // +kubebuilder:object:root=true
 // +kubebuilder:printcolumn:name="Image",type="string",JSONPath=".spec.image"
 // +kubebuilder:printcolumn:name="Replicas",type="integer",JSONPath=".spec.replicas"
+// +kubebuilder:printcolumn:name="Running",type="integer",JSONPath=".status.podSummary.running"
 // +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
 
 // A DNSet is a resource that represents a set of MO's DN instances
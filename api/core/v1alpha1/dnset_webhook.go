@@ -15,28 +15,41 @@
 package v1alpha1
 
 import (
+	"fmt"
+	"net"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	ctrl "sigs.k8s.io/controller-runtime"
-	"sigs.k8s.io/controller-runtime/pkg/webhook"
 )
 
-func (r *DNSet) setupWebhookWithManager(mgr ctrl.Manager) error {
+const (
+	minCompactionInterval = time.Second
+	maxCompactionInterval = time.Hour
+	maxCompactionMinCount = 10000
+)
+
+func (r *DNSet) setupWebhookWithManager(mgr ctrl.Manager, w *policyWebhook) error {
 	return ctrl.NewWebhookManagedBy(mgr).
 		For(r).
+		WithDefaulter(w).
+		WithValidator(w).
 		Complete()
 }
 
 // +kubebuilder:webhook:path=/mutate-core-matrixorigin-io-v1alpha1-dnset,mutating=true,failurePolicy=fail,sideEffects=None,groups=core.matrixorigin.io,resources=dnsets,verbs=create;update,versions=v1alpha1,name=mdnset.kb.io,admissionReviewVersions={v1,v1beta1}
 
-var _ webhook.Defaulter = &DNSet{}
+var _ policyObject = &DNSet{}
 
-// Default implements webhook.Defaulter so a webhook will be registered for the type
-func (r *DNSet) Default() {
-	r.Spec.DNSetBasic.Default()
+// Default implements policyObject so the type is defaulted by the policyWebhook
+func (r *DNSet) Default(p *WebhookPolicy) {
+	r.Spec.DNSetBasic.Default(p)
 }
 
-func (r *DNSetBasic) Default() {
+func (r *DNSetBasic) Default(p *WebhookPolicy) {
 	if r.Resources.Requests.Memory() != nil && r.SharedStorageCache.MemoryCacheSize == nil {
 		// default memory cache size to 50% request memory
 		size := r.Resources.Requests.Memory().DeepCopy()
@@ -44,39 +57,131 @@ func (r *DNSetBasic) Default() {
 		r.SharedStorageCache.MemoryCacheSize = &size
 	}
 	if r.CacheVolume != nil && r.SharedStorageCache.DiskCacheSize == nil {
-		// default disk cache size to the cache volume size
-		r.SharedStorageCache.DiskCacheSize = &r.CacheVolume.Size
+		// default disk cache size to the cache volume size minus the headroom
+		r.SharedStorageCache.DiskCacheSize = defaultDiskCacheSize(r.CacheVolume.Size, p)
 	}
 }
 
 // +kubebuilder:webhook:path=/validate-core-matrixorigin-io-v1alpha1-dnset,mutating=false,failurePolicy=fail,sideEffects=None,groups=core.matrixorigin.io,resources=dnsets,verbs=create;update,versions=v1alpha1,name=vdnset.kb.io,admissionReviewVersions={v1,v1beta1}
 
-var _ webhook.Validator = &DNSet{}
+// ValidateCreate implements policyObject so the type is validated by the policyWebhook
+func (r *DNSet) ValidateCreate(p *WebhookPolicy) error {
+	errs := r.validate()
+	errs = append(errs, validateCacheSize(r.Spec.Image, cacheSizeOf(r.Spec.CacheVolume, &r.Spec.SharedStorageCache), nil, r.ObjectMeta, p, field.NewPath("spec"))...)
+	return invalidOrNil(errs, r)
+}
+
+func (r *DNSet) ValidateUpdate(o runtime.Object, p *WebhookPolicy) error {
+	old := o.(*DNSet)
+	errs := r.validate()
+	errs = append(errs, validateCacheSize(r.Spec.Image, cacheSizeOf(r.Spec.CacheVolume, &r.Spec.SharedStorageCache),
+		cacheSizeOf(old.Spec.CacheVolume, &old.Spec.SharedStorageCache), r.ObjectMeta, p, field.NewPath("spec"))...)
+	errs = append(errs, validateNameOverrideUpdate(r.Spec.NameOverride, old.Spec.NameOverride, field.NewPath("spec").Child("nameOverride"))...)
+	errs = append(errs, validateHeadlessServiceUpdate(&r.Spec.PodSet, &old.Spec.PodSet, field.NewPath("spec").Child("headlessService"))...)
+	errs = append(errs, validateForceFailover(r.ObjectMeta, old.ObjectMeta, &old.Status.FailoverStatus)...)
+	return invalidOrNil(errs, r)
+}
 
-// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
-func (r *DNSet) ValidateCreate() error {
+func (r *DNSet) validate() field.ErrorList {
 	var errs field.ErrorList
 	errs = append(errs, validateLogSetRef(&r.Deps.LogSetRef, field.NewPath("deps"))...)
 	errs = append(errs, r.Spec.DNSetBasic.ValidateCreate()...)
 	errs = append(errs, validateMainContainer(&r.Spec.MainContainer, field.NewPath("spec"))...)
-	return invalidOrNil(errs, r)
+	errs = append(errs, validateOverlay(r.Spec.Overlay, field.NewPath("spec").Child("overlay"))...)
+	errs = append(errs, r.Spec.PodSet.validate(r.Spec.Overlay, r.Spec.TopologyEvenSpread, field.NewPath("spec"))...)
+	errs = append(errs, validateConfig(r.Spec.Config, r.Spec.Image, r.ObjectMeta, field.NewPath("spec").Child("config"))...)
+	return errs
 }
 
-func (r *DNSet) ValidateUpdate(old runtime.Object) error {
-	if err := r.ValidateCreate(); err != nil {
-		return err
+func (r *DNSetBasic) ValidateCreate() field.ErrorList {
+	var errs field.ErrorList
+	if r.CacheVolume != nil {
+		errs = append(errs, validateVolume(r.CacheVolume, field.NewPath("spec").Child("cacheVolume"))...)
+	}
+	if r.DataVolume != nil {
+		errs = append(errs, validateVolume(r.DataVolume, field.NewPath("spec").Child("dataVolume"))...)
 	}
-	return nil
+	errs = append(errs, validateExtraServiceArgs(r.ExtraServiceArgs, field.NewPath("spec").Child("extraServiceArgs"))...)
+	errs = append(errs, validateNameOverride(r.NameOverride, field.NewPath("spec").Child("nameOverride"))...)
+	errs = append(errs, validateSysctls(r.Sysctls, r.AllowUnsafeSysctls, field.NewPath("spec").Child("sysctls"))...)
+	errs = append(errs, validateVolumeMetadata(r.VolumeMetadata, field.NewPath("spec").Child("volumeMetadata"))...)
+	errs = append(errs, validateAntiAffinityPolicy(&r.PodSet, r.NodeSelector, field.NewPath("spec"))...)
+	if r.LockService != nil {
+		errs = append(errs, r.LockService.validate(field.NewPath("spec").Child("lockService"))...)
+	}
+	if r.Compaction != nil {
+		errs = append(errs, r.Compaction.validate(field.NewPath("spec").Child("compaction"))...)
+	}
+	errs = append(errs, validateNetworkAttachment(r.NetworkAttachment, field.NewPath("spec").Child("networkAttachment"))...)
+	errs = append(errs, validateHeadlessService(&r.PodSet, false, field.NewPath("spec").Child("headlessService"))...)
+	errs = append(errs, validateConfigFileNames(&r.PodSet, field.NewPath("spec"))...)
+	errs = append(errs, validateServiceAccountName(r.ServiceAccountName, field.NewPath("spec").Child("serviceAccountName"))...)
+	errs = append(errs, validateStuckPodPolicy(r.StuckPodPolicy, field.NewPath("spec").Child("stuckPodPolicy"))...)
+	errs = append(errs, r.validateAdvertiseAddress()...)
+	return errs
 }
 
-func (r *DNSet) ValidateDelete() error {
-	return nil
+func (c *CompactionConfig) validate(parent *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	inRange := func(d *metav1.Duration, path *field.Path) {
+		if d != nil && (d.Duration < minCompactionInterval || d.Duration > maxCompactionInterval) {
+			errs = append(errs, field.Invalid(path, d.Duration.String(), fmt.Sprintf("must be in range [%s, %s]", minCompactionInterval, maxCompactionInterval)))
+		}
+	}
+	inRange(c.FlushInterval, parent.Child("flushInterval"))
+	inRange(c.ScanInterval, parent.Child("scanInterval"))
+	if c.FlushInterval != nil && c.ScanInterval != nil && c.ScanInterval.Duration > c.FlushInterval.Duration {
+		errs = append(errs, field.Invalid(parent.Child("scanInterval"), c.ScanInterval.Duration.String(), "must be no more than flushInterval"))
+	}
+	if c.MinCount != nil && (*c.MinCount < 1 || *c.MinCount > maxCompactionMinCount) {
+		errs = append(errs, field.Invalid(parent.Child("minCount"), *c.MinCount, fmt.Sprintf("must be in range [1, %d]", maxCompactionMinCount)))
+	}
+	return errs
 }
 
-func (r *DNSetBasic) ValidateCreate() field.ErrorList {
+// validateAdvertiseAddress validates that the advertised address renders to a DNS name or an IP address,
+// which also keeps the rendered address safe to be embedded in the entrypoint script
+func (r *DNSetBasic) validateAdvertiseAddress() field.ErrorList {
 	var errs field.ErrorList
-	if r.CacheVolume != nil {
-		errs = append(errs, validateVolume(r.CacheVolume, field.NewPath("spec").Child("cacheVolume"))...)
+	if r.ClusterDomain != "" {
+		for _, msg := range validation.IsDNS1123Subdomain(r.ClusterDomain) {
+			errs = append(errs, field.Invalid(field.NewPath("spec").Child("clusterDomain"), r.ClusterDomain, msg))
+		}
+	}
+	tpl := r.AdvertiseAddressTemplate
+	if tpl == "" {
+		return errs
+	}
+	path := field.NewPath("spec").Child("advertiseAddressTemplate")
+	if r.NetworkAttachment != nil {
+		return append(errs, field.Invalid(path, tpl, "advertiseAddressTemplate conflicts with networkAttachment"))
+	}
+	addr, err := RenderAdvertiseAddress(tpl, AdvertiseAddressVars{PodName: "mo-dn-0", Ordinal: "0", Namespace: "default"})
+	if err != nil {
+		return append(errs, field.Invalid(path, tpl, fmt.Sprintf("invalid template: %v", err)))
+	}
+	if net.ParseIP(addr) != nil {
+		return errs
+	}
+	for _, msg := range validation.IsDNS1123Subdomain(addr) {
+		errs = append(errs, field.Invalid(path, tpl, fmt.Sprintf("rendered address %q is neither an IP address nor a DNS name: %s", addr, msg)))
+	}
+	return errs
+}
+
+func (l *LockServiceConfig) validate(parent *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	if l.KeepBindInterval != nil && l.KeepBindInterval.Duration <= 0 {
+		errs = append(errs, field.Invalid(parent.Child("keepBindInterval"), l.KeepBindInterval.Duration.String(), "must be positive"))
+	}
+	if l.KeepRemoteLockInterval != nil && l.KeepRemoteLockInterval.Duration <= 0 {
+		errs = append(errs, field.Invalid(parent.Child("keepRemoteLockInterval"), l.KeepRemoteLockInterval.Duration.String(), "must be positive"))
+	}
+	if l.RemoteLockTimeout != nil && l.RemoteLockTimeout.Duration <= 0 {
+		errs = append(errs, field.Invalid(parent.Child("remoteLockTimeout"), l.RemoteLockTimeout.Duration.String(), "must be positive"))
+	}
+	if l.KeepRemoteLockInterval != nil && l.RemoteLockTimeout != nil && l.KeepRemoteLockInterval.Duration >= l.RemoteLockTimeout.Duration {
+		errs = append(errs, field.Invalid(parent.Child("keepRemoteLockInterval"), l.KeepRemoteLockInterval.Duration.String(), "must be less than remoteLockTimeout"))
 	}
 	return errs
 }
@@ -55,4 +55,37 @@ var _ = Describe("DNSet Webhook", func() {
 		}
 		Expect(k8sClient.Create(context.TODO(), v06)).To(Succeed())
 	})
+
+	It("should validate config keys", func() {
+		dn := &DNSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "dn-" + randomString(5),
+				Namespace: "default",
+			},
+			Spec: DNSetSpec{
+				DNSetBasic: DNSetBasic{
+					PodSet: PodSet{
+						Replicas: 1,
+						MainContainer: MainContainer{
+							Image: "test",
+						},
+						Config: NewTomlConfig(map[string]interface{}{
+							"log": map[string]interface{}{
+								"levle": "debug",
+							},
+						}),
+					},
+				},
+			},
+			Deps: DNSetDeps{
+				LogSetRef: LogSetRef{
+					ExternalLogSet: &ExternalLogSet{HAKeeperEndpoint: "test:32001"},
+				},
+			},
+		}
+		Expect(k8sClient.Create(context.TODO(), dn)).ToNot(Succeed())
+
+		dn.Annotations = map[string]string{SkipConfigValidationAnnotation: "true"}
+		Expect(k8sClient.Create(context.TODO(), dn)).To(Succeed())
+	})
 })
@@ -54,10 +54,16 @@ type LogSetBasic struct {
 	// +optional
 	InitialConfig InitialConfig `json:"initialConfig"`
 
-	// StoreFailureTimeout is the timeout to fail-over the logset Pod after a failure of it is observed
+	// StoreFailureTimeout is the timeout to fail-over the logset Pod after a failure of it is confirmed
 	// +optional
 	StoreFailureTimeout *metav1.Duration `json:"storeFailureTimeout,omitempty"`
 
+	// StoreFailureDetectionDelay is how long a logset Pod must be continuously unhealthy before
+	// it is confirmed as failed, default to 0 which means confirm immediately. The StoreFailureTimeout
+	// counts from the confirmation, so the Pod is failed over after being unhealthy for the sum of both.
+	// +optional
+	StoreFailureDetectionDelay *metav1.Duration `json:"storeFailureDetectionDelay,omitempty"`
+
 	// FailedPodStrategy controls how to handle failed pod when failover happens, default to Delete
 	FailedPodStrategy *FailedPodStrategy `json:"failedPodStrategy,omitempty"`
 
@@ -69,6 +75,49 @@ type LogSetBasic struct {
 	// The default policy is Delete.
 	// +optional
 	PVCRetentionPolicy *PVCRetentionPolicy `json:"pvcRetentionPolicy,omitempty"`
+
+	// DiscoveryService customizes the HAKeeper discovery service of the logset
+	// +optional
+	DiscoveryService *DiscoveryService `json:"discoveryService,omitempty"`
+
+	// RollingUpdatePolicy allows the log pods to be updated in parallel once the LogSet is bootstrapped,
+	// the pods are always updated one by one before the LogSet is bootstrapped or if not set
+	// +optional
+	RollingUpdatePolicy *LogSetRollingUpdatePolicy `json:"rollingUpdatePolicy,omitempty"`
+
+	// StatusPollInterval is the interval to re-collect the state of the log stores while the logset
+	// is not ready or has unready stores, which drives the failure detection of the stores. A jitter
+	// is added to the interval. Default to 15s, must be in range [5s, 10m]
+	// +optional
+	StatusPollInterval *metav1.Duration `json:"statusPollInterval,omitempty"`
+}
+
+type LogSetRollingUpdatePolicy struct {
+	// MaxUnavailable is the max number of log pods that can be unavailable during a rolling update,
+	// it must keep a majority of the replicas of each log shard available
+	// +kubebuilder:validation:Minimum=1
+	// +required
+	MaxUnavailable int32 `json:"maxUnavailable"`
+}
+
+// DiscoveryService customizes the HAKeeper discovery service, e.g. to integrate the discovery
+// with a service mesh or external-dns
+type DiscoveryService struct {
+	// Labels are added to the discovery service, the labels of the discovery service are
+	// fully managed by the operator
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// Annotations are added to the discovery service, the annotations of the discovery service
+	// are fully managed by the operator
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	// Address overrides the HAKeeper discovery address published to the components, the address
+	// must resolve to the discovery service. Changing the address rolling-updates the cluster.
+	// Defaults to the in-cluster DNS name of the discovery service.
+	// +optional
+	Address string `json:"address,omitempty"`
 }
 
 func (l *LogSetBasic) GetFailedPodStrategy() FailedPodStrategy {
@@ -85,6 +134,20 @@ func (l *LogSetBasic) GetStoreFailureTimeout() metav1.Duration {
 	return *l.StoreFailureTimeout
 }
 
+func (l *LogSetBasic) GetStoreFailureDetectionDelay() metav1.Duration {
+	if l.StoreFailureDetectionDelay == nil {
+		return metav1.Duration{}
+	}
+	return *l.StoreFailureDetectionDelay
+}
+
+func (l *LogSetBasic) GetStatusPollInterval() metav1.Duration {
+	if l.StatusPollInterval == nil {
+		return metav1.Duration{Duration: defaultStatusPollInterval}
+	}
+	return *l.StatusPollInterval
+}
+
 func (l *LogSetBasic) GetPVCRetentionPolicy() PVCRetentionPolicy {
 	if l.PVCRetentionPolicy == nil {
 		return PVCRetentionPolicyDelete
@@ -123,7 +186,19 @@ type LogSetStatus struct {
 	ConditionalStatus `json:",inline"`
 	FailoverStatus    `json:",inline"`
 
+	// ResolvedImage is the image of the main container in the LogService StatefulSet,
+	// an UpgradeStarted event is emitted on the LogSet when it changes
+	ResolvedImage string `json:"resolvedImage,omitempty"`
+
+	// PodSummary counts the pods of this set by their phases
+	// +optional
+	PodSummary *PodSummary `json:"podSummary,omitempty"`
+
 	Discovery *LogSetDiscovery `json:"discovery,omitempty"`
+
+	// Bootstrapped is true once all the stores of the LogSet are available for the first time
+	// +optional
+	Bootstrapped bool `json:"bootstrapped,omitempty"`
 	// TODO(aylei): collect LogShards, DNShards and HAKeeper status from HAKeeper
 	// HAKeeper          *HAKeeperStatus  `json:"haKeeper,omitempty"`
 	// LogShards
@@ -142,6 +217,7 @@ func (l *LogSetDiscovery) String() string {
 // +kubebuilder:object:root=true
 // +kubebuilder:printcolumn:name="Image",type="string",JSONPath=".spec.image"
 // +kubebuilder:printcolumn:name="Replicas",type="integer",JSONPath=".spec.replicas"
+// +kubebuilder:printcolumn:name="Running",type="integer",JSONPath=".status.podSummary.running"
 // +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
 
 // A LogSet is a resource that represents a set of MO's LogService instances
@@ -15,9 +15,11 @@
 package v1alpha1
 
 import (
+	"fmt"
 	"k8s.io/apimachinery/pkg/api/equality"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	"k8s.io/utils/pointer"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -32,6 +34,10 @@ const (
 	singleReplica = 1
 
 	defaultStoreFailureTimeout = 10 * time.Minute
+
+	defaultStatusPollInterval = 15 * time.Second
+	minStatusPollInterval     = 5 * time.Second
+	maxStatusPollInterval     = 10 * time.Minute
 )
 
 func (r *LogSet) setupWebhookWithManager(mgr ctrl.Manager) error {
@@ -86,16 +92,27 @@ var _ webhook.Validator = &LogSet{}
 // ValidateCreate implements webhook.Validator so a webhook will be registered for the type
 func (r *LogSet) ValidateCreate() error {
 	errs := r.Spec.LogSetBasic.ValidateCreate()
-	errs = append(errs, validateMainContainer(&r.Spec.MainContainer, field.NewPath("spec"))...)
+	errs = append(errs, r.validate()...)
 	return invalidOrNil(errs, r)
 }
 
 func (r *LogSet) ValidateUpdate(o runtime.Object) error {
 	old := o.(*LogSet)
 	errs := r.Spec.LogSetBasic.ValidateUpdate(&old.Spec.LogSetBasic)
+	errs = append(errs, validateForceFailover(r.ObjectMeta, old.ObjectMeta, &old.Status.FailoverStatus)...)
+	errs = append(errs, r.validate()...)
 	return invalidOrNil(errs, r)
 }
 
+func (r *LogSet) validate() field.ErrorList {
+	var errs field.ErrorList
+	errs = append(errs, validateMainContainer(&r.Spec.MainContainer, field.NewPath("spec"))...)
+	errs = append(errs, validateOverlay(r.Spec.Overlay, field.NewPath("spec").Child("overlay"))...)
+	errs = append(errs, r.Spec.PodSet.validate(r.Spec.Overlay, r.Spec.TopologyEvenSpread, field.NewPath("spec"))...)
+	errs = append(errs, validateConfig(r.Spec.Config, r.Spec.Image, r.ObjectMeta, field.NewPath("spec").Child("config"))...)
+	return errs
+}
+
 func (r *LogSet) ValidateDelete() error {
 	return nil
 }
@@ -105,6 +122,59 @@ func (r *LogSetBasic) ValidateCreate() field.ErrorList {
 	errs = append(errs, validateVolume(&r.Volume, field.NewPath("spec").Child("volume"))...)
 	errs = append(errs, r.validateInitialConfig()...)
 	errs = append(errs, r.validateSharedStorage()...)
+	errs = append(errs, r.validateStoreFailureDetectionDelay()...)
+	errs = append(errs, validateExtraServiceArgs(r.ExtraServiceArgs, field.NewPath("spec").Child("extraServiceArgs"))...)
+	errs = append(errs, validateNameOverride(r.NameOverride, field.NewPath("spec").Child("nameOverride"))...)
+	errs = append(errs, validateSysctls(r.Sysctls, r.AllowUnsafeSysctls, field.NewPath("spec").Child("sysctls"))...)
+	errs = append(errs, validateVolumeMetadata(r.VolumeMetadata, field.NewPath("spec").Child("volumeMetadata"))...)
+	errs = append(errs, validateAntiAffinityPolicy(&r.PodSet, r.NodeSelector, field.NewPath("spec"))...)
+	errs = append(errs, validateDiscoveryService(r.DiscoveryService, field.NewPath("spec").Child("discoveryService"))...)
+	errs = append(errs, r.validateRollingUpdatePolicy()...)
+	errs = append(errs, r.validateStatusPollInterval()...)
+	errs = append(errs, validateHeadlessService(&r.PodSet, true, field.NewPath("spec").Child("headlessService"))...)
+	errs = append(errs, validateConfigFileNames(&r.PodSet, field.NewPath("spec"))...)
+	errs = append(errs, validateServiceAccountName(r.ServiceAccountName, field.NewPath("spec").Child("serviceAccountName"))...)
+	return errs
+}
+
+func (r *LogSetBasic) validateStatusPollInterval() field.ErrorList {
+	d := r.StatusPollInterval
+	if d == nil {
+		return nil
+	}
+	if d.Duration < minStatusPollInterval || d.Duration > maxStatusPollInterval {
+		return field.ErrorList{field.Invalid(field.NewPath("spec").Child("statusPollInterval"), d.Duration.String(),
+			fmt.Sprintf("statusPollInterval must be in range [%s, %s]", minStatusPollInterval, maxStatusPollInterval))}
+	}
+	return nil
+}
+
+// validateRollingUpdatePolicy validates that the pods updated in parallel never break the quorum of a log shard
+func (r *LogSetBasic) validateRollingUpdatePolicy() field.ErrorList {
+	p := r.RollingUpdatePolicy
+	if p == nil || r.InitialConfig.LogShardReplicas == nil {
+		return nil
+	}
+	path := field.NewPath("spec").Child("rollingUpdatePolicy", "maxUnavailable")
+	if p.MaxUnavailable < 1 {
+		return field.ErrorList{field.Invalid(path, p.MaxUnavailable, "maxUnavailable must be positive")}
+	}
+	if limit := (*r.InitialConfig.LogShardReplicas - 1) / 2; int(p.MaxUnavailable) > limit {
+		return field.ErrorList{field.Invalid(path, p.MaxUnavailable, fmt.Sprintf("maxUnavailable must not exceed %d to keep the quorum of %d log shard replicas", limit, *r.InitialConfig.LogShardReplicas))}
+	}
+	return nil
+}
+
+func validateDiscoveryService(s *DiscoveryService, parent *field.Path) field.ErrorList {
+	if s == nil {
+		return nil
+	}
+	errs := validateMetadata(s.Labels, s.Annotations, parent)
+	if s.Address != "" {
+		for _, msg := range validation.IsDNS1123Subdomain(s.Address) {
+			errs = append(errs, field.Invalid(parent.Child("address"), s.Address, msg))
+		}
+	}
 	return errs
 }
 
@@ -116,6 +186,7 @@ func (r *LogSetBasic) ValidateUpdate(old *LogSetBasic) field.ErrorList {
 	if !equality.Semantic.DeepEqual(old.InitialConfig, r.InitialConfig) {
 		errs = append(errs, field.Invalid(field.NewPath("spec").Child("initialConfig"), nil, "initialConfig is immutable"))
 	}
+	errs = append(errs, validateNameOverrideUpdate(r.NameOverride, old.NameOverride, field.NewPath("spec").Child("nameOverride"))...)
 	return errs
 }
 
@@ -128,6 +199,7 @@ func (r *LogSetBasic) validateSharedStorage() field.ErrorList {
 		if r.SharedStorage.S3.Path == "" {
 			errs = append(errs, field.Invalid(parent, nil, "path must be set for S3 storage"))
 		}
+		errs = append(errs, validateS3Provider(r.SharedStorage.S3, parent.Child("s3"))...)
 	}
 	if r.SharedStorage.FileSystem != nil {
 		count += 1
@@ -144,6 +216,27 @@ func (r *LogSetBasic) validateSharedStorage() field.ErrorList {
 	return errs
 }
 
+func (r *LogSetBasic) validateStoreFailureDetectionDelay() field.ErrorList {
+	var errs field.ErrorList
+	d := r.StoreFailureDetectionDelay
+	if d == nil {
+		return nil
+	}
+	path := field.NewPath("spec").Child("storeFailureDetectionDelay")
+	if d.Duration < 0 {
+		errs = append(errs, field.Invalid(path, d.Duration.String(), "storeFailureDetectionDelay must not be negative"))
+	}
+	return errs
+}
+
+func validateS3Provider(s3 *S3Provider, parent *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	if ref := s3.CABundleSecretRef; ref != nil {
+		errs = append(errs, validateKeyRef(ref.Name, ref.Key, parent.Child("caBundleSecretRef"))...)
+	}
+	return errs
+}
+
 func (r *LogSetBasic) validateInitialConfig() field.ErrorList {
 	var errs field.ErrorList
 	parent := field.NewPath("spec").Child("initialConfig")
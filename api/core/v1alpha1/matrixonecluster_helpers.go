@@ -14,7 +14,19 @@
 
 package v1alpha1
 
-import "fmt"
+import (
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// defaultMetricsPort is the default status port of MO that exposes the metrics
+	defaultMetricsPort = 7001
+
+	defaultCNRemovalGracePeriod = 5 * time.Minute
+)
 
 func (m *MatrixOneCluster) LogSetImage() string {
 	image := m.Spec.LogService.Image
@@ -48,6 +60,48 @@ func (m *MatrixOneCluster) ApSetImage() string {
 	return image
 }
 
+// GetPhase returns the phase of the cluster
+func (m *MatrixOneCluster) GetPhase() string {
+	return m.Status.Phase
+}
+
+// GetCNRemovalGracePeriod returns how long a removed CN set is drained before deleted, default to 5m
+func (m *MatrixOneCluster) GetCNRemovalGracePeriod() time.Duration {
+	if m.Spec.CNRemovalGracePeriod == nil {
+		return defaultCNRemovalGracePeriod
+	}
+	return m.Spec.CNRemovalGracePeriod.Duration
+}
+
 func (m *MatrixOneCluster) defaultImage() string {
 	return fmt.Sprintf("%s:%s", m.Spec.ImageRepository, m.Spec.Version)
 }
+
+func (c *Colocation) GetTopologyKey() string {
+	if c.TopologyKey == "" {
+		return corev1.LabelHostname
+	}
+	return c.TopologyKey
+}
+
+func (c *CNIsolation) GetPolicy() AntiAffinityPolicy {
+	if c.Policy == "" {
+		return AntiAffinityPolicyPreferred
+	}
+	return c.Policy
+}
+
+func (c *CNIsolation) GetTopologyKey() string {
+	if c.TopologyKey == "" {
+		return corev1.LabelHostname
+	}
+	return c.TopologyKey
+}
+
+// GetMetricsPort returns the port where the metrics of the pods are exposed
+func (n *NetworkPolicy) GetMetricsPort() int32 {
+	if n.MetricsPort == nil {
+		return defaultMetricsPort
+	}
+	return *n.MetricsPort
+}
@@ -16,6 +16,7 @@ package v1alpha1
 
 import (
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -63,8 +64,165 @@ type MatrixOneClusterSpec struct {
 	// +optional
 	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
 
+	// ServiceAccountName specifies default service account for all components,
+	// this will be overridden by component-level config
+	// +optional
+	ServiceAccountName string `json:"serviceAccountName,omitempty"`
+
+	// ImagePullPolicy is the image pull policy of all the main containers of this cluster,
+	// including the WebUI
 	// +optional
 	ImagePullPolicy *corev1.PullPolicy `json:"imagePullPolicy,omitempty"`
+
+	// PodLabels are the labels added to all the pods of this cluster, including the pods of
+	// the WebUI. The pod labels in the overlay of a set take precedence.
+	// +optional
+	PodLabels map[string]string `json:"podLabels,omitempty"`
+
+	// PodAnnotations are the annotations added to all the pods of this cluster, including the
+	// pods of the WebUI. The pod annotations in the overlay of a set take precedence.
+	// +optional
+	PodAnnotations map[string]string `json:"podAnnotations,omitempty"`
+
+	// CommonLabels are the labels added to all the resources owned by this cluster, including
+	// the pods, the persistent volume claims, the services and the configmaps. The labels used
+	// by the operator to select the resources take precedence over the common labels.
+	// +optional
+	CommonLabels map[string]string `json:"commonLabels,omitempty"`
+
+	// Hibernate stops the compute tiers of the cluster to save cost while retaining the state: the CN sets
+	// and the WebUI are scaled to zero first and then the DN set, the LogService keeps running. Setting
+	// it back to false resumes the DN set first and then the others.
+	// +optional
+	Hibernate bool `json:"hibernate,omitempty"`
+
+	// Timezone is the IANA timezone (e.g. Asia/Shanghai) of all the main containers of this cluster,
+	// including the WebUI, which is set as the TZ env. A TZ env set through the overlay of a set takes precedence.
+	// The timezone database must be available in the images.
+	// +optional
+	Timezone string `json:"timezone,omitempty"`
+
+	// Colocation colocates the DN and LogService pods of this cluster for small-footprint deployments,
+	// the affinity of DN, LogService and CN sets will be generated according to this policy
+	// +optional
+	Colocation *Colocation `json:"colocation,omitempty"`
+
+	// CNIsolation keeps the pods of the different CN sets (i.e. TP and AP) of this cluster away from
+	// each other, so that a noisy CN set does not disturb the others on the same node
+	// +optional
+	CNIsolation *CNIsolation `json:"cnIsolation,omitempty"`
+
+	// NetworkPolicy generates a NetworkPolicy that allows the traffic between the components of this
+	// cluster, which is required to run the cluster in namespaces that deny ingress by default
+	// +optional
+	NetworkPolicy *NetworkPolicy `json:"networkPolicy,omitempty"`
+
+	// ServiceAccount creates a ServiceAccount for each of the LogService, DN, TP and AP components of
+	// this cluster, which is annotated with the cloud identity to access the shared storage without
+	// static credentials. The ServiceAccounts are used by the components that do not specify their
+	// own serviceAccountName, and are deleted when unset.
+	// +optional
+	ServiceAccount *ManagedServiceAccount `json:"serviceAccount,omitempty"`
+
+	// CNRemovalGracePeriod is how long the pods of a CN set removed from the spec (i.e. the AP set)
+	// are drained before the set is deleted. Drained pods are removed from the endpoints of the
+	// service so that no new connection is routed to them, while the established connections
+	// are kept until the set is deleted. Default to 5m
+	// +optional
+	CNRemovalGracePeriod *metav1.Duration `json:"cnRemovalGracePeriod,omitempty"`
+
+	// BootstrapSQL is the SQL script executed against the cluster once after the cluster is
+	// initialized, e.g. to create the databases and users of the application
+	// +optional
+	BootstrapSQL *BootstrapSQL `json:"bootstrapSQL,omitempty"`
+
+	// ResourceProfile tunes the defaults of the components for the purpose of the cluster.
+	// Dev lowers the requests that are not set to a fraction of the limits so that the cluster
+	// fits in a small kubernetes cluster,
+	// Production requires the cpu and memory requests of the components to be set and equal to
+	// the limits (if any), which gives the pods the Guaranteed QoS class.
+	// +kubebuilder:validation:Enum=Production;Dev
+	// +optional
+	ResourceProfile ResourceProfile `json:"resourceProfile,omitempty"`
+}
+
+// ResourceProfile is the profile of the resource defaults of a cluster
+type ResourceProfile string
+
+const (
+	// ResourceProfileProduction sizes the components with requests equal to limits
+	ResourceProfileProduction ResourceProfile = "Production"
+	// ResourceProfileDev sizes the components with requests lower than limits
+	ResourceProfileDev ResourceProfile = "Dev"
+)
+
+// Colocation is the policy to colocate the DN and LogService pods of a cluster
+type Colocation struct {
+	// TopologyKey is the topology domain that the DN and LogService pods are colocated in,
+	// default to kubernetes.io/hostname
+	// +optional
+	TopologyKey string `json:"topologyKey,omitempty"`
+}
+
+// CNIsolation is the policy to isolate the CN sets of a cluster by pod anti-affinity
+type CNIsolation struct {
+	// Policy is the strictness of the isolation, pods of different CN sets are never scheduled to
+	// the same topology domain if Required, which leaves the pods pending if there are not enough
+	// domains, default to Preferred
+	// +kubebuilder:validation:Enum=Required;Preferred
+	// +optional
+	Policy AntiAffinityPolicy `json:"policy,omitempty"`
+
+	// TopologyKey is the topology domain that the CN sets are isolated in, default to kubernetes.io/hostname
+	// +optional
+	TopologyKey string `json:"topologyKey,omitempty"`
+}
+
+// NetworkPolicy is the policy to generate the NetworkPolicy of a cluster. The generated policy allows
+// the traffic between the pods of the cluster on the ports of the components and the traffic from
+// anywhere to the SQL port of CN and the ports of WebUI.
+type NetworkPolicy struct {
+	// Enabled generates the NetworkPolicy of the cluster, the policy is deleted when disabled
+	Enabled bool `json:"enabled"`
+
+	// MetricsFrom are the peers allowed to scrape the metrics of the pods, e.g. the namespace
+	// of the monitoring system. Scraping is not allowed if empty.
+	// +optional
+	MetricsFrom []networkingv1.NetworkPolicyPeer `json:"metricsFrom,omitempty"`
+
+	// MetricsPort is the port where the metrics are exposed, default to 7001
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	// +optional
+	MetricsPort *int32 `json:"metricsPort,omitempty"`
+}
+
+// ManagedServiceAccount is the cloud identity bound to the ServiceAccounts created for a cluster,
+// at least one of the identities must be set
+type ManagedServiceAccount struct {
+	// AWSRoleARN is the ARN of the IAM role assumed by the pods through IRSA,
+	// e.g. arn:aws:iam::123456789012:role/matrixone
+	// +optional
+	AWSRoleARN string `json:"awsRoleARN,omitempty"`
+
+	// GCPServiceAccount is the email of the GCP service account impersonated by the pods through
+	// GKE workload identity, e.g. matrixone@my-project.iam.gserviceaccount.com
+	// +optional
+	GCPServiceAccount string `json:"gcpServiceAccount,omitempty"`
+}
+
+// BootstrapSQL references the SQL script to bootstrap a cluster, exactly one of the sources must be set.
+// The script is executed in a single session with multiple statements allowed, and is re-executed
+// as a whole if it fails, so the statements should be idempotent.
+type BootstrapSQL struct {
+	// ConfigMapKeyRef selects the script from a ConfigMap in the namespace of the cluster
+	// +optional
+	ConfigMapKeyRef *corev1.ConfigMapKeySelector `json:"configMapKeyRef,omitempty"`
+
+	// SecretKeyRef selects the script from a Secret in the namespace of the cluster, which is
+	// preferred if the script contains credentials
+	// +optional
+	SecretKeyRef *corev1.SecretKeySelector `json:"secretKeyRef,omitempty"`
 }
 
 // MatrixOneClusterStatus defines the observed state of MatrixOneCluster
@@ -79,6 +237,15 @@ type MatrixOneClusterStatus struct {
 	// used to connect to the database.
 	CredentialRef *corev1.LocalObjectReference `json:"credentialRef,omitempty"`
 
+	// BootstrapSQLExecuted is true once the bootstrap SQL script is executed successfully,
+	// the script will not be executed again afterwards
+	// +optional
+	BootstrapSQLExecuted bool `json:"bootstrapSQLExecuted,omitempty"`
+
+	// PodSummary is the rollup of the pod summaries of all the components
+	// +optional
+	PodSummary *PodSummary `json:"podSummary,omitempty"`
+
 	// TP is the TP set status
 	TP *CNSetStatus `json:"tp,omitempty"`
 	// AP is the AP set status
@@ -86,6 +253,11 @@ type MatrixOneClusterStatus struct {
 	// DN is the DN set status
 	DN *DNSetStatus `json:"dn,omitempty"`
 
+	// CNSetRemoval is the progress of removing the CN set that is removed from the spec,
+	// nil if no CN set is being removed
+	// +optional
+	CNSetRemoval *CNSetRemovalStatus `json:"cnSetRemoval,omitempty"`
+
 	// Webui is the webui service status
 	Webui *WebUIStatus `json:"webui,omitempty"`
 
@@ -93,6 +265,20 @@ type MatrixOneClusterStatus struct {
 	LogService *LogSetStatus `json:"logService,omitempty"`
 }
 
+// CNSetRemovalStatus is the progress of removing a CN set from the cluster
+type CNSetRemovalStatus struct {
+	// Name is the name of the CN set being removed
+	Name string `json:"name"`
+
+	// DrainStartTime is the time when the pods of the CN set started to be drained,
+	// the CN set is deleted after the cnRemovalGracePeriod since then
+	DrainStartTime metav1.Time `json:"drainStartTime"`
+
+	// DrainedPods is the number of the pods of the CN set that have been drained
+	// +optional
+	DrainedPods int32 `json:"drainedPods,omitempty"`
+}
+
 // +kubebuilder:object:root=true
 
 // A MatrixOneCluster is a resource that represents a MatrixOne Cluster
@@ -104,6 +290,9 @@ type MatrixOneClusterStatus struct {
 // +kubebuilder:printcolumn:name="AP",type="integer",JSONPath=".spec.ap.replicas"
 // +kubebuilder:printcolumn:name="Version",type="string",JSONPath=".spec.version"
 // +kubebuilder:printcolumn:name="Phase",type="string",JSONPath=".status.phase"
+// +kubebuilder:printcolumn:name="Running",type="integer",JSONPath=".status.podSummary.running"
+// +kubebuilder:printcolumn:name="Pending",type="integer",priority=1,JSONPath=".status.podSummary.pending"
+// +kubebuilder:printcolumn:name="Failed",type="integer",priority=1,JSONPath=".status.podSummary.failed"
 // +kubebuilder:printcolumn:name="UI",type="integer",priority=1,JSONPath=".spec.webui.replicas"
 // +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
 type MatrixOneCluster struct {
@@ -15,42 +15,83 @@
 package v1alpha1
 
 import (
+	"fmt"
+	"regexp"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1validation "k8s.io/apimachinery/pkg/apis/meta/v1/validation"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	ctrl "sigs.k8s.io/controller-runtime"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
-	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+var (
+	// awsRoleARNPattern matches the ARNs of the IAM roles in all the AWS partitions
+	awsRoleARNPattern = regexp.MustCompile(`^arn:aws[a-z-]*:iam::\d{12}:role/[\w+=,.@/-]{1,512}$`)
+	// gcpServiceAccountPattern matches the emails of the user-managed GCP service accounts
+	gcpServiceAccountPattern = regexp.MustCompile(`^[a-z][a-z0-9-]{4,28}[a-z0-9]@[a-z][a-z0-9-]{4,28}[a-z0-9]\.iam\.gserviceaccount\.com$`)
 )
 
 // log is for logging in this package.
 var moLog = logf.Log.WithName("mo-cluster")
 
-func (r *MatrixOneCluster) setupWebhookWithManager(mgr ctrl.Manager) error {
+const (
+	// maxColocatedReplicas is the max replicas of DN and LogService when colocation is enabled
+	maxColocatedReplicas = 3
+)
+
+func (r *MatrixOneCluster) setupWebhookWithManager(mgr ctrl.Manager, w *policyWebhook) error {
 	return ctrl.NewWebhookManagedBy(mgr).
 		For(r).
+		WithDefaulter(w).
+		WithValidator(w).
 		Complete()
 }
 
 // +kubebuilder:webhook:path=/mutate-core-matrixorigin-io-v1alpha1-matrixonecluster,mutating=true,failurePolicy=fail,sideEffects=None,groups=core.matrixorigin.io,resources=matrixoneclusters,verbs=create;update,versions=v1alpha1,name=mmatrixonecluster.kb.io,admissionReviewVersions=v1;v1beta1
 
-var _ webhook.Defaulter = &MatrixOneCluster{}
+var _ policyObject = &MatrixOneCluster{}
 
-// Default implements webhook.Defaulter so a webhook will be registered for the type
-func (r *MatrixOneCluster) Default() {
+// Default implements policyObject so the type is defaulted by the policyWebhook
+func (r *MatrixOneCluster) Default(p *WebhookPolicy) {
 	r.Spec.LogService.Default()
-	r.Spec.DN.Default()
-	r.Spec.TP.Default()
+	r.Spec.DN.Default(p)
+	r.Spec.TP.Default(p)
 	if r.Spec.AP != nil {
-		r.Spec.AP.Default()
+		r.Spec.AP.Default(p)
 	}
 }
 
 // +kubebuilder:webhook:path=/validate-core-matrixorigin-io-v1alpha1-matrixonecluster,mutating=false,failurePolicy=fail,sideEffects=None,groups=core.matrixorigin.io,resources=matrixoneclusters,verbs=create;update,versions=v1alpha1,name=vmatrixonecluster.kb.io,admissionReviewVersions=v1;v1beta1
 
-var _ webhook.Validator = &MatrixOneCluster{}
+// ValidateCreate implements policyObject so the type is validated by the policyWebhook
+func (r *MatrixOneCluster) ValidateCreate(p *WebhookPolicy) error {
+	errs := r.validate()
+	errs = append(errs, r.validateCacheSize(nil, p)...)
+	errs = append(errs, r.validateCacheVolumeRequired(nil, p)...)
+	return invalidOrNil(errs, r)
+}
 
-// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
-func (r *MatrixOneCluster) ValidateCreate() error {
+func (r *MatrixOneCluster) ValidateUpdate(o runtime.Object, p *WebhookPolicy) error {
+	old := o.(*MatrixOneCluster)
+	errs := r.validate()
+	errs = append(errs, r.validateCacheSize(old, p)...)
+	errs = append(errs, r.Spec.LogService.ValidateUpdate(&old.Spec.LogService)...)
+	errs = append(errs, validateUpgrade(r.Spec.Version, old.Spec.Version, r.ObjectMeta, p.UpgradeCompatibility, field.NewPath("spec").Child("version"))...)
+	errs = append(errs, validateNameOverrideUpdate(r.Spec.DN.NameOverride, old.Spec.DN.NameOverride, field.NewPath("spec").Child("dn", "nameOverride"))...)
+	errs = append(errs, validateNameOverrideUpdate(r.Spec.TP.NameOverride, old.Spec.TP.NameOverride, field.NewPath("spec").Child("tp", "nameOverride"))...)
+	errs = append(errs, validateHeadlessServiceUpdate(&r.Spec.DN.PodSet, &old.Spec.DN.PodSet, field.NewPath("spec").Child("dn", "headlessService"))...)
+	errs = append(errs, validateHeadlessServiceUpdate(&r.Spec.TP.PodSet, &old.Spec.TP.PodSet, field.NewPath("spec").Child("tp", "headlessService"))...)
+	if r.Spec.AP != nil && old.Spec.AP != nil {
+		errs = append(errs, validateNameOverrideUpdate(r.Spec.AP.NameOverride, old.Spec.AP.NameOverride, field.NewPath("spec").Child("ap", "nameOverride"))...)
+		errs = append(errs, validateHeadlessServiceUpdate(&r.Spec.AP.PodSet, &old.Spec.AP.PodSet, field.NewPath("spec").Child("ap", "headlessService"))...)
+	}
+	errs = append(errs, r.validateCacheVolumeRequired(old, p)...)
+	return invalidOrNil(errs, r)
+}
+
+func (r *MatrixOneCluster) validate() field.ErrorList {
 	var errs field.ErrorList
 	errs = append(errs, r.Spec.LogService.ValidateCreate()...)
 	errs = append(errs, r.Spec.DN.ValidateCreate()...)
@@ -58,22 +99,267 @@ func (r *MatrixOneCluster) ValidateCreate() error {
 	if r.Spec.AP != nil {
 		errs = append(errs, r.Spec.AP.ValidateCreate()...)
 	}
+	errs = append(errs, validateConfig(r.Spec.LogService.Config, r.LogSetImage(), r.ObjectMeta, field.NewPath("spec").Child("logService", "config"))...)
+	errs = append(errs, validateConfig(r.Spec.DN.Config, r.DnSetImage(), r.ObjectMeta, field.NewPath("spec").Child("dn", "config"))...)
+	errs = append(errs, validateConfig(r.Spec.TP.Config, r.TpSetImage(), r.ObjectMeta, field.NewPath("spec").Child("tp", "config"))...)
+	if r.Spec.AP != nil {
+		errs = append(errs, validateConfig(r.Spec.AP.Config, r.ApSetImage(), r.ObjectMeta, field.NewPath("spec").Child("ap", "config"))...)
+	}
+	errs = append(errs, validateCacheSharingMode(r.Spec.TP.CacheSharingMode, r.TpSetImage(), field.NewPath("spec").Child("tp"))...)
+	if r.Spec.AP != nil {
+		errs = append(errs, validateCacheSharingMode(r.Spec.AP.CacheSharingMode, r.ApSetImage(), field.NewPath("spec").Child("ap"))...)
+	}
+	errs = append(errs, r.Spec.LogService.PodSet.validate(nil, r.topologySpreadOf(&r.Spec.LogService.PodSet), field.NewPath("spec").Child("logService"))...)
+	errs = append(errs, r.Spec.DN.PodSet.validate(nil, r.topologySpreadOf(&r.Spec.DN.PodSet), field.NewPath("spec").Child("dn"))...)
+	errs = append(errs, r.Spec.TP.PodSet.validate(nil, r.topologySpreadOf(&r.Spec.TP.PodSet), field.NewPath("spec").Child("tp"))...)
+	if r.Spec.AP != nil {
+		errs = append(errs, r.Spec.AP.PodSet.validate(nil, r.topologySpreadOf(&r.Spec.AP.PodSet), field.NewPath("spec").Child("ap"))...)
+	}
+	errs = append(errs, r.validateNameOverrideConflict()...)
+	errs = append(errs, r.validateColocation()...)
+	errs = append(errs, r.validateAntiAffinity()...)
+	errs = append(errs, r.validateCNIsolation()...)
+	errs = append(errs, r.validateBootstrapSQL()...)
+	errs = append(errs, r.validateServiceAccount()...)
+	errs = append(errs, r.validateResourceProfile()...)
+	errs = append(errs, validateTimezone(r.Spec.Timezone, field.NewPath("spec").Child("timezone"))...)
+	errs = append(errs, validateCommonLabels(r.Spec.CommonLabels, field.NewPath("spec").Child("commonLabels"))...)
+	errs = append(errs, validateServiceAccountName(r.Spec.ServiceAccountName, field.NewPath("spec").Child("serviceAccountName"))...)
+	if d := r.Spec.CNRemovalGracePeriod; d != nil && d.Duration < 0 {
+		errs = append(errs, field.Invalid(field.NewPath("spec").Child("cnRemovalGracePeriod"), d.Duration.String(), "must not be negative"))
+	}
 	if r.Spec.Version == "" {
 		errs = append(errs, field.Invalid(field.NewPath("spec").Child("version"), "", "version must be set"))
 	}
-	return invalidOrNil(errs, r)
+	return errs
 }
 
-func (r *MatrixOneCluster) ValidateUpdate(o runtime.Object) error {
-	if err := r.ValidateCreate(); err != nil {
-		return err
+// validateNameOverrideConflict rejects the nameOverrides that make two sets of the cluster generate
+// resources of the same names, the default base names are consistent with the set controllers
+func (r *MatrixOneCluster) validateNameOverrideConflict() field.ErrorList {
+	baseName := func(override string, defaultName string) string {
+		if override != "" {
+			return override
+		}
+		return defaultName
+	}
+	type set struct {
+		base string
+		path *field.Path
+	}
+	path := field.NewPath("spec")
+	sets := []set{
+		{baseName(r.Spec.LogService.NameOverride, r.Name+"-log"), path.Child("logService", "nameOverride")},
+		{baseName(r.Spec.DN.NameOverride, r.Name+"-dn"), path.Child("dn", "nameOverride")},
+		{baseName(r.Spec.TP.NameOverride, r.Name+"-tp-cn"), path.Child("tp", "nameOverride")},
+	}
+	if r.Spec.AP != nil {
+		sets = append(sets, set{baseName(r.Spec.AP.NameOverride, r.Name+"-ap-cn"), path.Child("ap", "nameOverride")})
 	}
 	var errs field.ErrorList
-	old := o.(*MatrixOneCluster)
-	errs = append(errs, r.Spec.LogService.ValidateUpdate(&old.Spec.LogService)...)
-	return invalidOrNil(errs, r)
+	seen := map[string]bool{}
+	for _, s := range sets {
+		if seen[s.base] {
+			errs = append(errs, field.Duplicate(s.path, s.base))
+		}
+		seen[s.base] = true
+	}
+	return errs
+}
+
+// validateCacheSize validates the cache sizes of the sets changed from old, which is nil on creation,
+// against the minimum cache size of the policy
+func (r *MatrixOneCluster) validateCacheSize(old *MatrixOneCluster, p *WebhookPolicy) field.ErrorList {
+	var oldDN, oldTP, oldAP *cacheSize
+	if old != nil {
+		oldDN = cacheSizeOf(old.Spec.DN.CacheVolume, &old.Spec.DN.SharedStorageCache)
+		oldTP = cacheSizeOf(old.Spec.TP.CacheVolume, &old.Spec.TP.SharedStorageCache)
+		if old.Spec.AP != nil {
+			oldAP = cacheSizeOf(old.Spec.AP.CacheVolume, &old.Spec.AP.SharedStorageCache)
+		}
+	}
+	path := field.NewPath("spec")
+	errs := validateCacheSize(r.DnSetImage(), cacheSizeOf(r.Spec.DN.CacheVolume, &r.Spec.DN.SharedStorageCache), oldDN, r.ObjectMeta, p, path.Child("dn"))
+	errs = append(errs, validateCacheSize(r.TpSetImage(), cacheSizeOf(r.Spec.TP.CacheVolume, &r.Spec.TP.SharedStorageCache), oldTP, r.ObjectMeta, p, path.Child("tp"))...)
+	if r.Spec.AP != nil {
+		errs = append(errs, validateCacheSize(r.ApSetImage(), cacheSizeOf(r.Spec.AP.CacheVolume, &r.Spec.AP.SharedStorageCache), oldAP, r.ObjectMeta, p, path.Child("ap"))...)
+	}
+	return errs
+}
+
+// validateCacheVolumeRequired validates the CN sets created by the change from old, which is nil
+// on creation, against the cache volume requirement of the policy
+func (r *MatrixOneCluster) validateCacheVolumeRequired(old *MatrixOneCluster, p *WebhookPolicy) field.ErrorList {
+	var errs field.ErrorList
+	if old == nil {
+		errs = append(errs, r.Spec.TP.validateCacheVolumeRequired(p, field.NewPath("spec").Child("tp"))...)
+	}
+	if r.Spec.AP != nil && (old == nil || old.Spec.AP == nil) {
+		errs = append(errs, r.Spec.AP.validateCacheVolumeRequired(p, field.NewPath("spec").Child("ap"))...)
+	}
+	return errs
+}
+
+func (r *MatrixOneCluster) validateColocation() field.ErrorList {
+	var errs field.ErrorList
+	if r.Spec.Colocation == nil {
+		return nil
+	}
+	path := field.NewPath("spec").Child("colocation")
+	if r.Spec.DN.Replicas > maxColocatedReplicas {
+		errs = append(errs, field.Invalid(path, r.Spec.DN.Replicas, fmt.Sprintf("colocation requires dn replicas no more than %d", maxColocatedReplicas)))
+	}
+	if r.Spec.LogService.Replicas > maxColocatedReplicas {
+		errs = append(errs, field.Invalid(path, r.Spec.LogService.Replicas, fmt.Sprintf("colocation requires logService replicas no more than %d", maxColocatedReplicas)))
+	}
+	// each DN pod requires a distinct node that runs a LogService pod, which can never be satisfied
+	// if there are more DN replicas than LogService replicas
+	if r.Spec.Colocation.GetTopologyKey() == corev1.LabelHostname &&
+		r.Spec.DN.GetAntiAffinityPolicy(AntiAffinityPolicyPreferred) == AntiAffinityPolicyRequired &&
+		r.Spec.DN.Replicas > r.Spec.LogService.Replicas {
+		errs = append(errs, field.Invalid(field.NewPath("spec").Child("dn", "antiAffinityPolicy"), r.Spec.DN.AntiAffinityPolicy,
+			"dn replicas must be no more than logService replicas when the dn pods are colocated with logService pods and required to spread across nodes"))
+	}
+	return errs
+}
+
+// validateAntiAffinity validates the anti-affinity policies of the sets that inherit the node selector
+// of the cluster, the sets with their own node selectors are validated by the set validations
+func (r *MatrixOneCluster) validateAntiAffinity() field.ErrorList {
+	var errs field.ErrorList
+	validate := func(p *PodSet, path *field.Path) {
+		if p.NodeSelector == nil {
+			errs = append(errs, validateAntiAffinityPolicy(p, r.Spec.NodeSelector, path)...)
+		}
+	}
+	validate(&r.Spec.LogService.PodSet, field.NewPath("spec").Child("logService"))
+	validate(&r.Spec.DN.PodSet, field.NewPath("spec").Child("dn"))
+	validate(&r.Spec.TP.PodSet, field.NewPath("spec").Child("tp"))
+	if r.Spec.AP != nil {
+		validate(&r.Spec.AP.PodSet, field.NewPath("spec").Child("ap"))
+	}
+	return errs
 }
 
-func (r *MatrixOneCluster) ValidateDelete() error {
-	return nil
+func (r *MatrixOneCluster) validateCNIsolation() field.ErrorList {
+	c := r.Spec.CNIsolation
+	if c == nil {
+		return nil
+	}
+	path := field.NewPath("spec").Child("cnIsolation")
+	var errs field.ErrorList
+	if c.TopologyKey != "" {
+		errs = append(errs, metav1validation.ValidateLabelName(c.TopologyKey, path.Child("topologyKey"))...)
+	}
+	if r.Spec.AP == nil || c.GetPolicy() != AntiAffinityPolicyRequired || c.GetTopologyKey() != corev1.LabelHostname {
+		return errs
+	}
+	// the CN sets can never be isolated if they are pinned to the same node
+	tpNode := r.nodeSelectorOf(&r.Spec.TP.PodSet)[corev1.LabelHostname]
+	apNode := r.nodeSelectorOf(&r.Spec.AP.PodSet)[corev1.LabelHostname]
+	if tpNode != "" && tpNode == apNode {
+		errs = append(errs, field.Invalid(path.Child("policy"), c.Policy,
+			fmt.Sprintf("tp and ap are both pinned to node %s and can not be isolated from each other", tpNode)))
+	}
+	return errs
+}
+
+// validateBootstrapSQL validates the reference of the bootstrap SQL script, the existence of the
+// referenced object is checked by the controller before the script is executed
+func (r *MatrixOneCluster) validateBootstrapSQL() field.ErrorList {
+	b := r.Spec.BootstrapSQL
+	if b == nil {
+		return nil
+	}
+	path := field.NewPath("spec").Child("bootstrapSQL")
+	if (b.ConfigMapKeyRef == nil) == (b.SecretKeyRef == nil) {
+		return field.ErrorList{field.Invalid(path, b, "exactly one of configMapKeyRef and secretKeyRef must be set")}
+	}
+	if ref := b.ConfigMapKeyRef; ref != nil {
+		return validateKeyRef(ref.Name, ref.Key, path.Child("configMapKeyRef"))
+	}
+	return validateKeyRef(b.SecretKeyRef.Name, b.SecretKeyRef.Key, path.Child("secretKeyRef"))
+}
+
+// validateServiceAccount validates the cloud identities annotated to the ServiceAccounts created
+// for the cluster
+func (r *MatrixOneCluster) validateServiceAccount() field.ErrorList {
+	sa := r.Spec.ServiceAccount
+	if sa == nil {
+		return nil
+	}
+	var errs field.ErrorList
+	path := field.NewPath("spec").Child("serviceAccount")
+	if sa.AWSRoleARN == "" && sa.GCPServiceAccount == "" {
+		errs = append(errs, field.Invalid(path, sa, "at least one of awsRoleARN and gcpServiceAccount must be set"))
+	}
+	if sa.AWSRoleARN != "" && !awsRoleARNPattern.MatchString(sa.AWSRoleARN) {
+		errs = append(errs, field.Invalid(path.Child("awsRoleARN"), sa.AWSRoleARN, "must be the ARN of an IAM role, e.g. arn:aws:iam::123456789012:role/matrixone"))
+	}
+	if sa.GCPServiceAccount != "" && !gcpServiceAccountPattern.MatchString(sa.GCPServiceAccount) {
+		errs = append(errs, field.Invalid(path.Child("gcpServiceAccount"), sa.GCPServiceAccount, "must be the email of a GCP service account, e.g. matrixone@my-project.iam.gserviceaccount.com"))
+	}
+	if r.Spec.ServiceAccountName != "" {
+		errs = append(errs, field.Invalid(field.NewPath("spec").Child("serviceAccountName"), r.Spec.ServiceAccountName, "serviceAccountName must not be set when serviceAccount is set"))
+	}
+	return errs
+}
+
+func validateKeyRef(name string, key string, path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	if name == "" {
+		errs = append(errs, field.Required(path.Child("name"), "name of the referenced object must be set"))
+	}
+	if key == "" {
+		errs = append(errs, field.Required(path.Child("key"), "key of the referenced object must be set"))
+	}
+	return errs
+}
+
+// validateResourceProfile validates that the components are sized with requests equal to limits
+// under the Production profile, the limits that are not set are defaulted from the requests
+func (r *MatrixOneCluster) validateResourceProfile() field.ErrorList {
+	if r.Spec.ResourceProfile != ResourceProfileProduction {
+		return nil
+	}
+	path := field.NewPath("spec")
+	errs := validateGuaranteedResources(&r.Spec.LogService.PodSet, path.Child("logService"))
+	errs = append(errs, validateGuaranteedResources(&r.Spec.DN.PodSet, path.Child("dn"))...)
+	errs = append(errs, validateGuaranteedResources(&r.Spec.TP.PodSet, path.Child("tp"))...)
+	if r.Spec.AP != nil {
+		errs = append(errs, validateGuaranteedResources(&r.Spec.AP.PodSet, path.Child("ap"))...)
+	}
+	return errs
+}
+
+func validateGuaranteedResources(p *PodSet, parent *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	path := parent.Child("resources")
+	for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+		req, ok := p.Resources.Requests[name]
+		if !ok {
+			errs = append(errs, field.Required(path.Child("requests").Key(string(name)), "must be set under the Production resource profile"))
+			continue
+		}
+		if limit, ok := p.Resources.Limits[name]; ok && limit.Cmp(req) != 0 {
+			errs = append(errs, field.Invalid(path.Child("limits").Key(string(name)), limit.String(), "must be equal to the request under the Production resource profile"))
+		}
+	}
+	return errs
+}
+
+// nodeSelectorOf returns the node selector of the set, which defaults to the node selector of the cluster
+func (r *MatrixOneCluster) nodeSelectorOf(p *PodSet) map[string]string {
+	if p.NodeSelector != nil {
+		return p.NodeSelector
+	}
+	return r.Spec.NodeSelector
+}
+
+// topologySpreadOf returns the topology even spread of the set, which defaults to the one of the cluster
+func (r *MatrixOneCluster) topologySpreadOf(p *PodSet) []string {
+	if p.TopologyEvenSpread != nil {
+		return p.TopologyEvenSpread
+	}
+	return r.Spec.TopologyEvenSpread
 }
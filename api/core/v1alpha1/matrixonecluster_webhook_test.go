@@ -18,6 +18,7 @@ import (
 	"context"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/utils/pointer"
@@ -118,6 +119,39 @@ var _ = Describe("MatrixOneCluster Webhook", func() {
 		invalidReplicas.Spec.LogService.Replicas = 2
 		invalidReplicas.Spec.LogService.InitialConfig.LogShardReplicas = pointer.Int(3)
 		Expect(k8sClient.Create(context.TODO(), emptySharedStorage)).ToNot(Succeed())
+
+		By("reject invalid common labels")
+		invalidLabels := tpl.DeepCopy()
+		invalidLabels.Spec.CommonLabels = map[string]string{"matrixorigin.io/instance": "foo"}
+		Expect(k8sClient.Create(context.TODO(), invalidLabels)).ToNot(Succeed())
+		invalidLabels.Spec.CommonLabels = map[string]string{"team": "not a valid value"}
+		Expect(k8sClient.Create(context.TODO(), invalidLabels)).ToNot(Succeed())
+
+		By("reject infeasible CN isolation")
+		infeasible := tpl.DeepCopy()
+		infeasible.Spec.NodeSelector = map[string]string{corev1.LabelHostname: "node-1"}
+		infeasible.Spec.AP = &CNSetBasic{PodSet: PodSet{Replicas: 1}}
+		infeasible.Spec.CNIsolation = &CNIsolation{Policy: AntiAffinityPolicyRequired}
+		Expect(k8sClient.Create(context.TODO(), infeasible)).ToNot(Succeed())
+
+		By("reject ambiguous bootstrap SQL")
+		ambiguous := tpl.DeepCopy()
+		ambiguous.Spec.BootstrapSQL = &BootstrapSQL{}
+		Expect(k8sClient.Create(context.TODO(), ambiguous)).ToNot(Succeed())
+		ambiguous.Spec.BootstrapSQL = &BootstrapSQL{
+			ConfigMapKeyRef: &corev1.ConfigMapKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "bootstrap"}, Key: "init.sql"},
+			SecretKeyRef:    &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "bootstrap"}, Key: "init.sql"},
+		}
+		Expect(k8sClient.Create(context.TODO(), ambiguous)).ToNot(Succeed())
+
+		By("reject unschedulable anti-affinity")
+		unschedulable := tpl.DeepCopy()
+		unschedulable.Spec.Colocation = &Colocation{}
+		unschedulable.Spec.LogService.Replicas = 1
+		unschedulable.Spec.DN.AntiAffinityPolicy = AntiAffinityPolicyRequired
+		Expect(k8sClient.Create(context.TODO(), unschedulable)).ToNot(Succeed())
+		unschedulable.Spec.DN.AntiAffinityPolicy = AntiAffinityPolicyPreferred
+		Expect(k8sClient.Create(context.TODO(), unschedulable)).To(Succeed())
 	})
 
 	It("should validate and mutate MatrixOneCluster", func() {
@@ -0,0 +1,131 @@
+// Copyright 2023 Matrix Origin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+const (
+	// ForceUpgradeAnnotation skips the upgrade compatibility check of the cluster version when set to "true",
+	// the operator must make sure the on-disk data of the current version is readable by the new version
+	ForceUpgradeAnnotation = "matrixorigin.io/force-upgrade"
+)
+
+var minorSeriesPattern = regexp.MustCompile(`^v?(\d+)\.(\d+)(\.|-|$)`)
+
+// UpgradeCompatibility is the compatibility matrix of MO versions, it maps a minor series (e.g. 0.7)
+// to the minor series that can be upgraded to from it. Upgrades within a minor series are always allowed.
+type UpgradeCompatibility map[string][]string
+
+// ParseUpgradeCompatibility parses the compatibility matrix from a comma separated list of upgrade
+// paths, e.g. "0.6->0.7,0.7->0.8"
+func ParseUpgradeCompatibility(s string) (UpgradeCompatibility, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+	c := UpgradeCompatibility{}
+	for _, p := range strings.Split(s, ",") {
+		parts := strings.Split(strings.TrimSpace(p), "->")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid upgrade path %q, must be in the form of <from>-><to>", p)
+		}
+		from, ok := minorSeries(parts[0])
+		if !ok {
+			return nil, fmt.Errorf("invalid version %q in upgrade path %q", parts[0], p)
+		}
+		to, ok := minorSeries(parts[1])
+		if !ok {
+			return nil, fmt.Errorf("invalid version %q in upgrade path %q", parts[1], p)
+		}
+		c[from] = append(c[from], to)
+	}
+	return c, nil
+}
+
+// minorSeries returns the minor series of the version, e.g. 0.7 of v0.7.1
+func minorSeries(version string) (string, bool) {
+	m := minorSeriesPattern.FindStringSubmatch(strings.TrimSpace(version))
+	if m == nil {
+		return "", false
+	}
+	return m[1] + "." + m[2], true
+}
+
+// minorSeriesAtLeast returns whether the minor series is the same as or later than the min series
+func minorSeriesAtLeast(series string, min string) bool {
+	var major, minor, minMajor, minMinor int
+	fmt.Sscanf(series, "%d.%d", &major, &minor)
+	fmt.Sscanf(min, "%d.%d", &minMajor, &minMinor)
+	return major > minMajor || (major == minMajor && minor >= minMinor)
+}
+
+// latestSeriesOf returns the latest of the listed minor series that the version belongs to, which is
+// the latest one no later than the version. Versions that are not semantic (e.g. nightly builds) take
+// the latest listed series. An empty string is returned if no listed series matches.
+func latestSeriesOf(version string, listed []string) string {
+	latest := ""
+	series, semantic := minorSeries(version)
+	for _, s := range listed {
+		if semantic && !minorSeriesAtLeast(series, s) {
+			continue
+		}
+		if latest == "" || minorSeriesAtLeast(s, latest) {
+			latest = s
+		}
+	}
+	return latest
+}
+
+// imageTag returns the tag of the image reference, or an empty string if the image is not tagged
+func imageTag(image string) string {
+	i := strings.LastIndex(image, ":")
+	if i < 0 || strings.Contains(image[i:], "/") {
+		return ""
+	}
+	return image[i+1:]
+}
+
+// validateUpgrade rejects the version changes that are not allowed by the compatibility matrix of
+// the webhook policy, versions that are not semantic (e.g. nightly builds) are not validated
+func validateUpgrade(version string, oldVersion string, meta metav1.ObjectMeta, c UpgradeCompatibility, parent *field.Path) field.ErrorList {
+	if c == nil || version == oldVersion || meta.Annotations[ForceUpgradeAnnotation] == "true" {
+		return nil
+	}
+	from, ok := minorSeries(oldVersion)
+	if !ok {
+		return nil
+	}
+	to, ok := minorSeries(version)
+	if !ok || from == to {
+		return nil
+	}
+	for _, allowed := range c[from] {
+		if allowed == to {
+			return nil
+		}
+	}
+	targets := append([]string{}, c[from]...)
+	sort.Strings(targets)
+	return field.ErrorList{field.Forbidden(parent, fmt.Sprintf(
+		"upgrading from %s to %s is not supported, %s can be upgraded to %v, upgrade through an intermediate version or set the annotation %s=true to force the upgrade",
+		oldVersion, version, from, targets, ForceUpgradeAnnotation))}
+}
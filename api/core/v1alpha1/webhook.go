@@ -15,26 +15,179 @@
 package v1alpha1
 
 import (
+	"context"
+	"fmt"
+	"net"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	// embed the timezone database to validate the timezones regardless of the base image
+	_ "time/tzdata"
+
+	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	apivalidation "k8s.io/apimachinery/pkg/api/validation"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	metav1validation "k8s.io/apimachinery/pkg/apis/meta/v1/validation"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// configFlag is the flag of MO service to specify the config file, which is always set by the operator
+	configFlag = "cfg"
+
+	defaultDiskCacheHeadroomPercent = 5
+
+	// SkipCacheSizeValidationAnnotation disables the validation of the minimum cache size when set to
+	// "true", which is useful for development environments with small volumes
+	SkipCacheSizeValidationAnnotation = "matrixorigin.io/skip-cache-size-validation"
+
+	// reservedKeyDomain is the domain of the label and annotation keys used by the operator
+	reservedKeyDomain = "matrixorigin.io"
+
+	// tmpPath is where the entrypoint writes its temporary files
+	tmpPath = "/tmp"
+
+	// distributedCacheMinVersion is the first MO minor series that supports the distributed cache
+	distributedCacheMinVersion = "1.1"
+)
+
+var (
+	// safeSysctls are the sysctls allowed by the kubelet by default,
+	// refer to https://kubernetes.io/docs/tasks/administer-cluster/sysctl-cluster/#safe-and-unsafe-sysctls
+	safeSysctls = map[string]bool{
+		"kernel.shm_rmid_forced":              true,
+		"net.ipv4.ip_local_port_range":        true,
+		"net.ipv4.tcp_syncookies":             true,
+		"net.ipv4.ping_group_range":           true,
+		"net.ipv4.ip_unprivileged_port_start": true,
+	}
+	// namespacedSysctlPrefixes are the prefixes of namespaced sysctls, only namespaced
+	// sysctls can be set for pods
+	namespacedSysctlPrefixes = []string{"kernel.shm", "kernel.msg", "kernel.sem", "fs.mqueue.", "net."}
+	// networkInterfacePattern matches the names of linux network interfaces
+	networkInterfacePattern = regexp.MustCompile(`^[a-zA-Z0-9_.-]{1,15}$`)
 )
 
 var webhookLog = logf.Log.WithName("mo-webhook")
 
-func RegisterWebhooks(mgr ctrl.Manager) error {
-	if err := (&MatrixOneCluster{}).setupWebhookWithManager(mgr); err != nil {
+// WebhookPolicy is the operator level policy enforced by the validating webhooks,
+// which allows different environments (e.g. dev and prod) to enforce different rules
+type WebhookPolicy struct {
+	// RequireCNCacheVolume rejects the CN specs without a cache volume
+	RequireCNCacheVolume bool
+	// DiskCacheHeadroomPercent is the percentage of the cache volume that is not used by the
+	// disk cache when defaulting the disk cache size
+	DiskCacheHeadroomPercent int64
+	// DiskCacheHeadroomFloor is the minimum size of the cache volume that is not used by the
+	// disk cache when defaulting the disk cache size
+	DiskCacheHeadroomFloor resource.Quantity
+	// MinCacheSizes rejects the cache volumes and disk caches smaller than the minimum of the MO version,
+	// nil disables the validation
+	MinCacheSizes MinCacheSizes
+	// UpgradeCompatibility rejects the cluster version changes that are not allowed by the matrix,
+	// nil disables the validation
+	UpgradeCompatibility UpgradeCompatibility
+}
+
+// DefaultWebhookPolicy returns the default policy enforced by the validating webhooks
+func DefaultWebhookPolicy() WebhookPolicy {
+	return WebhookPolicy{
+		DiskCacheHeadroomPercent: defaultDiskCacheHeadroomPercent,
+		DiskCacheHeadroomFloor:   resource.MustParse("2Gi"),
+		MinCacheSizes:            MinCacheSizes{"0.0": resource.MustParse("1Gi")},
+	}
+}
+
+// MinCacheSizes maps the MO minor series (e.g. 1.0) to the minimum cache size of the series, a version
+// takes the minimum of the latest listed series that is not later than it
+type MinCacheSizes map[string]resource.Quantity
+
+// ParseMinCacheSizes parses the minimum cache sizes from a YAML map of the minor series to the
+// sizes, e.g. {"0.8": "1Gi", "1.0": "2Gi"}, which is mounted from the configmap of the operator
+func ParseMinCacheSizes(data []byte) (MinCacheSizes, error) {
+	raw := map[string]resource.Quantity{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	m := MinCacheSizes{}
+	for k, v := range raw {
+		series, ok := minorSeries(k)
+		if !ok {
+			return nil, fmt.Errorf("invalid minor series %q of the min cache size", k)
+		}
+		m[series] = v
+	}
+	return m, nil
+}
+
+// minSizeOf returns the minimum cache size of the version, versions that are not semantic (e.g.
+// nightly builds) take the minimum of the latest series
+func (m MinCacheSizes) minSizeOf(version string) resource.Quantity {
+	var listed []string
+	for s := range m {
+		listed = append(listed, s)
+	}
+	return m[latestSeriesOf(version, listed)]
+}
+
+// policyObject is an API object whose defaulting and validation depend on the WebhookPolicy
+type policyObject interface {
+	runtime.Object
+	Default(p *WebhookPolicy)
+	ValidateCreate(p *WebhookPolicy) error
+	ValidateUpdate(old runtime.Object, p *WebhookPolicy) error
+}
+
+// policyWebhook defaults and validates the policyObjects with the policy it holds, so that
+// the webhooks registered on different managers (e.g. in tests) do not share the policy
+type policyWebhook struct {
+	policy WebhookPolicy
+}
+
+var _ admission.CustomDefaulter = &policyWebhook{}
+var _ admission.CustomValidator = &policyWebhook{}
+
+func (w *policyWebhook) Default(_ context.Context, obj runtime.Object) error {
+	obj.(policyObject).Default(&w.policy)
+	return nil
+}
+
+func (w *policyWebhook) ValidateCreate(_ context.Context, obj runtime.Object) error {
+	return obj.(policyObject).ValidateCreate(&w.policy)
+}
+
+func (w *policyWebhook) ValidateUpdate(_ context.Context, oldObj, newObj runtime.Object) error {
+	return newObj.(policyObject).ValidateUpdate(oldObj, &w.policy)
+}
+
+func (w *policyWebhook) ValidateDelete(_ context.Context, _ runtime.Object) error {
+	return nil
+}
+
+// RegisterWebhooks registers the webhooks of all the API types, the given policy is enforced by the webhooks
+func RegisterWebhooks(mgr ctrl.Manager, policy WebhookPolicy) error {
+	w := &policyWebhook{policy: policy}
+	if err := (&MatrixOneCluster{}).setupWebhookWithManager(mgr, w); err != nil {
 		return err
 	}
 	if err := (&LogSet{}).setupWebhookWithManager(mgr); err != nil {
 		return err
 	}
-	if err := (&DNSet{}).setupWebhookWithManager(mgr); err != nil {
+	if err := (&DNSet{}).setupWebhookWithManager(mgr, w); err != nil {
 		return err
 	}
-	if err := (&CNSet{}).setupWebhookWithManager(mgr); err != nil {
+	if err := (&CNSet{}).setupWebhookWithManager(mgr, w); err != nil {
 		return err
 	}
 	if err := (&WebUI{}).setupWebhookWithManager(mgr); err != nil {
@@ -51,13 +204,42 @@ func invalidOrNil(allErrs field.ErrorList, r client.Object) error {
 }
 
 func validateLogSetRef(ref *LogSetRef, parent *field.Path) field.ErrorList {
+	switch {
+	case ref.LogSet == nil && ref.ExternalLogSet == nil:
+		return field.ErrorList{field.Required(parent, "one of deps.logSet or deps.externalLogSet must be set")}
+	case ref.LogSet != nil && ref.ExternalLogSet != nil:
+		return field.ErrorList{field.Forbidden(parent.Child("externalLogSet"), "deps.logSet and deps.externalLogSet are mutually exclusive")}
+	case ref.ExternalLogSet != nil:
+		return validateExternalLogSet(ref.ExternalLogSet, parent.Child("externalLogSet"))
+	}
+	return nil
+}
+
+func validateExternalLogSet(ext *ExternalLogSet, parent *field.Path) field.ErrorList {
+	path := parent.Child("haKeeperEndpoint")
+	if ext.HAKeeperEndpoint == "" {
+		return field.ErrorList{field.Required(path, "the HAKeeper endpoint of the external LogSet must be set")}
+	}
+	host, port, err := net.SplitHostPort(ext.HAKeeperEndpoint)
+	if err != nil || host == "" {
+		return field.ErrorList{field.Invalid(path, ext.HAKeeperEndpoint, "must be in the form of host:port")}
+	}
 	var errs field.ErrorList
-	if ref.LogSet == nil && ref.ExternalLogSet == nil {
-		errs = append(errs, field.Invalid(parent, nil, "one of deps.logSet or deps.externalLogSet must be set"))
+	for _, msg := range validation.IsValidPortNum(portNum(port)) {
+		errs = append(errs, field.Invalid(path, ext.HAKeeperEndpoint, msg))
 	}
 	return errs
 }
 
+// portNum parses the port number, an invalid port is returned as 0
+func portNum(s string) int {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
 func validateMainContainer(c *MainContainer, parent *field.Path) field.ErrorList {
 	var errs field.ErrorList
 	if c.Image == "" {
@@ -66,6 +248,407 @@ func validateMainContainer(c *MainContainer, parent *field.Path) field.ErrorList
 	return errs
 }
 
+// cacheSize is the sizes of the cache volume and the disk cache of a set
+type cacheSize struct {
+	volume *resource.Quantity
+	disk   *resource.Quantity
+}
+
+func cacheSizeOf(v *Volume, c *SharedStorageCache) *cacheSize {
+	s := &cacheSize{disk: c.DiskCacheSize}
+	if v != nil {
+		s.volume = &v.Size
+	}
+	return s
+}
+
+// validateCacheSize validates the cache volume and the disk cache against the minimum cache size of the
+// MO version of the image. Only the sizes that are changed from old, which is nil on creation, are
+// validated so that raising the minimum does not block the updates of the existing sets. The validation
+// is skipped if the SkipCacheSizeValidationAnnotation of the object is set.
+func validateCacheSize(image string, cur *cacheSize, old *cacheSize, meta metav1.ObjectMeta, p *WebhookPolicy, parent *field.Path) field.ErrorList {
+	minSize := p.MinCacheSizes.minSizeOf(imageTag(image))
+	if minSize.IsZero() || meta.Annotations[SkipCacheSizeValidationAnnotation] == "true" {
+		return nil
+	}
+	if old == nil {
+		old = &cacheSize{}
+	}
+	var errs field.ErrorList
+	hint := fmt.Sprintf("must be no less than %s to avoid cache thrashing, set annotation %s=true to skip this validation", minSize.String(), SkipCacheSizeValidationAnnotation)
+	tooSmall := func(size, oldSize *resource.Quantity) bool {
+		if size == nil || (oldSize != nil && size.Cmp(*oldSize) == 0) {
+			return false
+		}
+		return size.Cmp(minSize) < 0
+	}
+	if tooSmall(cur.volume, old.volume) {
+		errs = append(errs, field.Invalid(parent.Child("cacheVolume", "size"), cur.volume.String(), hint))
+	}
+	if tooSmall(cur.disk, old.disk) {
+		errs = append(errs, field.Invalid(parent.Child("sharedStorageCache", "diskCacheSize"), cur.disk.String(), hint))
+	}
+	return errs
+}
+
+func validateVolumeMetadata(m *VolumeMetadata, parent *field.Path) field.ErrorList {
+	if m == nil {
+		return nil
+	}
+	return validateMetadata(m.Labels, m.Annotations, parent)
+}
+
+// validateMetadata validates the labels and annotations that are added to the generated objects
+func validateMetadata(labels map[string]string, annotations map[string]string, parent *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	errs = append(errs, metav1validation.ValidateLabels(labels, parent.Child("labels"))...)
+	errs = append(errs, apivalidation.ValidateAnnotations(annotations, parent.Child("annotations"))...)
+	for k := range labels {
+		if isReservedKey(k) {
+			errs = append(errs, field.Forbidden(parent.Child("labels").Key(k), "the key is reserved by the operator"))
+		}
+	}
+	for k := range annotations {
+		if isReservedKey(k) {
+			errs = append(errs, field.Forbidden(parent.Child("annotations").Key(k), "the key is reserved by the operator"))
+		}
+	}
+	return errs
+}
+
+func validateCommonLabels(labels map[string]string, path *field.Path) field.ErrorList {
+	errs := metav1validation.ValidateLabels(labels, path)
+	for k := range labels {
+		if isReservedKey(k) {
+			errs = append(errs, field.Forbidden(path.Key(k), "the key is reserved by the operator"))
+		}
+	}
+	return errs
+}
+
+// isReservedKey returns whether the label or annotation key is reserved by the operator
+func isReservedKey(k string) bool {
+	return strings.HasPrefix(k, reservedKeyDomain+"/") || strings.HasSuffix(strings.SplitN(k, "/", 2)[0], "."+reservedKeyDomain)
+}
+
+func validateTimezone(tz string, parent *field.Path) field.ErrorList {
+	if tz == "" {
+		return nil
+	}
+	// Local is the timezone of the webhook rather than an IANA timezone
+	if _, err := time.LoadLocation(tz); err != nil || tz == "Local" {
+		return field.ErrorList{field.Invalid(parent, tz, "must be a valid IANA timezone")}
+	}
+	return nil
+}
+
+func validateOverlay(o *Overlay, parent *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	if o == nil {
+		return nil
+	}
+	if len(o.CommandWrapper) > 0 && !strings.HasPrefix(o.CommandWrapper[0], "/") {
+		errs = append(errs, field.Invalid(parent.Child("commandWrapper").Index(0), o.CommandWrapper[0], "must be an absolute path"))
+	}
+	return errs
+}
+
+// validate validates the pod settings shared by the sets that run MO, o is the overlay of the set
+// and topology is the topology spread domains the set actually uses
+func (p *PodSet) validate(o *Overlay, topology []string, parent *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	errs = append(errs, validateContainerSecurityContext(p, o, parent)...)
+	errs = append(errs, validateTmpVolume(p, o, parent)...)
+	errs = append(errs, validateEphemeralStorage(p, parent)...)
+	errs = append(errs, validateImagePullDeadline(p, parent)...)
+	errs = append(errs, validateTopologySpreadPolicy(topology, p.TopologySpreadPolicy, parent)...)
+	errs = append(errs, validateDataDir(p.DataDir, parent.Child("dataDir"))...)
+	return errs
+}
+
+// validateContainerSecurityContext validates that the hardened main container can still run the
+// entrypoint, which writes temporary files to /tmp
+func validateContainerSecurityContext(p *PodSet, o *Overlay, parent *field.Path) field.ErrorList {
+	sc := p.ContainerSecurityContext
+	if sc == nil {
+		return nil
+	}
+	var errs field.ErrorList
+	path := parent.Child("containerSecurityContext")
+	if sc.RunAsUser != nil && *sc.RunAsUser == 0 {
+		errs = append(errs, field.Invalid(path.Child("runAsUser"), *sc.RunAsUser, "must not be root"))
+	}
+	if sc.ReadOnlyRootFilesystem && p.TmpVolume == nil && !hasTmpVolumeMount(o) {
+		errs = append(errs, field.Invalid(path.Child("readOnlyRootFilesystem"), sc.ReadOnlyRootFilesystem,
+			"the entrypoint requires a writable /tmp, set .tmpVolume or mount a volume at /tmp through the overlay"))
+	}
+	return errs
+}
+
+func validateTmpVolume(p *PodSet, o *Overlay, parent *field.Path) field.ErrorList {
+	tv := p.TmpVolume
+	if tv == nil {
+		return nil
+	}
+	var errs field.ErrorList
+	path := parent.Child("tmpVolume")
+	if hasTmpVolumeMount(o) {
+		errs = append(errs, field.Forbidden(path, "a volume is already mounted at /tmp through the overlay"))
+	}
+	if tv.Medium != corev1.StorageMediumDefault && tv.Medium != corev1.StorageMediumMemory {
+		errs = append(errs, field.NotSupported(path.Child("medium"), tv.Medium, []string{string(corev1.StorageMediumMemory)}))
+	}
+	if tv.SizeLimit == nil {
+		if tv.Medium == corev1.StorageMediumMemory {
+			errs = append(errs, field.Required(path.Child("sizeLimit"), "sizeLimit is required for Memory medium to bound the memory usage"))
+		}
+		return errs
+	}
+	if tv.SizeLimit.Sign() <= 0 {
+		errs = append(errs, field.Invalid(path.Child("sizeLimit"), tv.SizeLimit.String(), "must be positive"))
+	}
+	if mem := p.Resources.Limits.Memory(); tv.Medium == corev1.StorageMediumMemory && !mem.IsZero() && tv.SizeLimit.Cmp(*mem) >= 0 {
+		errs = append(errs, field.Invalid(path.Child("sizeLimit"), tv.SizeLimit.String(), "must be less than the memory limit of the container"))
+	}
+	return errs
+}
+
+func validateEphemeralStorage(p *PodSet, parent *field.Path) field.ErrorList {
+	es := p.EphemeralStorage
+	if es == nil {
+		return nil
+	}
+	var errs field.ErrorList
+	path := parent.Child("ephemeralStorage")
+	if es.Request != nil && es.Request.Sign() <= 0 {
+		errs = append(errs, field.Invalid(path.Child("request"), es.Request.String(), "must be positive"))
+	}
+	if es.Limit == nil {
+		return errs
+	}
+	if es.Limit.Sign() <= 0 {
+		return append(errs, field.Invalid(path.Child("limit"), es.Limit.String(), "must be positive"))
+	}
+	request := es.Request
+	if q, ok := p.Resources.Requests[corev1.ResourceEphemeralStorage]; ok {
+		request = &q
+	}
+	if _, ok := p.Resources.Limits[corev1.ResourceEphemeralStorage]; !ok && request != nil && request.Cmp(*es.Limit) > 0 {
+		errs = append(errs, field.Invalid(path.Child("limit"), es.Limit.String(), "must be no less than the ephemeral-storage request"))
+	}
+	if tv := p.TmpVolume; tv != nil && tv.Medium == corev1.StorageMediumDefault && tv.SizeLimit != nil && tv.SizeLimit.Cmp(*es.Limit) > 0 {
+		errs = append(errs, field.Invalid(path.Child("limit"), es.Limit.String(), "must be no less than the sizeLimit of the tmp volume on the node storage"))
+	}
+	return errs
+}
+
+func validateImagePullDeadline(p *PodSet, parent *field.Path) field.ErrorList {
+	if d := p.ImagePullDeadline; d != nil && d.Duration <= 0 {
+		return field.ErrorList{field.Invalid(parent.Child("imagePullDeadline"), d.Duration.String(), "must be positive")}
+	}
+	return nil
+}
+
+func validateServiceAccountName(name string, parent *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	if name == "" {
+		return nil
+	}
+	for _, msg := range validation.IsDNS1123Subdomain(name) {
+		errs = append(errs, field.Invalid(parent, name, msg))
+	}
+	return errs
+}
+
+// minStuckPodTimeout keeps a transient node NotReady from causing force deletions
+const minStuckPodTimeout = time.Minute
+
+func validateStuckPodPolicy(p *StuckPodPolicy, parent *field.Path) field.ErrorList {
+	if p == nil || p.Timeout == nil {
+		return nil
+	}
+	if p.Timeout.Duration < minStuckPodTimeout {
+		return field.ErrorList{field.Invalid(parent.Child("timeout"), p.Timeout.Duration.String(), "must not be less than 1m")}
+	}
+	return nil
+}
+
+// validateConfigFileNames validates the overridden names of the config file and the entrypoint script,
+// which share the same ConfigMap and the same mount path
+func validateConfigFileNames(p *PodSet, parent *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	if n := p.ConfigFileName; n != "" {
+		for _, msg := range validation.IsConfigMapKey(n) {
+			errs = append(errs, field.Invalid(parent.Child("configFileName"), n, msg))
+		}
+	}
+	if n := p.EntrypointFileName; n != "" {
+		for _, msg := range validation.IsConfigMapKey(n) {
+			errs = append(errs, field.Invalid(parent.Child("entrypointFileName"), n, msg))
+		}
+		if n == p.ConfigFileName {
+			errs = append(errs, field.Invalid(parent.Child("entrypointFileName"), n, "must be different from configFileName"))
+		}
+	}
+	return errs
+}
+
+// validateHeadlessService validates the cluster IP of the headless service, the pods are discovered
+// by their DNS records which are only published by a headless service if required
+func validateHeadlessService(p *PodSet, requireHeadless bool, parent *field.Path) field.ErrorList {
+	if p.HeadlessService == nil {
+		return nil
+	}
+	path := parent.Child("clusterIP")
+	ip := p.GetHeadlessClusterIP()
+	switch {
+	case ip == corev1.ClusterIPNone:
+		return nil
+	case ip != "" && net.ParseIP(ip) == nil:
+		return field.ErrorList{field.Invalid(path, ip, "clusterIP must be None, empty or a valid IP address")}
+	case requireHeadless:
+		return field.ErrorList{field.Invalid(path, ip, "clusterIP must be None since the pods are discovered by their DNS records")}
+	case p.DNSBasedIdentity:
+		return field.ErrorList{field.Invalid(path, ip, "clusterIP must be None when dnsBasedIdentity is enabled")}
+	}
+	return nil
+}
+
+func validateHeadlessServiceUpdate(cur, old *PodSet, parent *field.Path) field.ErrorList {
+	if cur.GetHeadlessClusterIP() != old.GetHeadlessClusterIP() {
+		return field.ErrorList{field.Invalid(parent.Child("clusterIP"), cur.GetHeadlessClusterIP(), "clusterIP is immutable")}
+	}
+	return nil
+}
+
+func validateNetworkAttachment(n *NetworkAttachment, parent *field.Path) field.ErrorList {
+	if n == nil {
+		return nil
+	}
+	var errs field.ErrorList
+	name := n.Name
+	if ns, nn, ok := strings.Cut(n.Name, "/"); ok {
+		for _, msg := range validation.IsDNS1123Label(ns) {
+			errs = append(errs, field.Invalid(parent.Child("name"), n.Name, msg))
+		}
+		name = nn
+	}
+	for _, msg := range validation.IsDNS1123Subdomain(name) {
+		errs = append(errs, field.Invalid(parent.Child("name"), n.Name, msg))
+	}
+	if n.Interface != "" && !networkInterfacePattern.MatchString(n.Interface) {
+		errs = append(errs, field.Invalid(parent.Child("interface"), n.Interface, "must be a valid network interface name of at most 15 characters"))
+	}
+	return errs
+}
+
+func hasTmpVolumeMount(o *Overlay) bool {
+	if o == nil {
+		return false
+	}
+	for _, m := range o.VolumeMounts {
+		if path.Clean(m.MountPath) == tmpPath && !m.ReadOnly {
+			return true
+		}
+	}
+	return false
+}
+
+// validateAntiAffinityPolicy rejects the Required anti-affinity policy that can never be satisfied,
+// i.e. when multiple replicas of the set are pinned to a single node by the node selector
+func validateAntiAffinityPolicy(p *PodSet, nodeSelector map[string]string, parent *field.Path) field.ErrorList {
+	if p.GetAntiAffinityPolicy(AntiAffinityPolicyPreferred) != AntiAffinityPolicyRequired || p.Replicas <= 1 {
+		return nil
+	}
+	if node := nodeSelector[corev1.LabelHostname]; node != "" {
+		return field.ErrorList{field.Invalid(parent.Child("antiAffinityPolicy"), p.AntiAffinityPolicy,
+			fmt.Sprintf("%d replicas are pinned to node %s and can never be spread across nodes", p.Replicas, node))}
+	}
+	return nil
+}
+
+func validateTopologySpreadPolicy(domains []string, policy *TopologySpreadPolicy, parent *field.Path) field.ErrorList {
+	if policy == nil {
+		return nil
+	}
+	var errs field.ErrorList
+	path := parent.Child("topologySpreadPolicy")
+	if len(domains) == 0 {
+		errs = append(errs, field.Required(parent.Child("topologySpread"), "topologySpread must be set to use topologySpreadPolicy"))
+	}
+	if policy.MinDomains == nil {
+		return errs
+	}
+	if *policy.MinDomains < 1 {
+		errs = append(errs, field.Invalid(path.Child("minDomains"), *policy.MinDomains, "must be positive"))
+	}
+	if policy.WhenUnsatisfiable == corev1.ScheduleAnyway {
+		errs = append(errs, field.Invalid(path.Child("minDomains"), *policy.MinDomains, "minDomains requires whenUnsatisfiable to be DoNotSchedule"))
+	}
+	return errs
+}
+
+// validateCacheSharingMode validates that the MO version of the image supports the cache sharing mode,
+// versions that are not semantic (e.g. nightly builds) are not validated
+func validateCacheSharingMode(mode CacheSharingMode, image string, parent *field.Path) field.ErrorList {
+	if mode != CacheSharingModeDistributed {
+		return nil
+	}
+	version := imageTag(image)
+	series, ok := minorSeries(version)
+	if !ok || minorSeriesAtLeast(series, distributedCacheMinVersion) {
+		return nil
+	}
+	return field.ErrorList{field.Invalid(parent.Child("cacheSharingMode"), mode,
+		fmt.Sprintf("requires MO %s or later, got %s", distributedCacheMinVersion, version))}
+}
+
+// validateDataDir validates that the data dir is a single directory under the data volume
+func validateDataDir(dir string, parent *field.Path) field.ErrorList {
+	if dir == "" {
+		return nil
+	}
+	if dir == "." || dir == ".." || strings.ContainsAny(dir, `/\`) {
+		return field.ErrorList{field.Invalid(parent, dir, "must be a directory name without path separators")}
+	}
+	return nil
+}
+
+func validateExtraServiceArgs(args []string, parent *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	seen := map[string]bool{}
+	for i, arg := range args {
+		if !strings.HasPrefix(arg, "-") {
+			// value of the previous flag
+			continue
+		}
+		name := strings.SplitN(strings.TrimLeft(arg, "-"), "=", 2)[0]
+		if name == configFlag {
+			errs = append(errs, field.Invalid(parent.Index(i), arg, fmt.Sprintf("flag -%s is managed by the operator", configFlag)))
+		} else if seen[name] {
+			errs = append(errs, field.Duplicate(parent.Index(i), arg))
+		}
+		seen[name] = true
+	}
+	return errs
+}
+
+func validateSpillVolume(v *SpillVolume, parent *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	if (v.EmptyDir == nil) == (v.HostPath == nil) {
+		errs = append(errs, field.Invalid(parent, nil, "exactly one of emptyDir or hostPath must be set"))
+	}
+	if v.HostPath != nil {
+		if !v.AllowHostPath {
+			errs = append(errs, field.Forbidden(parent.Child("hostPath"), "allowHostPath must be set to use a hostPath spill volume"))
+		}
+		if !strings.HasPrefix(v.HostPath.Path, "/") {
+			errs = append(errs, field.Invalid(parent.Child("hostPath", "path"), v.HostPath.Path, "must be an absolute path"))
+		}
+	}
+	return errs
+}
+
 func validateVolume(v *Volume, parent *field.Path) field.ErrorList {
 	var errs field.ErrorList
 	if v.Size.IsZero() {
@@ -73,3 +656,83 @@ func validateVolume(v *Volume, parent *field.Path) field.ErrorList {
 	}
 	return errs
 }
+
+func validateNameOverride(name string, parent *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	if name == "" {
+		return nil
+	}
+	for _, msg := range validation.IsDNS1035Label(name) {
+		errs = append(errs, field.Invalid(parent, name, msg))
+	}
+	return errs
+}
+
+func validateNameOverrideUpdate(cur, old string, parent *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	if cur != old {
+		errs = append(errs, field.Invalid(parent, cur, "nameOverride is immutable"))
+	}
+	return errs
+}
+
+// defaultDiskCacheSize returns the default disk cache size on the cache volume of the given size,
+// max(headroom floor, headroom percent of the volume) is left for the filesystem overhead and is
+// capped to half of the volume so that small volumes still have a usable cache
+func defaultDiskCacheSize(volumeSize resource.Quantity, p *WebhookPolicy) *resource.Quantity {
+	total := volumeSize.Value()
+	headroom := total * p.DiskCacheHeadroomPercent / 100
+	if floor := p.DiskCacheHeadroomFloor.Value(); headroom < floor {
+		headroom = floor
+	}
+	if headroom > total/2 {
+		headroom = total / 2
+	}
+	return resource.NewQuantity(total-headroom, resource.BinarySI)
+}
+
+// validateForceFailover validates that the store to be failed over exists when the
+// ForceFailoverAnnotation is changed
+func validateForceFailover(cur, old metav1.ObjectMeta, status *FailoverStatus) field.ErrorList {
+	podName := cur.Annotations[ForceFailoverAnnotation]
+	if podName == "" || podName == old.Annotations[ForceFailoverAnnotation] {
+		return nil
+	}
+	for _, stores := range [][]Store{status.AvailableStores, status.SuspectedStores, status.FailedStores} {
+		for _, s := range stores {
+			if s.PodName == podName {
+				return nil
+			}
+		}
+	}
+	return field.ErrorList{field.NotFound(field.NewPath("metadata").Child("annotations").Key(ForceFailoverAnnotation), podName)}
+}
+
+func validateSysctls(sysctls []corev1.Sysctl, allowUnsafe bool, parent *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	seen := map[string]bool{}
+	for i, s := range sysctls {
+		path := parent.Index(i).Child("name")
+		if seen[s.Name] {
+			errs = append(errs, field.Duplicate(path, s.Name))
+		}
+		seen[s.Name] = true
+		if !isNamespacedSysctl(s.Name) {
+			errs = append(errs, field.Invalid(path, s.Name, "must be a namespaced sysctl"))
+			continue
+		}
+		if !safeSysctls[s.Name] && !allowUnsafe {
+			errs = append(errs, field.Forbidden(path, fmt.Sprintf("sysctl %s is unsafe, allowUnsafeSysctls must be set and the sysctl must be allowed by the kubelet", s.Name)))
+		}
+	}
+	return errs
+}
+
+func isNamespacedSysctl(name string) bool {
+	for _, prefix := range namespacedSysctlPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -97,7 +97,7 @@ var _ = BeforeSuite(func() {
 		MetricsBindAddress: "0",
 	})
 	Expect(err).NotTo(HaveOccurred())
-	Expect(RegisterWebhooks(mgr)).To(Succeed())
+	Expect(RegisterWebhooks(mgr, DefaultWebhookPolicy())).To(Succeed())
 
 	go func() {
 		err = mgr.Start(ctx)
@@ -0,0 +1,920 @@
+// Copyright 2023 Matrix Origin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/utils/pointer"
+)
+
+func TestDefaultDiskCacheSize(t *testing.T) {
+	tests := []struct {
+		name   string
+		volume string
+		want   string
+	}{{
+		name:   "small volume",
+		volume: "1Gi",
+		want:   "512Mi",
+	}, {
+		name:   "floor",
+		volume: "10Gi",
+		want:   "8Gi",
+	}, {
+		name:   "percent",
+		volume: "100Gi",
+		want:   "95Gi",
+	}, {
+		name:   "large volume",
+		volume: "2000Gi",
+		want:   "1900Gi",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			policy := DefaultWebhookPolicy()
+			got := defaultDiskCacheSize(resource.MustParse(tt.volume), &policy)
+			g.Expect(got.Cmp(resource.MustParse(tt.want))).To(BeZero(), "got %s", got.String())
+		})
+	}
+}
+
+func TestValidateVolumeMetadata(t *testing.T) {
+	tests := []struct {
+		name    string
+		meta    *VolumeMetadata
+		wantErr bool
+	}{{
+		name: "valid",
+		meta: &VolumeMetadata{
+			Labels:      map[string]string{"backup": "true"},
+			Annotations: map[string]string{"backup.velero.io/backup-volumes": "mo-data"},
+		},
+	}, {
+		name:    "reserved label",
+		meta:    &VolumeMetadata{Labels: map[string]string{"matrixorigin.io/component": "CNSet"}},
+		wantErr: true,
+	}, {
+		name:    "reserved annotation of subdomain",
+		meta:    &VolumeMetadata{Annotations: map[string]string{"backup.matrixorigin.io/skip": "true"}},
+		wantErr: true,
+	}, {
+		name:    "invalid label",
+		meta:    &VolumeMetadata{Labels: map[string]string{"backup": "not valid"}},
+		wantErr: true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			errs := validateVolumeMetadata(tt.meta, field.NewPath("spec").Child("volumeMetadata"))
+			g.Expect(len(errs) > 0).To(Equal(tt.wantErr), "errs: %v", errs)
+		})
+	}
+}
+
+func TestValidateCacheSize(t *testing.T) {
+	g := NewGomegaWithT(t)
+	parent := field.NewPath("spec")
+	policy := DefaultWebhookPolicy()
+	image := "matrixorigin/matrixone:1.0.0"
+	small := &Volume{Size: resource.MustParse("100Mi")}
+	g.Expect(validateCacheSize(image, cacheSizeOf(small, &SharedStorageCache{}), nil, metav1.ObjectMeta{}, &policy, parent)).To(HaveLen(1))
+	smallCache := small.Size.DeepCopy()
+	g.Expect(validateCacheSize(image, cacheSizeOf(&Volume{Size: resource.MustParse("10Gi")}, &SharedStorageCache{
+		DiskCacheSize: &smallCache,
+	}), nil, metav1.ObjectMeta{}, &policy, parent)).To(HaveLen(1))
+	g.Expect(validateCacheSize(image, cacheSizeOf(small, &SharedStorageCache{}), nil, metav1.ObjectMeta{
+		Annotations: map[string]string{SkipCacheSizeValidationAnnotation: "true"},
+	}, &policy, parent)).To(BeEmpty())
+	g.Expect(validateCacheSize(image, cacheSizeOf(nil, &SharedStorageCache{}), nil, metav1.ObjectMeta{}, &policy, parent)).To(BeEmpty())
+
+	// sizes that are not changed by the update are not validated
+	g.Expect(validateCacheSize(image, cacheSizeOf(small, &SharedStorageCache{}), cacheSizeOf(small, &SharedStorageCache{}),
+		metav1.ObjectMeta{}, &policy, parent)).To(BeEmpty())
+	g.Expect(validateCacheSize(image, cacheSizeOf(small, &SharedStorageCache{}), cacheSizeOf(&Volume{Size: resource.MustParse("200Mi")}, &SharedStorageCache{}),
+		metav1.ObjectMeta{}, &policy, parent)).To(HaveLen(1))
+
+	// the minimum depends on the MO version of the image
+	policy.MinCacheSizes = MinCacheSizes{"1.0": resource.MustParse("50Mi")}
+	g.Expect(validateCacheSize(image, cacheSizeOf(small, &SharedStorageCache{}), nil, metav1.ObjectMeta{}, &policy, parent)).To(BeEmpty())
+	g.Expect(validateCacheSize("matrixorigin/matrixone:0.8.0", cacheSizeOf(small, &SharedStorageCache{}), nil, metav1.ObjectMeta{}, &policy, parent)).To(BeEmpty())
+	policy.MinCacheSizes = nil
+	g.Expect(validateCacheSize(image, cacheSizeOf(small, &SharedStorageCache{}), nil, metav1.ObjectMeta{}, &policy, parent)).To(BeEmpty())
+}
+
+func TestMinCacheSizes(t *testing.T) {
+	g := NewGomegaWithT(t)
+	m, err := ParseMinCacheSizes([]byte(`
+"0.8": 1Gi
+"v1.0": 4Gi
+`))
+	g.Expect(err).NotTo(HaveOccurred())
+	tests := []struct {
+		version string
+		want    string
+	}{{
+		version: "0.7.0",
+		want:    "0",
+	}, {
+		version: "0.8.1",
+		want:    "1Gi",
+	}, {
+		version: "v0.9.0",
+		want:    "1Gi",
+	}, {
+		version: "1.0.0-rc1",
+		want:    "4Gi",
+	}, {
+		version: "nightly-1a2b3c",
+		want:    "4Gi",
+	}}
+	for _, tt := range tests {
+		got := m.minSizeOf(tt.version)
+		g.Expect(got.Cmp(resource.MustParse(tt.want))).To(BeZero(), "version %s, got %s", tt.version, got.String())
+	}
+
+	_, err = ParseMinCacheSizes([]byte(`latest: 1Gi`))
+	g.Expect(err).To(HaveOccurred())
+	_, err = ParseMinCacheSizes([]byte(`"1.0": large`))
+	g.Expect(err).To(HaveOccurred())
+}
+
+func TestRequireCNCacheVolume(t *testing.T) {
+	g := NewGomegaWithT(t)
+	policy := DefaultWebhookPolicy()
+	policy.RequireCNCacheVolume = true
+	cn := &CNSet{
+		Spec: CNSetSpec{
+			CNSetBasic: CNSetBasic{
+				PodSet: PodSet{
+					Replicas:      2,
+					MainContainer: MainContainer{Image: "test"},
+				},
+			},
+		},
+		Deps: CNSetDeps{
+			LogSetRef: LogSetRef{ExternalLogSet: &ExternalLogSet{HAKeeperEndpoint: "test:32001"}},
+		},
+	}
+	g.Expect(cn.ValidateCreate(&policy)).NotTo(Succeed())
+	g.Expect(cn.ValidateUpdate(cn.DeepCopy(), &policy)).To(Succeed(), "existing CNSets can be updated without a cache volume")
+	withCache := cn.DeepCopy()
+	withCache.Spec.CacheVolume = &Volume{Size: resource.MustParse("10Gi")}
+	g.Expect(withCache.ValidateCreate(&policy)).To(Succeed())
+
+	mo := &MatrixOneCluster{
+		Spec: MatrixOneClusterSpec{
+			TP:      withCache.Spec.CNSetBasic,
+			Version: "1.0.0",
+		},
+	}
+	old := mo.DeepCopy()
+	mo.Spec.AP = cn.Spec.CNSetBasic.DeepCopy()
+	g.Expect(mo.validateCacheVolumeRequired(old, &policy)).To(HaveLen(1), "ap added without a cache volume")
+	g.Expect(mo.validateCacheVolumeRequired(mo.DeepCopy(), &policy)).To(BeEmpty())
+}
+
+func TestValidateContainerSecurityContext(t *testing.T) {
+	tmpOverlay := &Overlay{
+		MainContainerOverlay: MainContainerOverlay{
+			VolumeMounts: []corev1.VolumeMount{{Name: "tmp", MountPath: "/tmp/"}},
+		},
+	}
+	tests := []struct {
+		name    string
+		podSet  PodSet
+		overlay *Overlay
+		wantErr bool
+	}{{
+		name:   "non-root",
+		podSet: PodSet{ContainerSecurityContext: &ContainerSecurityContext{RunAsUser: pointer.Int64(1000)}},
+	}, {
+		name:    "root",
+		podSet:  PodSet{ContainerSecurityContext: &ContainerSecurityContext{RunAsUser: pointer.Int64(0)}},
+		wantErr: true,
+	}, {
+		name:    "read-only root without writable tmp",
+		podSet:  PodSet{ContainerSecurityContext: &ContainerSecurityContext{ReadOnlyRootFilesystem: true}},
+		wantErr: true,
+	}, {
+		name:    "read-only root with writable tmp",
+		podSet:  PodSet{ContainerSecurityContext: &ContainerSecurityContext{ReadOnlyRootFilesystem: true}},
+		overlay: tmpOverlay,
+	}, {
+		name: "read-only root with tmp volume",
+		podSet: PodSet{
+			ContainerSecurityContext: &ContainerSecurityContext{ReadOnlyRootFilesystem: true},
+			TmpVolume:                &TmpVolume{},
+		},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			errs := validateContainerSecurityContext(&tt.podSet, tt.overlay, field.NewPath("spec"))
+			g.Expect(len(errs) > 0).To(Equal(tt.wantErr), "errs: %v", errs)
+		})
+	}
+}
+
+func TestValidateTmpVolume(t *testing.T) {
+	memLimited := MainContainer{
+		Resources: corev1.ResourceRequirements{
+			Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")},
+		},
+	}
+	size := func(s string) *resource.Quantity {
+		q := resource.MustParse(s)
+		return &q
+	}
+	tests := []struct {
+		name    string
+		podSet  PodSet
+		wantErr bool
+	}{{
+		name:   "node storage",
+		podSet: PodSet{TmpVolume: &TmpVolume{}},
+	}, {
+		name:   "memory",
+		podSet: PodSet{MainContainer: memLimited, TmpVolume: &TmpVolume{Medium: corev1.StorageMediumMemory, SizeLimit: size("64Mi")}},
+	}, {
+		name:    "memory without size limit",
+		podSet:  PodSet{TmpVolume: &TmpVolume{Medium: corev1.StorageMediumMemory}},
+		wantErr: true,
+	}, {
+		name:    "memory exceeds the memory limit",
+		podSet:  PodSet{MainContainer: memLimited, TmpVolume: &TmpVolume{Medium: corev1.StorageMediumMemory, SizeLimit: size("2Gi")}},
+		wantErr: true,
+	}, {
+		name:    "non-positive size limit",
+		podSet:  PodSet{TmpVolume: &TmpVolume{SizeLimit: size("0")}},
+		wantErr: true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			errs := validateTmpVolume(&tt.podSet, nil, field.NewPath("spec"))
+			g.Expect(len(errs) > 0).To(Equal(tt.wantErr), "errs: %v", errs)
+		})
+	}
+}
+
+func TestValidateLogSetRef(t *testing.T) {
+	tests := []struct {
+		name    string
+		ref     LogSetRef
+		wantErr bool
+	}{{
+		name: "inline",
+		ref:  LogSetRef{LogSet: &LogSet{}},
+	}, {
+		name: "external",
+		ref:  LogSetRef{ExternalLogSet: &ExternalLogSet{HAKeeperEndpoint: "logservice.mo:32001"}},
+	}, {
+		name:    "neither",
+		wantErr: true,
+	}, {
+		name:    "both",
+		ref:     LogSetRef{LogSet: &LogSet{}, ExternalLogSet: &ExternalLogSet{HAKeeperEndpoint: "logservice.mo:32001"}},
+		wantErr: true,
+	}, {
+		name:    "external without endpoint",
+		ref:     LogSetRef{ExternalLogSet: &ExternalLogSet{}},
+		wantErr: true,
+	}, {
+		name:    "external without port",
+		ref:     LogSetRef{ExternalLogSet: &ExternalLogSet{HAKeeperEndpoint: "logservice.mo"}},
+		wantErr: true,
+	}, {
+		name:    "external with invalid port",
+		ref:     LogSetRef{ExternalLogSet: &ExternalLogSet{HAKeeperEndpoint: "logservice.mo:99999"}},
+		wantErr: true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			errs := validateLogSetRef(&tt.ref, field.NewPath("deps"))
+			g.Expect(len(errs) > 0).To(Equal(tt.wantErr), "errs: %v", errs)
+		})
+	}
+}
+
+func TestValidateNetworkAttachment(t *testing.T) {
+	tests := []struct {
+		name       string
+		attachment NetworkAttachment
+		wantErr    bool
+	}{{
+		name:       "name",
+		attachment: NetworkAttachment{Name: "data-plane"},
+	}, {
+		name:       "namespaced name with interface",
+		attachment: NetworkAttachment{Name: "mo/data-plane", Interface: "eth1"},
+	}, {
+		name:       "empty name",
+		attachment: NetworkAttachment{},
+		wantErr:    true,
+	}, {
+		name:       "invalid namespace",
+		attachment: NetworkAttachment{Name: "MO/data-plane"},
+		wantErr:    true,
+	}, {
+		name:       "interface too long",
+		attachment: NetworkAttachment{Name: "data-plane", Interface: "a-very-long-interface"},
+		wantErr:    true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			errs := validateNetworkAttachment(&tt.attachment, field.NewPath("spec").Child("networkAttachment"))
+			g.Expect(len(errs) > 0).To(Equal(tt.wantErr), "errs: %v", errs)
+		})
+	}
+}
+
+func TestValidateEphemeralStorage(t *testing.T) {
+	size := func(s string) *resource.Quantity {
+		q := resource.MustParse(s)
+		return &q
+	}
+	tests := []struct {
+		name    string
+		podSet  PodSet
+		wantErr bool
+	}{{
+		name:   "request and limit",
+		podSet: PodSet{EphemeralStorage: &EphemeralStorage{Request: size("1Gi"), Limit: size("4Gi")}},
+	}, {
+		name:    "request exceeds limit",
+		podSet:  PodSet{EphemeralStorage: &EphemeralStorage{Request: size("8Gi"), Limit: size("4Gi")}},
+		wantErr: true,
+	}, {
+		name: "request in resources exceeds limit",
+		podSet: PodSet{
+			MainContainer: MainContainer{Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceEphemeralStorage: resource.MustParse("8Gi")},
+			}},
+			EphemeralStorage: &EphemeralStorage{Limit: size("4Gi")},
+		},
+		wantErr: true,
+	}, {
+		name: "tmp volume exceeds limit",
+		podSet: PodSet{
+			TmpVolume:        &TmpVolume{SizeLimit: size("8Gi")},
+			EphemeralStorage: &EphemeralStorage{Limit: size("4Gi")},
+		},
+		wantErr: true,
+	}, {
+		name:    "non-positive request",
+		podSet:  PodSet{EphemeralStorage: &EphemeralStorage{Request: size("0")}},
+		wantErr: true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			errs := validateEphemeralStorage(&tt.podSet, field.NewPath("spec"))
+			g.Expect(len(errs) > 0).To(Equal(tt.wantErr), "errs: %v", errs)
+		})
+	}
+}
+
+func TestValidateImagePullDeadline(t *testing.T) {
+	g := NewGomegaWithT(t)
+	g.Expect(validateImagePullDeadline(&PodSet{}, field.NewPath("spec"))).To(BeEmpty())
+	g.Expect(validateImagePullDeadline(&PodSet{ImagePullDeadline: &metav1.Duration{Duration: time.Minute}}, field.NewPath("spec"))).To(BeEmpty())
+	g.Expect(validateImagePullDeadline(&PodSet{ImagePullDeadline: &metav1.Duration{}}, field.NewPath("spec"))).NotTo(BeEmpty())
+}
+
+func TestValidateCacheSharingMode(t *testing.T) {
+	tests := []struct {
+		name    string
+		mode    CacheSharingMode
+		image   string
+		wantErr bool
+	}{{
+		name:  "local",
+		mode:  CacheSharingModeLocal,
+		image: "matrixorigin/matrixone:0.8.0",
+	}, {
+		name:  "distributed on supported version",
+		mode:  CacheSharingModeDistributed,
+		image: "registry:5000/matrixorigin/matrixone:v1.1.0",
+	}, {
+		name:    "distributed on unsupported version",
+		mode:    CacheSharingModeDistributed,
+		image:   "matrixorigin/matrixone:0.8.0",
+		wantErr: true,
+	}, {
+		name:  "distributed on nightly build",
+		mode:  CacheSharingModeDistributed,
+		image: "matrixorigin/matrixone:nightly-abcdef",
+	}, {
+		name:  "untagged image",
+		mode:  CacheSharingModeDistributed,
+		image: "registry:5000/matrixorigin/matrixone",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			errs := validateCacheSharingMode(tt.mode, tt.image, field.NewPath("spec"))
+			g.Expect(len(errs) > 0).To(Equal(tt.wantErr), "errs: %v", errs)
+		})
+	}
+}
+
+func TestValidateRollingUpdatePolicy(t *testing.T) {
+	g := NewGomegaWithT(t)
+	ls := &LogSetBasic{
+		InitialConfig:       InitialConfig{LogShardReplicas: pointer.Int(5)},
+		RollingUpdatePolicy: &LogSetRollingUpdatePolicy{MaxUnavailable: 2},
+	}
+	g.Expect(ls.validateRollingUpdatePolicy()).To(BeEmpty())
+	ls.InitialConfig.LogShardReplicas = pointer.Int(3)
+	g.Expect(ls.validateRollingUpdatePolicy()).NotTo(BeEmpty())
+}
+
+func TestValidateStatusPollInterval(t *testing.T) {
+	g := NewGomegaWithT(t)
+	ls := &LogSetBasic{}
+	g.Expect(ls.validateStatusPollInterval()).To(BeEmpty())
+	g.Expect(ls.GetStatusPollInterval().Duration).To(Equal(defaultStatusPollInterval))
+	ls.StatusPollInterval = &metav1.Duration{Duration: time.Minute}
+	g.Expect(ls.validateStatusPollInterval()).To(BeEmpty())
+	ls.StatusPollInterval = &metav1.Duration{Duration: time.Second}
+	g.Expect(ls.validateStatusPollInterval()).NotTo(BeEmpty())
+	ls.StatusPollInterval = &metav1.Duration{Duration: time.Hour}
+	g.Expect(ls.validateStatusPollInterval()).NotTo(BeEmpty())
+}
+
+func TestValidateUpgrade(t *testing.T) {
+	c, err := ParseUpgradeCompatibility("0.6->0.7, 0.7->0.8")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		from        string
+		to          string
+		annotations map[string]string
+		wantErr     bool
+	}{{
+		name: "patch upgrade",
+		from: "0.7.0",
+		to:   "0.7.1",
+	}, {
+		name: "supported upgrade",
+		from: "v0.6.0",
+		to:   "v0.7.0",
+	}, {
+		name:    "skipped minor version",
+		from:    "0.6.0",
+		to:      "0.8.0",
+		wantErr: true,
+	}, {
+		name:        "forced upgrade",
+		from:        "0.6.0",
+		to:          "0.8.0",
+		annotations: map[string]string{ForceUpgradeAnnotation: "true"},
+	}, {
+		name: "nightly build",
+		from: "0.6.0",
+		to:   "nightly-1a2b3c",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			errs := validateUpgrade(tt.to, tt.from, metav1.ObjectMeta{Annotations: tt.annotations}, c, field.NewPath("spec").Child("version"))
+			g.Expect(len(errs) > 0).To(Equal(tt.wantErr), "errs: %v", errs)
+		})
+	}
+
+	_, err = ParseUpgradeCompatibility("0.6=>0.7")
+	if err == nil {
+		t.Error("invalid upgrade path should be rejected")
+	}
+}
+
+func TestValidateDataDir(t *testing.T) {
+	tests := []struct {
+		dir     string
+		wantErr bool
+	}{
+		{dir: "", wantErr: false},
+		{dir: "mo-data", wantErr: false},
+		{dir: "data_v1", wantErr: false},
+		{dir: ".", wantErr: true},
+		{dir: "..", wantErr: true},
+		{dir: "../data", wantErr: true},
+		{dir: "data/sub", wantErr: true},
+		{dir: `data\sub`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.dir, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			errs := validateDataDir(tt.dir, field.NewPath("spec").Child("dataDir"))
+			g.Expect(len(errs) > 0).To(Equal(tt.wantErr), "errs: %v", errs)
+		})
+	}
+}
+
+func TestValidateScaleStrategy(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   intstr.IntOrString
+		wantErr bool
+	}{{
+		name:  "number",
+		value: intstr.FromInt(3),
+	}, {
+		name:  "percentage",
+		value: intstr.FromString("25%"),
+	}, {
+		name:    "zero",
+		value:   intstr.FromInt(0),
+		wantErr: true,
+	}, {
+		name:    "percentage out of range",
+		value:   intstr.FromString("150%"),
+		wantErr: true,
+	}, {
+		name:    "malformed",
+		value:   intstr.FromString("three"),
+		wantErr: true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			s := &ScaleStrategy{MaxUnavailable: &tt.value}
+			errs := s.validate(field.NewPath("spec").Child("scaleStrategy"))
+			g.Expect(len(errs) > 0).To(Equal(tt.wantErr), "errs: %v", errs)
+		})
+	}
+}
+
+func TestValidateAntiAffinityPolicy(t *testing.T) {
+	g := NewGomegaWithT(t)
+	parent := field.NewPath("spec")
+	pinned := map[string]string{corev1.LabelHostname: "node-1"}
+	p := &PodSet{Replicas: 3}
+	g.Expect(validateAntiAffinityPolicy(p, pinned, parent)).To(BeEmpty(), "Preferred by default")
+	p.AntiAffinityPolicy = AntiAffinityPolicyRequired
+	g.Expect(validateAntiAffinityPolicy(p, pinned, parent)).To(HaveLen(1))
+	g.Expect(validateAntiAffinityPolicy(p, map[string]string{"arch": "arm64"}, parent)).To(BeEmpty())
+	p.Replicas = 1
+	g.Expect(validateAntiAffinityPolicy(p, pinned, parent)).To(BeEmpty())
+
+	mo := &MatrixOneCluster{Spec: MatrixOneClusterSpec{
+		NodeSelector: pinned,
+		DN:           DNSetBasic{PodSet: PodSet{Replicas: 2, AntiAffinityPolicy: AntiAffinityPolicyRequired}},
+		TP:           CNSetBasic{PodSet: PodSet{Replicas: 2}},
+	}}
+	g.Expect(mo.validateAntiAffinity()).To(HaveLen(1))
+	mo.Spec.DN.NodeSelector = map[string]string{"arch": "arm64"}
+	g.Expect(mo.validateAntiAffinity()).To(BeEmpty(), "the node selector of the set takes precedence")
+}
+
+func TestValidateTopologySpreadPolicy(t *testing.T) {
+	zone := []string{corev1.LabelTopologyZone}
+	tests := []struct {
+		name    string
+		domains []string
+		policy  *TopologySpreadPolicy
+		wantErr bool
+	}{{
+		name:    "min domains",
+		domains: zone,
+		policy:  &TopologySpreadPolicy{MinDomains: pointer.Int32(3)},
+	}, {
+		name:    "schedule anyway",
+		domains: zone,
+		policy:  &TopologySpreadPolicy{WhenUnsatisfiable: corev1.ScheduleAnyway},
+	}, {
+		name:    "no topology spread",
+		policy:  &TopologySpreadPolicy{MinDomains: pointer.Int32(3)},
+		wantErr: true,
+	}, {
+		name:    "min domains with schedule anyway",
+		domains: zone,
+		policy:  &TopologySpreadPolicy{MinDomains: pointer.Int32(3), WhenUnsatisfiable: corev1.ScheduleAnyway},
+		wantErr: true,
+	}, {
+		name:    "non-positive min domains",
+		domains: zone,
+		policy:  &TopologySpreadPolicy{MinDomains: pointer.Int32(0)},
+		wantErr: true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			errs := validateTopologySpreadPolicy(tt.domains, tt.policy, field.NewPath("spec"))
+			g.Expect(len(errs) > 0).To(Equal(tt.wantErr), "errs: %v", errs)
+		})
+	}
+}
+
+func TestValidateGuaranteedResources(t *testing.T) {
+	resources := func(requests, limits corev1.ResourceList) *PodSet {
+		return &PodSet{MainContainer: MainContainer{Resources: corev1.ResourceRequirements{Requests: requests, Limits: limits}}}
+	}
+	sized := corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse("2"),
+		corev1.ResourceMemory: resource.MustParse("4Gi"),
+	}
+	tests := []struct {
+		name    string
+		podSet  *PodSet
+		wantErr bool
+	}{{
+		name:   "requests only",
+		podSet: resources(sized, nil),
+	}, {
+		name:   "requests equal to limits",
+		podSet: resources(sized, corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2000m")}),
+	}, {
+		name:    "no requests",
+		podSet:  resources(nil, sized),
+		wantErr: true,
+	}, {
+		name:    "limits greater than requests",
+		podSet:  resources(sized, corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("8Gi")}),
+		wantErr: true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			errs := validateGuaranteedResources(tt.podSet, field.NewPath("spec"))
+			g.Expect(len(errs) > 0).To(Equal(tt.wantErr), "errs: %v", errs)
+		})
+	}
+}
+
+func TestValidateHeadlessService(t *testing.T) {
+	withClusterIP := func(ip string, dnsBasedIdentity bool) *PodSet {
+		return &PodSet{HeadlessService: &HeadlessServicePolicy{ClusterIP: pointer.String(ip)}, DNSBasedIdentity: dnsBasedIdentity}
+	}
+	tests := []struct {
+		name            string
+		podSet          *PodSet
+		requireHeadless bool
+		wantErr         bool
+	}{{
+		name:            "default",
+		podSet:          &PodSet{},
+		requireHeadless: true,
+	}, {
+		name:   "regular service",
+		podSet: withClusterIP("", false),
+	}, {
+		name:   "fixed cluster IP",
+		podSet: withClusterIP("10.96.0.100", false),
+	}, {
+		name:    "invalid cluster IP",
+		podSet:  withClusterIP("not-an-ip", false),
+		wantErr: true,
+	}, {
+		name:    "regular service with dns based identity",
+		podSet:  withClusterIP("", true),
+		wantErr: true,
+	}, {
+		name:            "regular service when headless is required",
+		podSet:          withClusterIP("", false),
+		requireHeadless: true,
+		wantErr:         true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			errs := validateHeadlessService(tt.podSet, tt.requireHeadless, field.NewPath("spec"))
+			g.Expect(len(errs) > 0).To(Equal(tt.wantErr), "errs: %v", errs)
+		})
+	}
+}
+
+func TestValidateAdvertiseAddress(t *testing.T) {
+	tests := []struct {
+		name    string
+		dn      *DNSetBasic
+		wantErr bool
+	}{{
+		name: "default",
+		dn:   &DNSetBasic{},
+	}, {
+		name: "dns name",
+		dn:   &DNSetBasic{AdvertiseAddressTemplate: "dn-{{ .Ordinal }}.{{ .Namespace }}.mo.example.com"},
+	}, {
+		name: "custom cluster domain",
+		dn:   &DNSetBasic{PodSet: PodSet{ClusterDomain: "cluster.example"}},
+	}, {
+		name:    "invalid cluster domain",
+		dn:      &DNSetBasic{PodSet: PodSet{ClusterDomain: "Cluster_Example"}},
+		wantErr: true,
+	}, {
+		name:    "unknown field",
+		dn:      &DNSetBasic{AdvertiseAddressTemplate: "{{ .PodIP }}"},
+		wantErr: true,
+	}, {
+		name:    "shell injection",
+		dn:      &DNSetBasic{AdvertiseAddressTemplate: "$(hostname -i)"},
+		wantErr: true,
+	}, {
+		name: "conflicts with network attachment",
+		dn: &DNSetBasic{
+			AdvertiseAddressTemplate: "{{ .PodName }}.mo.example.com",
+			NetworkAttachment:        &NetworkAttachment{Name: "data-plane"},
+		},
+		wantErr: true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			errs := tt.dn.validateAdvertiseAddress()
+			g.Expect(len(errs) > 0).To(Equal(tt.wantErr), "errs: %v", errs)
+		})
+	}
+}
+
+func TestValidateCompactionConfig(t *testing.T) {
+	g := NewGomegaWithT(t)
+	c := &CompactionConfig{
+		FlushInterval: &metav1.Duration{Duration: time.Minute},
+		ScanInterval:  &metav1.Duration{Duration: 5 * time.Second},
+		MinCount:      pointer.Int32(100),
+	}
+	g.Expect(c.validate(field.NewPath("spec"))).To(BeEmpty())
+	c.ScanInterval.Duration = 2 * time.Minute
+	g.Expect(c.validate(field.NewPath("spec"))).To(HaveLen(1))
+	c.ScanInterval = nil
+	c.FlushInterval.Duration = 100 * time.Millisecond
+	c.MinCount = pointer.Int32(0)
+	g.Expect(c.validate(field.NewPath("spec"))).To(HaveLen(2))
+}
+
+func TestValidateConfigFileNames(t *testing.T) {
+	g := NewGomegaWithT(t)
+	g.Expect(validateConfigFileNames(&PodSet{}, field.NewPath("spec"))).To(BeEmpty())
+	g.Expect(validateConfigFileNames(&PodSet{ConfigFileName: "mo.toml", EntrypointFileName: "run.sh"}, field.NewPath("spec"))).To(BeEmpty())
+	g.Expect(validateConfigFileNames(&PodSet{ConfigFileName: "conf/mo.toml"}, field.NewPath("spec"))).To(HaveLen(1))
+	g.Expect(validateConfigFileNames(&PodSet{ConfigFileName: "mo.toml", EntrypointFileName: "mo.toml"}, field.NewPath("spec"))).To(HaveLen(1))
+}
+
+func TestValidateStuckPodPolicy(t *testing.T) {
+	g := NewGomegaWithT(t)
+	g.Expect(validateStuckPodPolicy(nil, field.NewPath("spec"))).To(BeEmpty())
+	g.Expect(validateStuckPodPolicy(&StuckPodPolicy{}, field.NewPath("spec"))).To(BeEmpty())
+	g.Expect(validateStuckPodPolicy(&StuckPodPolicy{Timeout: &metav1.Duration{Duration: 10 * time.Minute}}, field.NewPath("spec"))).To(BeEmpty())
+	g.Expect(validateStuckPodPolicy(&StuckPodPolicy{Timeout: &metav1.Duration{Duration: 10 * time.Second}}, field.NewPath("spec"))).To(HaveLen(1))
+}
+
+func TestValidateServiceAccountName(t *testing.T) {
+	g := NewGomegaWithT(t)
+	g.Expect(validateServiceAccountName("", field.NewPath("spec"))).To(BeEmpty())
+	g.Expect(validateServiceAccountName("mo-s3", field.NewPath("spec"))).To(BeEmpty())
+	g.Expect(validateServiceAccountName("MO_S3", field.NewPath("spec"))).ToNot(BeEmpty())
+}
+
+func TestValidateServiceAccount(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    MatrixOneClusterSpec
+		wantErr bool
+	}{{
+		name: "unset",
+	}, {
+		name: "irsa",
+		spec: MatrixOneClusterSpec{ServiceAccount: &ManagedServiceAccount{AWSRoleARN: "arn:aws-cn:iam::123456789012:role/path/mo"}},
+	}, {
+		name: "workload identity",
+		spec: MatrixOneClusterSpec{ServiceAccount: &ManagedServiceAccount{GCPServiceAccount: "matrixone@my-project.iam.gserviceaccount.com"}},
+	}, {
+		name:    "no identity",
+		spec:    MatrixOneClusterSpec{ServiceAccount: &ManagedServiceAccount{}},
+		wantErr: true,
+	}, {
+		name:    "invalid role arn",
+		spec:    MatrixOneClusterSpec{ServiceAccount: &ManagedServiceAccount{AWSRoleARN: "arn:aws:iam::1234:user/mo"}},
+		wantErr: true,
+	}, {
+		name:    "invalid gcp service account",
+		spec:    MatrixOneClusterSpec{ServiceAccount: &ManagedServiceAccount{GCPServiceAccount: "matrixone@gmail.com"}},
+		wantErr: true,
+	}, {
+		name: "conflict with serviceAccountName",
+		spec: MatrixOneClusterSpec{
+			ServiceAccountName: "mo",
+			ServiceAccount:     &ManagedServiceAccount{AWSRoleARN: "arn:aws:iam::123456789012:role/mo"},
+		},
+		wantErr: true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			mo := &MatrixOneCluster{Spec: tt.spec}
+			errs := mo.validateServiceAccount()
+			g.Expect(len(errs) > 0).To(Equal(tt.wantErr), "errs: %v", errs)
+		})
+	}
+}
+
+func TestValidateNameOverrideConflict(t *testing.T) {
+	tests := []struct {
+		name    string
+		tp      string
+		ap      *CNSetBasic
+		wantErr bool
+	}{{
+		name: "unset",
+		ap:   &CNSetBasic{},
+	}, {
+		name: "distinct",
+		tp:   "tp",
+		ap:   &CNSetBasic{PodSet: PodSet{NameOverride: "ap"}},
+	}, {
+		name:    "same override",
+		tp:      "cn",
+		ap:      &CNSetBasic{PodSet: PodSet{NameOverride: "cn"}},
+		wantErr: true,
+	}, {
+		name:    "override collides with the default name",
+		tp:      "mo-ap-cn",
+		ap:      &CNSetBasic{},
+		wantErr: true,
+	}, {
+		name: "no ap",
+		tp:   "mo-ap-cn",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			mo := &MatrixOneCluster{ObjectMeta: metav1.ObjectMeta{Name: "mo"}, Spec: MatrixOneClusterSpec{AP: tt.ap}}
+			mo.Spec.TP.NameOverride = tt.tp
+			errs := mo.validateNameOverrideConflict()
+			g.Expect(len(errs) > 0).To(Equal(tt.wantErr), "errs: %v", errs)
+		})
+	}
+}
+
+func TestValidateConfig(t *testing.T) {
+	g := NewGomegaWithT(t)
+	path := field.NewPath("spec").Child("config")
+	c := NewTomlConfig(map[string]interface{}{
+		"log": map[string]interface{}{
+			"levle": "debug",
+		},
+	})
+	errs := validateConfig(c, "matrixorigin/matrixone:1.1.0", metav1.ObjectMeta{}, path)
+	g.Expect(errs).To(HaveLen(1))
+	g.Expect(errs[0].Field).To(Equal("spec.config.log.levle"))
+	g.Expect(errs[0].Type).To(Equal(field.ErrorTypeNotSupported))
+
+	c = NewTomlConfig(map[string]interface{}{"log": "debug"})
+	errs = validateConfig(c, "matrixorigin/matrixone:1.1.0", metav1.ObjectMeta{}, path)
+	g.Expect(errs).To(HaveLen(1))
+	g.Expect(errs[0].Field).To(Equal("spec.config.log"))
+
+	// the schema is chosen by the MO version of the image
+	c = NewTomlConfig(map[string]interface{}{"proxy": map[string]interface{}{}})
+	g.Expect(validateConfig(c, "matrixorigin/matrixone:1.0.0", metav1.ObjectMeta{}, path)).To(HaveLen(1))
+	g.Expect(validateConfig(c, "matrixorigin/matrixone:1.1.2", metav1.ObjectMeta{}, path)).To(BeEmpty())
+	g.Expect(validateConfig(c, "matrixorigin/matrixone:nightly-abcdef", metav1.ObjectMeta{}, path)).To(BeEmpty())
+
+	skip := metav1.ObjectMeta{Annotations: map[string]string{SkipConfigValidationAnnotation: "true"}}
+	g.Expect(validateConfig(c, "matrixorigin/matrixone:1.0.0", skip, path)).To(BeEmpty())
+}
+
+func TestValidatePodSet(t *testing.T) {
+	g := NewGomegaWithT(t)
+	p := &PodSet{
+		DataDir:                  "data/sub",
+		ContainerSecurityContext: &ContainerSecurityContext{ReadOnlyRootFilesystem: true},
+	}
+	var fields []string
+	for _, err := range p.validate(nil, nil, field.NewPath("spec").Child("dn")) {
+		fields = append(fields, err.Field)
+	}
+	g.Expect(fields).To(ConsistOf("spec.dn.dataDir", "spec.dn.containerSecurityContext.readOnlyRootFilesystem"))
+
+	// the tmp volume mounted through the overlay makes the root filesystem read-only compatible
+	o := &Overlay{MainContainerOverlay: MainContainerOverlay{VolumeMounts: []corev1.VolumeMount{{Name: "tmp", MountPath: "/tmp"}}}}
+	p.DataDir = ""
+	g.Expect(p.validate(o, nil, field.NewPath("spec"))).To(BeEmpty())
+}
@@ -68,11 +68,19 @@ type WebUIDeps struct {
 type WebUIStatus struct {
 	ConditionalStatus `json:",inline"`
 	FailoverStatus    `json:",inline"`
+
+	// ResolvedImage is the image of the WebUI Deployment, with the overlay applied
+	ResolvedImage string `json:"resolvedImage,omitempty"`
+
+	// PodSummary counts the pods of this set by their phases
+	// +optional
+	PodSummary *PodSummary `json:"podSummary,omitempty"`
 }
 
 // +kubebuilder:object:root=true
 // +kubebuilder:printcolumn:name="Image",type="string",JSONPath=".spec.image"
 // +kubebuilder:printcolumn:name="Replicas",type="integer",JSONPath=".spec.replicas"
+// +kubebuilder:printcolumn:name="Running",type="integer",JSONPath=".status.podSummary.running"
 // +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
 
 // WebUI  is a resource that represents a set of MO's webui instances
@@ -43,14 +43,22 @@ var _ webhook.Validator = &WebUI{}
 func (r *WebUI) ValidateCreate() error {
 	var errs field.ErrorList
 	errs = append(errs, validateMainContainer(&r.Spec.MainContainer, field.NewPath("spec"))...)
+	errs = append(errs, validateOverlay(r.Spec.Overlay, field.NewPath("spec").Child("overlay"))...)
+	errs = append(errs, validateNameOverride(r.Spec.NameOverride, field.NewPath("spec").Child("nameOverride"))...)
+	errs = append(errs, validateSysctls(r.Spec.Sysctls, r.Spec.AllowUnsafeSysctls, field.NewPath("spec").Child("sysctls"))...)
+	errs = append(errs, validateTopologySpreadPolicy(r.Spec.TopologyEvenSpread, r.Spec.TopologySpreadPolicy, field.NewPath("spec"))...)
+	errs = append(errs, validateImagePullDeadline(&r.Spec.PodSet, field.NewPath("spec"))...)
+	errs = append(errs, validateServiceAccountName(r.Spec.ServiceAccountName, field.NewPath("spec").Child("serviceAccountName"))...)
 	return invalidOrNil(errs, r)
 }
 
-func (r *WebUI) ValidateUpdate(old runtime.Object) error {
+func (r *WebUI) ValidateUpdate(o runtime.Object) error {
 	if err := r.ValidateCreate(); err != nil {
 		return err
 	}
-	return nil
+	old := o.(*WebUI)
+	errs := validateNameOverrideUpdate(r.Spec.NameOverride, old.Spec.NameOverride, field.NewPath("spec").Child("nameOverride"))
+	return invalidOrNil(errs, r)
 }
 
 func (r *WebUI) ValidateDelete() error {
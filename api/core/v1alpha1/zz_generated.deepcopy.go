@@ -21,10 +21,52 @@ package v1alpha1
 
 import (
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BootstrapSQL) DeepCopyInto(out *BootstrapSQL) {
+	*out = *in
+	if in.ConfigMapKeyRef != nil {
+		in, out := &in.ConfigMapKeyRef, &out.ConfigMapKeyRef
+		*out = new(corev1.ConfigMapKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SecretKeyRef != nil {
+		in, out := &in.SecretKeyRef, &out.SecretKeyRef
+		*out = new(corev1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BootstrapSQL.
+func (in *BootstrapSQL) DeepCopy() *BootstrapSQL {
+	if in == nil {
+		return nil
+	}
+	out := new(BootstrapSQL)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CNIsolation) DeepCopyInto(out *CNIsolation) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CNIsolation.
+func (in *CNIsolation) DeepCopy() *CNIsolation {
+	if in == nil {
+		return nil
+	}
+	out := new(CNIsolation)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CNSet) DeepCopyInto(out *CNSet) {
 	*out = *in
@@ -67,6 +109,26 @@ func (in *CNSetBasic) DeepCopyInto(out *CNSetBasic) {
 		*out = new(Volume)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.SpillVolume != nil {
+		in, out := &in.SpillVolume, &out.SpillVolume
+		*out = new(SpillVolume)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Frontend != nil {
+		in, out := &in.Frontend, &out.Frontend
+		*out = new(FrontendConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ScaleStrategy != nil {
+		in, out := &in.ScaleStrategy, &out.ScaleStrategy
+		*out = new(ScaleStrategy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.NetworkAttachment != nil {
+		in, out := &in.NetworkAttachment, &out.NetworkAttachment
+		*out = new(NetworkAttachment)
+		**out = **in
+	}
 	in.SharedStorageCache.DeepCopyInto(&out.SharedStorageCache)
 }
 
@@ -133,6 +195,22 @@ func (in *CNSetList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CNSetRemovalStatus) DeepCopyInto(out *CNSetRemovalStatus) {
+	*out = *in
+	in.DrainStartTime.DeepCopyInto(&out.DrainStartTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CNSetRemovalStatus.
+func (in *CNSetRemovalStatus) DeepCopy() *CNSetRemovalStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(CNSetRemovalStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CNSetSpec) DeepCopyInto(out *CNSetSpec) {
 	*out = *in
@@ -159,6 +237,11 @@ func (in *CNSetStatus) DeepCopyInto(out *CNSetStatus) {
 	*out = *in
 	in.ConditionalStatus.DeepCopyInto(&out.ConditionalStatus)
 	in.FailoverStatus.DeepCopyInto(&out.FailoverStatus)
+	if in.PodSummary != nil {
+		in, out := &in.PodSummary, &out.PodSummary
+		*out = new(PodSummary)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CNSetStatus.
@@ -171,6 +254,51 @@ func (in *CNSetStatus) DeepCopy() *CNSetStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Colocation) DeepCopyInto(out *Colocation) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Colocation.
+func (in *Colocation) DeepCopy() *Colocation {
+	if in == nil {
+		return nil
+	}
+	out := new(Colocation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CompactionConfig) DeepCopyInto(out *CompactionConfig) {
+	*out = *in
+	if in.FlushInterval != nil {
+		in, out := &in.FlushInterval, &out.FlushInterval
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.ScanInterval != nil {
+		in, out := &in.ScanInterval, &out.ScanInterval
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.MinCount != nil {
+		in, out := &in.MinCount, &out.MinCount
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CompactionConfig.
+func (in *CompactionConfig) DeepCopy() *CompactionConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(CompactionConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ConditionalStatus) DeepCopyInto(out *ConditionalStatus) {
 	*out = *in
@@ -193,6 +321,31 @@ func (in *ConditionalStatus) DeepCopy() *ConditionalStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ContainerSecurityContext) DeepCopyInto(out *ContainerSecurityContext) {
+	*out = *in
+	if in.RunAsUser != nil {
+		in, out := &in.RunAsUser, &out.RunAsUser
+		*out = new(int64)
+		**out = **in
+	}
+	if in.RunAsGroup != nil {
+		in, out := &in.RunAsGroup, &out.RunAsGroup
+		*out = new(int64)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ContainerSecurityContext.
+func (in *ContainerSecurityContext) DeepCopy() *ContainerSecurityContext {
+	if in == nil {
+		return nil
+	}
+	out := new(ContainerSecurityContext)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DNSet) DeepCopyInto(out *DNSet) {
 	*out = *in
@@ -230,7 +383,32 @@ func (in *DNSetBasic) DeepCopyInto(out *DNSetBasic) {
 		*out = new(Volume)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.DataVolume != nil {
+		in, out := &in.DataVolume, &out.DataVolume
+		*out = new(Volume)
+		(*in).DeepCopyInto(*out)
+	}
 	in.SharedStorageCache.DeepCopyInto(&out.SharedStorageCache)
+	if in.StartupProbe != nil {
+		in, out := &in.StartupProbe, &out.StartupProbe
+		*out = new(StartupProbe)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.LockService != nil {
+		in, out := &in.LockService, &out.LockService
+		*out = new(LockServiceConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Compaction != nil {
+		in, out := &in.Compaction, &out.Compaction
+		*out = new(CompactionConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.NetworkAttachment != nil {
+		in, out := &in.NetworkAttachment, &out.NetworkAttachment
+		*out = new(NetworkAttachment)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DNSetBasic.
@@ -317,6 +495,11 @@ func (in *DNSetStatus) DeepCopyInto(out *DNSetStatus) {
 	*out = *in
 	in.ConditionalStatus.DeepCopyInto(&out.ConditionalStatus)
 	in.FailoverStatus.DeepCopyInto(&out.FailoverStatus)
+	if in.PodSummary != nil {
+		in, out := &in.PodSummary, &out.PodSummary
+		*out = new(PodSummary)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DNSetStatus.
@@ -329,6 +512,60 @@ func (in *DNSetStatus) DeepCopy() *DNSetStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DiscoveryService) DeepCopyInto(out *DiscoveryService) {
+	*out = *in
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DiscoveryService.
+func (in *DiscoveryService) DeepCopy() *DiscoveryService {
+	if in == nil {
+		return nil
+	}
+	out := new(DiscoveryService)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EphemeralStorage) DeepCopyInto(out *EphemeralStorage) {
+	*out = *in
+	if in.Request != nil {
+		in, out := &in.Request, &out.Request
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.Limit != nil {
+		in, out := &in.Limit, &out.Limit
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EphemeralStorage.
+func (in *EphemeralStorage) DeepCopy() *EphemeralStorage {
+	if in == nil {
+		return nil
+	}
+	out := new(EphemeralStorage)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ExternalLogSet) DeepCopyInto(out *ExternalLogSet) {
 	*out = *in
@@ -354,6 +591,13 @@ func (in *FailoverStatus) DeepCopyInto(out *FailoverStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.SuspectedStores != nil {
+		in, out := &in.SuspectedStores, &out.SuspectedStores
+		*out = make([]Store, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	if in.FailedStores != nil {
 		in, out := &in.FailedStores, &out.FailedStores
 		*out = make([]Store, len(*in))
@@ -388,6 +632,51 @@ func (in *FileSystemProvider) DeepCopy() *FileSystemProvider {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FrontendConfig) DeepCopyInto(out *FrontendConfig) {
+	*out = *in
+	if in.MaxMessageSize != nil {
+		in, out := &in.MaxMessageSize, &out.MaxMessageSize
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FrontendConfig.
+func (in *FrontendConfig) DeepCopy() *FrontendConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(FrontendConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HeadlessServicePolicy) DeepCopyInto(out *HeadlessServicePolicy) {
+	*out = *in
+	if in.ClusterIP != nil {
+		in, out := &in.ClusterIP, &out.ClusterIP
+		*out = new(string)
+		**out = **in
+	}
+	if in.IPFamilyPolicy != nil {
+		in, out := &in.IPFamilyPolicy, &out.IPFamilyPolicy
+		*out = new(corev1.IPFamilyPolicy)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HeadlessServicePolicy.
+func (in *HeadlessServicePolicy) DeepCopy() *HeadlessServicePolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(HeadlessServicePolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *InitialConfig) DeepCopyInto(out *InitialConfig) {
 	*out = *in
@@ -418,6 +707,36 @@ func (in *InitialConfig) DeepCopy() *InitialConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LockServiceConfig) DeepCopyInto(out *LockServiceConfig) {
+	*out = *in
+	if in.KeepBindInterval != nil {
+		in, out := &in.KeepBindInterval, &out.KeepBindInterval
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.KeepRemoteLockInterval != nil {
+		in, out := &in.KeepRemoteLockInterval, &out.KeepRemoteLockInterval
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.RemoteLockTimeout != nil {
+		in, out := &in.RemoteLockTimeout, &out.RemoteLockTimeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LockServiceConfig.
+func (in *LockServiceConfig) DeepCopy() *LockServiceConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(LockServiceConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *LogSet) DeepCopyInto(out *LogSet) {
 	*out = *in
@@ -457,6 +776,11 @@ func (in *LogSetBasic) DeepCopyInto(out *LogSetBasic) {
 		*out = new(v1.Duration)
 		**out = **in
 	}
+	if in.StoreFailureDetectionDelay != nil {
+		in, out := &in.StoreFailureDetectionDelay, &out.StoreFailureDetectionDelay
+		*out = new(v1.Duration)
+		**out = **in
+	}
 	if in.FailedPodStrategy != nil {
 		in, out := &in.FailedPodStrategy, &out.FailedPodStrategy
 		*out = new(FailedPodStrategy)
@@ -467,6 +791,21 @@ func (in *LogSetBasic) DeepCopyInto(out *LogSetBasic) {
 		*out = new(PVCRetentionPolicy)
 		**out = **in
 	}
+	if in.DiscoveryService != nil {
+		in, out := &in.DiscoveryService, &out.DiscoveryService
+		*out = new(DiscoveryService)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RollingUpdatePolicy != nil {
+		in, out := &in.RollingUpdatePolicy, &out.RollingUpdatePolicy
+		*out = new(LogSetRollingUpdatePolicy)
+		**out = **in
+	}
+	if in.StatusPollInterval != nil {
+		in, out := &in.StatusPollInterval, &out.StatusPollInterval
+		*out = new(v1.Duration)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LogSetBasic.
@@ -551,6 +890,21 @@ func (in *LogSetRef) DeepCopy() *LogSetRef {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LogSetRollingUpdatePolicy) DeepCopyInto(out *LogSetRollingUpdatePolicy) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LogSetRollingUpdatePolicy.
+func (in *LogSetRollingUpdatePolicy) DeepCopy() *LogSetRollingUpdatePolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(LogSetRollingUpdatePolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *LogSetSpec) DeepCopyInto(out *LogSetSpec) {
 	*out = *in
@@ -577,6 +931,11 @@ func (in *LogSetStatus) DeepCopyInto(out *LogSetStatus) {
 	*out = *in
 	in.ConditionalStatus.DeepCopyInto(&out.ConditionalStatus)
 	in.FailoverStatus.DeepCopyInto(&out.FailoverStatus)
+	if in.PodSummary != nil {
+		in, out := &in.PodSummary, &out.PodSummary
+		*out = new(PodSummary)
+		**out = **in
+	}
 	if in.Discovery != nil {
 		in, out := &in.Discovery, &out.Discovery
 		*out = new(LogSetDiscovery)
@@ -618,6 +977,11 @@ func (in *MainContainerOverlay) DeepCopyInto(out *MainContainerOverlay) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.CommandWrapper != nil {
+		in, out := &in.CommandWrapper, &out.CommandWrapper
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	if in.Args != nil {
 		in, out := &in.Args, &out.Args
 		*out = make([]string, len(*in))
@@ -669,6 +1033,11 @@ func (in *MainContainerOverlay) DeepCopyInto(out *MainContainerOverlay) {
 		*out = new(corev1.Lifecycle)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.MainContainerSecurityContext != nil {
+		in, out := &in.MainContainerSecurityContext, &out.MainContainerSecurityContext
+		*out = new(corev1.SecurityContext)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MainContainerOverlay.
@@ -681,6 +1050,21 @@ func (in *MainContainerOverlay) DeepCopy() *MainContainerOverlay {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ManagedServiceAccount) DeepCopyInto(out *ManagedServiceAccount) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManagedServiceAccount.
+func (in *ManagedServiceAccount) DeepCopy() *ManagedServiceAccount {
+	if in == nil {
+		return nil
+	}
+	out := new(ManagedServiceAccount)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MatrixOneCluster) DeepCopyInto(out *MatrixOneCluster) {
 	*out = *in
@@ -773,6 +1157,57 @@ func (in *MatrixOneClusterSpec) DeepCopyInto(out *MatrixOneClusterSpec) {
 		*out = new(corev1.PullPolicy)
 		**out = **in
 	}
+	if in.PodLabels != nil {
+		in, out := &in.PodLabels, &out.PodLabels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.PodAnnotations != nil {
+		in, out := &in.PodAnnotations, &out.PodAnnotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.CommonLabels != nil {
+		in, out := &in.CommonLabels, &out.CommonLabels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Colocation != nil {
+		in, out := &in.Colocation, &out.Colocation
+		*out = new(Colocation)
+		**out = **in
+	}
+	if in.CNIsolation != nil {
+		in, out := &in.CNIsolation, &out.CNIsolation
+		*out = new(CNIsolation)
+		**out = **in
+	}
+	if in.NetworkPolicy != nil {
+		in, out := &in.NetworkPolicy, &out.NetworkPolicy
+		*out = new(NetworkPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ServiceAccount != nil {
+		in, out := &in.ServiceAccount, &out.ServiceAccount
+		*out = new(ManagedServiceAccount)
+		**out = **in
+	}
+	if in.CNRemovalGracePeriod != nil {
+		in, out := &in.CNRemovalGracePeriod, &out.CNRemovalGracePeriod
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.BootstrapSQL != nil {
+		in, out := &in.BootstrapSQL, &out.BootstrapSQL
+		*out = new(BootstrapSQL)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MatrixOneClusterSpec.
@@ -794,6 +1229,11 @@ func (in *MatrixOneClusterStatus) DeepCopyInto(out *MatrixOneClusterStatus) {
 		*out = new(corev1.LocalObjectReference)
 		**out = **in
 	}
+	if in.PodSummary != nil {
+		in, out := &in.PodSummary, &out.PodSummary
+		*out = new(PodSummary)
+		**out = **in
+	}
 	if in.TP != nil {
 		in, out := &in.TP, &out.TP
 		*out = new(CNSetStatus)
@@ -809,6 +1249,11 @@ func (in *MatrixOneClusterStatus) DeepCopyInto(out *MatrixOneClusterStatus) {
 		*out = new(DNSetStatus)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.CNSetRemoval != nil {
+		in, out := &in.CNSetRemoval, &out.CNSetRemoval
+		*out = new(CNSetRemovalStatus)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.Webui != nil {
 		in, out := &in.Webui, &out.Webui
 		*out = new(WebUIStatus)
@@ -831,6 +1276,48 @@ func (in *MatrixOneClusterStatus) DeepCopy() *MatrixOneClusterStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NetworkAttachment) DeepCopyInto(out *NetworkAttachment) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkAttachment.
+func (in *NetworkAttachment) DeepCopy() *NetworkAttachment {
+	if in == nil {
+		return nil
+	}
+	out := new(NetworkAttachment)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NetworkPolicy) DeepCopyInto(out *NetworkPolicy) {
+	*out = *in
+	if in.MetricsFrom != nil {
+		in, out := &in.MetricsFrom, &out.MetricsFrom
+		*out = make([]networkingv1.NetworkPolicyPeer, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.MetricsPort != nil {
+		in, out := &in.MetricsPort, &out.MetricsPort
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkPolicy.
+func (in *NetworkPolicy) DeepCopy() *NetworkPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(NetworkPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Overlay) DeepCopyInto(out *Overlay) {
 	*out = *in
@@ -949,6 +1436,11 @@ func (in *PodSet) DeepCopyInto(out *PodSet) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.TopologySpreadPolicy != nil {
+		in, out := &in.TopologySpreadPolicy, &out.TopologySpreadPolicy
+		*out = new(TopologySpreadPolicy)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.NodeSelector != nil {
 		in, out := &in.NodeSelector, &out.NodeSelector
 		*out = make(map[string]string, len(*in))
@@ -956,10 +1448,65 @@ func (in *PodSet) DeepCopyInto(out *PodSet) {
 			(*out)[key] = val
 		}
 	}
+	if in.VolumeMetadata != nil {
+		in, out := &in.VolumeMetadata, &out.VolumeMetadata
+		*out = new(VolumeMetadata)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ContainerSecurityContext != nil {
+		in, out := &in.ContainerSecurityContext, &out.ContainerSecurityContext
+		*out = new(ContainerSecurityContext)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TmpVolume != nil {
+		in, out := &in.TmpVolume, &out.TmpVolume
+		*out = new(TmpVolume)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.EphemeralStorage != nil {
+		in, out := &in.EphemeralStorage, &out.EphemeralStorage
+		*out = new(EphemeralStorage)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.StuckPodPolicy != nil {
+		in, out := &in.StuckPodPolicy, &out.StuckPodPolicy
+		*out = new(StuckPodPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Sysctls != nil {
+		in, out := &in.Sysctls, &out.Sysctls
+		*out = make([]corev1.Sysctl, len(*in))
+		copy(*out, *in)
+	}
 	if in.Config != nil {
 		in, out := &in.Config, &out.Config
 		*out = (*in).DeepCopy()
 	}
+	if in.RevisionHistoryLimit != nil {
+		in, out := &in.RevisionHistoryLimit, &out.RevisionHistoryLimit
+		*out = new(int32)
+		**out = **in
+	}
+	if in.ImagePullDeadline != nil {
+		in, out := &in.ImagePullDeadline, &out.ImagePullDeadline
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.ExtraServiceArgs != nil {
+		in, out := &in.ExtraServiceArgs, &out.ExtraServiceArgs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.PublishNotReadyAddresses != nil {
+		in, out := &in.PublishNotReadyAddresses, &out.PublishNotReadyAddresses
+		*out = new(bool)
+		**out = **in
+	}
+	if in.HeadlessService != nil {
+		in, out := &in.HeadlessService, &out.HeadlessService
+		*out = new(HeadlessServicePolicy)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PodSet.
@@ -972,6 +1519,21 @@ func (in *PodSet) DeepCopy() *PodSet {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PodSummary) DeepCopyInto(out *PodSummary) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PodSummary.
+func (in *PodSummary) DeepCopy() *PodSummary {
+	if in == nil {
+		return nil
+	}
+	out := new(PodSummary)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RollingUpdateStrategy) DeepCopyInto(out *RollingUpdateStrategy) {
 	*out = *in
@@ -1010,6 +1572,11 @@ func (in *S3Provider) DeepCopyInto(out *S3Provider) {
 		*out = new(corev1.LocalObjectReference)
 		**out = **in
 	}
+	if in.CABundleSecretRef != nil {
+		in, out := &in.CABundleSecretRef, &out.CABundleSecretRef
+		*out = new(corev1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new S3Provider.
@@ -1022,6 +1589,26 @@ func (in *S3Provider) DeepCopy() *S3Provider {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScaleStrategy) DeepCopyInto(out *ScaleStrategy) {
+	*out = *in
+	if in.MaxUnavailable != nil {
+		in, out := &in.MaxUnavailable, &out.MaxUnavailable
+		*out = new(intstr.IntOrString)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScaleStrategy.
+func (in *ScaleStrategy) DeepCopy() *ScaleStrategy {
+	if in == nil {
+		return nil
+	}
+	out := new(ScaleStrategy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SharedStorageCache) DeepCopyInto(out *SharedStorageCache) {
 	*out = *in
@@ -1072,6 +1659,56 @@ func (in *SharedStorageProvider) DeepCopy() *SharedStorageProvider {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SpillVolume) DeepCopyInto(out *SpillVolume) {
+	*out = *in
+	if in.EmptyDir != nil {
+		in, out := &in.EmptyDir, &out.EmptyDir
+		*out = new(corev1.EmptyDirVolumeSource)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.HostPath != nil {
+		in, out := &in.HostPath, &out.HostPath
+		*out = new(corev1.HostPathVolumeSource)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SpillVolume.
+func (in *SpillVolume) DeepCopy() *SpillVolume {
+	if in == nil {
+		return nil
+	}
+	out := new(SpillVolume)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StartupProbe) DeepCopyInto(out *StartupProbe) {
+	*out = *in
+	if in.FailureThreshold != nil {
+		in, out := &in.FailureThreshold, &out.FailureThreshold
+		*out = new(int32)
+		**out = **in
+	}
+	if in.PeriodSeconds != nil {
+		in, out := &in.PeriodSeconds, &out.PeriodSeconds
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StartupProbe.
+func (in *StartupProbe) DeepCopy() *StartupProbe {
+	if in == nil {
+		return nil
+	}
+	out := new(StartupProbe)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Store) DeepCopyInto(out *Store) {
 	*out = *in
@@ -1088,6 +1725,66 @@ func (in *Store) DeepCopy() *Store {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StuckPodPolicy) DeepCopyInto(out *StuckPodPolicy) {
+	*out = *in
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StuckPodPolicy.
+func (in *StuckPodPolicy) DeepCopy() *StuckPodPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(StuckPodPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TmpVolume) DeepCopyInto(out *TmpVolume) {
+	*out = *in
+	if in.SizeLimit != nil {
+		in, out := &in.SizeLimit, &out.SizeLimit
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TmpVolume.
+func (in *TmpVolume) DeepCopy() *TmpVolume {
+	if in == nil {
+		return nil
+	}
+	out := new(TmpVolume)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TopologySpreadPolicy) DeepCopyInto(out *TopologySpreadPolicy) {
+	*out = *in
+	if in.MinDomains != nil {
+		in, out := &in.MinDomains, &out.MinDomains
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TopologySpreadPolicy.
+func (in *TopologySpreadPolicy) DeepCopy() *TopologySpreadPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(TopologySpreadPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Volume) DeepCopyInto(out *Volume) {
 	*out = *in
@@ -1114,6 +1811,35 @@ func (in *Volume) DeepCopy() *Volume {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VolumeMetadata) DeepCopyInto(out *VolumeMetadata) {
+	*out = *in
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VolumeMetadata.
+func (in *VolumeMetadata) DeepCopy() *VolumeMetadata {
+	if in == nil {
+		return nil
+	}
+	out := new(VolumeMetadata)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *WebUI) DeepCopyInto(out *WebUI) {
 	*out = *in
@@ -1246,6 +1972,11 @@ func (in *WebUIStatus) DeepCopyInto(out *WebUIStatus) {
 	*out = *in
 	in.ConditionalStatus.DeepCopyInto(&out.ConditionalStatus)
 	in.FailoverStatus.DeepCopyInto(&out.FailoverStatus)
+	if in.PodSummary != nil {
+		in, out := &in.PodSummary, &out.PodSummary
+		*out = new(PodSummary)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WebUIStatus.
@@ -73,3 +73,18 @@ caBundle: {{ $ca.Cert | b64enc }}
 tls.crt: {{ $cert.Cert | b64enc }}
 tls.key: {{ $cert.Key | b64enc }}
 {{- end -}}
+
+{{/*
+Scope the webhooks to the watched namespaces so that the objects in other namespaces are
+left to the operators watching them
+*/}}
+{{- define "matrixone-operator.webhookNamespaceSelector" -}}
+namespaceSelector:
+  matchExpressions:
+  - key: kubernetes.io/metadata.name
+    operator: In
+    values:
+    {{- range (split "," .Values.env.WATCH_NAMESPACE) }}
+    - {{ trim . | quote }}
+    {{- end }}
+{{- end }}
@@ -27,8 +27,10 @@ import (
 	"github.com/matrixorigin/matrixone-operator/pkg/controllers/mocluster"
 	hookctrl "github.com/matrixorigin/matrixone-operator/pkg/controllers/webhook"
 	"github.com/matrixorigin/matrixone-operator/pkg/controllers/webui"
+	"github.com/matrixorigin/matrixone-operator/pkg/utils"
 	kruisev1 "github.com/openkruise/kruise-api/apps/v1beta1"
 	"go.uber.org/zap/zapcore"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	controllermetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
@@ -36,6 +38,7 @@ import (
 	// to ensure that exec-entrypoint and run can make use of them.
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 
+	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
@@ -66,6 +69,8 @@ func main() {
 	var webhookCertDir string
 	var caFile string
 	var failover bool
+	webhookPolicy := v1alpha1.DefaultWebhookPolicy()
+	diskCacheHeadroomFloor := resource.QuantityValue{Quantity: webhookPolicy.DiskCacheHeadroomFloor}
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
@@ -74,6 +79,17 @@ func main() {
 	flag.StringVar(&webhookCertDir, "webhook-certificate-directory", "/tmp/k8s-webhook-server/serving-certs", "the directory that provide certificates for the webhook server")
 	flag.StringVar(&caFile, "ca-file", "caBundle", "the filename of caBundle")
 	flag.BoolVar(&failover, "failover", true, "enable failover feature-gate")
+	flag.BoolVar(&webhookPolicy.RequireCNCacheVolume, "require-cn-cache-volume", false, "reject CN specs without a cache volume")
+	flag.Int64Var(&webhookPolicy.DiskCacheHeadroomPercent, "disk-cache-headroom-percent", webhookPolicy.DiskCacheHeadroomPercent,
+		"the percentage of the cache volume that is not used by the disk cache when defaulting the disk cache size")
+	flag.Var(&diskCacheHeadroomFloor, "disk-cache-headroom-floor",
+		"the minimum size of the cache volume that is not used by the disk cache when defaulting the disk cache size")
+	var minCacheSizeFile string
+	flag.StringVar(&minCacheSizeFile, "min-cache-size-file", "",
+		"the YAML file that maps MO minor versions to the minimum sizes of the cache volumes and disk caches (e.g. \"1.0\": 2Gi), which is mounted from the operator configmap, 1Gi applies to all versions if not set")
+	var upgradeCompatibility string
+	flag.StringVar(&upgradeCompatibility, "upgrade-compatibility", "",
+		"the comma separated upgrade paths of MO minor versions (e.g. 0.6->0.7,0.7->0.8), cluster version changes not listed are rejected, empty disables the validation")
 	opts := &zap.Options{
 		Development: true,
 		TimeEncoder: zapcore.RFC3339TimeEncoder,
@@ -83,7 +99,7 @@ func main() {
 
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(opts)))
 
-	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
+	mgrOpts := ctrl.Options{
 		Scheme:                 scheme,
 		Host:                   "0.0.0.0",
 		Port:                   9443,
@@ -94,7 +110,18 @@ func main() {
 		WebhookServer: &webhook.Server{
 			CertDir: webhookCertDir,
 		},
-	})
+	}
+	// scope the cache, and thus all the reconciles, to the watched namespaces
+	switch watchNamespaces := utils.WatchNamespaces(os.Getenv("WATCH_NAMESPACE")); len(watchNamespaces) {
+	case 0:
+	case 1:
+		mgrOpts.Namespace = watchNamespaces[0]
+		setupLog.Info("watching namespace", "namespace", watchNamespaces[0])
+	default:
+		mgrOpts.NewCache = cache.MultiNamespacedCacheBuilder(watchNamespaces)
+		setupLog.Info("watching namespaces", "namespaces", watchNamespaces)
+	}
+	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), mgrOpts)
 	exitIf(err, "failed to start manager")
 
 	collector := metrics.NewMetricsCollector("matrixone", mgr.GetClient())
@@ -109,7 +136,19 @@ func main() {
 	controllermetrics.Registry.MustRegister(collector)
 
 	if os.Getenv("ENABLE_WEBHOOKS") != "false" {
-		err := v1alpha1.RegisterWebhooks(mgr)
+		if webhookPolicy.DiskCacheHeadroomPercent < 0 || webhookPolicy.DiskCacheHeadroomPercent >= 100 {
+			exitIf(fmt.Errorf("invalid disk cache headroom percent %d", webhookPolicy.DiskCacheHeadroomPercent), "invalid webhook policy")
+		}
+		webhookPolicy.DiskCacheHeadroomFloor = diskCacheHeadroomFloor.Quantity
+		if minCacheSizeFile != "" {
+			data, err := os.ReadFile(minCacheSizeFile)
+			exitIf(err, "unable to read the min cache size file")
+			webhookPolicy.MinCacheSizes, err = v1alpha1.ParseMinCacheSizes(data)
+			exitIf(err, "invalid webhook policy")
+		}
+		webhookPolicy.UpgradeCompatibility, err = v1alpha1.ParseUpgradeCompatibility(upgradeCompatibility)
+		exitIf(err, "invalid webhook policy")
+		err := v1alpha1.RegisterWebhooks(mgr, webhookPolicy)
 		exitIf(err, "unable to set up webhook")
 
 		caBundle, err := os.ReadFile(fmt.Sprintf("%s/%s", webhookCertDir, caFile))
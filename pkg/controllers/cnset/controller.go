@@ -21,6 +21,7 @@ import (
 	"github.com/matrixorigin/controller-runtime/pkg/util"
 	"github.com/matrixorigin/matrixone-operator/api/core/v1alpha1"
 	"github.com/matrixorigin/matrixone-operator/pkg/controllers/common"
+	"github.com/matrixorigin/matrixone-operator/pkg/metrics"
 	kruise "github.com/openkruise/kruise-api/apps/v1beta1"
 	"github.com/pkg/errors"
 	"github.com/samber/lo"
@@ -57,6 +58,10 @@ func (c *Actor) with(sts *kruise.StatefulSet, svc *corev1.Service) *WithResource
 func (c *Actor) Observe(ctx *recon.Context[*v1alpha1.CNSet]) (recon.Action[*v1alpha1.CNSet], error) {
 	cn := ctx.Obj
 
+	if err := common.CheckExternalLogSet(&cn.Deps.LogSetRef, &cn.Status.ConditionalStatus); err != nil {
+		return nil, err
+	}
+
 	svc := &corev1.Service{}
 	err, foundSvc := util.IsFound(ctx.Get(client.ObjectKey{Namespace: cn.Namespace, Name: svcName(cn)}, svc))
 	if err != nil {
@@ -72,6 +77,9 @@ func (c *Actor) Observe(ctx *recon.Context[*v1alpha1.CNSet]) (recon.Action[*v1al
 	if !foundSts || !foundSvc {
 		return c.Create, nil
 	}
+	if err := common.SyncHeadlessService(ctx, buildHeadlessSvc(cn)); err != nil {
+		return nil, errors.Wrap(err, "sync cn headless service")
+	}
 
 	// update statefulset of cnset
 	origin := sts.DeepCopy()
@@ -95,8 +103,20 @@ func (c *Actor) Observe(ctx *recon.Context[*v1alpha1.CNSet]) (recon.Action[*v1al
 	if err != nil {
 		return nil, errors.Wrap(err, "list cnset pods")
 	}
+	if err := common.SyncDrainedPods(ctx, podList.Items); err != nil {
+		return nil, err
+	}
+	if err := common.SyncStoreUUIDLabels(ctx, podList.Items, common.StoreTypeCN); err != nil {
+		return nil, err
+	}
+	if err := common.ForceDeleteStuckPods(ctx, ctx.Obj.Spec.StuckPodPolicy, podList.Items); err != nil {
+		return nil, err
+	}
 
-	common.CollectStoreStatus(&cn.Status.FailoverStatus, podList.Items)
+	common.CollectStoreStatus(&cn.Status.FailoverStatus, podList.Items, 0)
+	cn.Status.PodSummary = common.CollectPodSummary(podList.Items)
+	common.CollectImagePullStatus(&cn.Status.ConditionalStatus, podList.Items, cn.Spec.GetImagePullDeadline())
+	cn.Status.ResolvedImage = common.ResolvedImage(&sts.Spec.Template)
 
 	if len(cn.Status.AvailableStores) >= int(cn.Spec.Replicas) {
 		cn.Status.SetCondition(metav1.Condition{
@@ -129,14 +149,23 @@ func (c *Actor) Observe(ctx *recon.Context[*v1alpha1.CNSet]) (recon.Action[*v1al
 }
 
 func (c *WithResources) Scale(ctx *recon.Context[*v1alpha1.CNSet]) error {
-	return ctx.Patch(c.sts, func() error {
+	from := *c.sts.Spec.Replicas
+	if err := ctx.Patch(c.sts, func() error {
 		syncReplicas(ctx.Obj, c.sts)
 		return nil
-	})
+	}); err != nil {
+		return err
+	}
+	common.EmitScaleEvent(ctx.Event, from, ctx.Obj.Spec.Replicas)
+	return nil
 }
 
 func (c *WithResources) Update(ctx *recon.Context[*v1alpha1.CNSet]) error {
-	return ctx.Update(c.sts)
+	if err := ctx.Update(c.sts); err != nil {
+		return err
+	}
+	common.EmitUpgradeEvent(ctx.Event, ctx.Obj.Status.ResolvedImage, common.ResolvedImage(&c.sts.Spec.Template))
+	return nil
 }
 
 func (c *WithResources) SvcUpdate(ctx *recon.Context[*v1alpha1.CNSet]) error {
@@ -158,6 +187,7 @@ func (c *WithResources) Repair(ctx *recon.Context[*v1alpha1.CNSet]) error {
 		return errors.Wrapf(err, "error parse ordinal from pod name %s", toRepair[0].PodName)
 	}
 	c.sts.Spec.ReserveOrdinals = util.Upsert(c.sts.Spec.ReserveOrdinals, ordinal)
+	common.EmitFailoverEvent(ctx.Event, toRepair[0].PodName)
 
 	return nil
 }
@@ -192,31 +222,14 @@ func (c *Actor) Finalize(ctx *recon.Context[*v1alpha1.CNSet]) (bool, error) {
 }
 
 func (c *Actor) Create(ctx *recon.Context[*v1alpha1.CNSet]) error {
-	cn := ctx.Obj
-
-	hSvc := buildHeadlessSvc(cn)
-	cnSet := buildCNSet(cn)
-	svc := buildSvc(cn)
-	syncReplicas(cn, cnSet)
-	syncPodMeta(cn, cnSet)
-	syncPodSpec(cn, cnSet, ctx.Dep.Deps.LogSet.Spec.SharedStorage)
-	syncPersistentVolumeClaim(cn, cnSet)
-
-	configMap, err := buildCNSetConfigMap(cn, ctx.Dep.Deps.LogSet)
+	objs, err := RenderManifests(ctx.Obj, ctx.Dep.Deps.LogSet)
+	common.SetConfigBuiltCondition(&ctx.Obj.Status.ConditionalStatus, err)
 	if err != nil {
 		return err
 	}
 
-	if err := common.SyncConfigMap(ctx, &cnSet.Spec.Template.Spec, configMap); err != nil {
-		return err
-	}
-
 	// create all resources
-	err = lo.Reduce[client.Object, error]([]client.Object{
-		hSvc,
-		svc,
-		cnSet,
-	}, func(errs error, o client.Object, _ int) error {
+	err = lo.Reduce[client.Object, error](objs, func(errs error, o client.Object, _ int) error {
 		err := ctx.CreateOwned(o)
 		return multierr.Append(errs, util.Ignore(apierrors.IsAlreadyExists, err))
 	}, nil)
@@ -227,8 +240,39 @@ func (c *Actor) Create(ctx *recon.Context[*v1alpha1.CNSet]) error {
 	return nil
 }
 
+// RenderManifests renders the underlying objects of the CNSet without touching the cluster
+func RenderManifests(cn *v1alpha1.CNSet, ls *v1alpha1.LogSet) ([]client.Object, error) {
+	hSvc := buildHeadlessSvc(cn)
+	cnSet := buildCNSet(cn)
+	svc := buildSvc(cn)
+	syncReplicas(cn, cnSet)
+	syncPodMeta(cn, cnSet)
+	syncPodSpec(cn, cnSet, ls.Spec.SharedStorage)
+	syncPersistentVolumeClaim(cn, cnSet)
+	common.SyncUpdateStrategy(&cn.Spec.PodSet, cnSet)
+	common.SyncRevisionHistoryLimit(&cn.Spec.PodSet, cnSet)
+
+	configMap, err := buildCNSetConfigMap(cn, ls)
+	if err != nil {
+		return nil, err
+	}
+	ep, err := common.RenderEntrypointConfigMap(&cn.Spec.PodSet, &cnSet.Spec.Template.Spec, configMap, common.EntrypointFileName(&cn.Spec.PodSet))
+	if err != nil {
+		return nil, err
+	}
+	cm, err := common.RenderConfigMap(&cnSet.Spec.Template.Spec, configMap, common.ConfigFileName(&cn.Spec.PodSet, common.ConfigFile))
+	if err != nil {
+		return nil, err
+	}
+	objs := []client.Object{cm, hSvc, svc, cnSet}
+	if ep != nil {
+		objs = append(objs, ep)
+	}
+	return objs, nil
+}
+
 func (c *Actor) Reconcile(mgr manager.Manager) error {
-	err := recon.Setup[*v1alpha1.CNSet](&v1alpha1.CNSet{}, "cnset", mgr, c,
+	err := recon.Setup[*v1alpha1.CNSet](&v1alpha1.CNSet{}, "cnset", mgr, metrics.Instrument[*v1alpha1.CNSet]("cnset", c),
 		recon.WithBuildFn(func(b *builder.Builder) {
 			b.Owns(&kruise.StatefulSet{}).
 				Owns(&corev1.Service{})
@@ -241,15 +285,21 @@ func (c *Actor) Reconcile(mgr manager.Manager) error {
 }
 func syncPods(ctx *recon.Context[*v1alpha1.CNSet], sts *kruise.StatefulSet) error {
 	cm, err := buildCNSetConfigMap(ctx.Obj, ctx.Dep.Deps.LogSet)
+	common.SetConfigBuiltCondition(&ctx.Obj.Status.ConditionalStatus, err)
 	if err != nil {
 		return err
 	}
 
 	syncPodMeta(ctx.Obj, sts)
+	common.SyncUpdateStrategy(&ctx.Obj.Spec.PodSet, sts)
+	common.SyncRevisionHistoryLimit(&ctx.Obj.Spec.PodSet, sts)
 
 	if ctx.Dep != nil {
 		syncPodSpec(ctx.Obj, sts, ctx.Dep.Deps.LogSet.Spec.SharedStorage)
 	}
 
-	return common.SyncConfigMap(ctx, &sts.Spec.Template.Spec, cm)
+	if err := common.SyncEntrypointConfigMap(ctx, &ctx.Obj.Spec.PodSet, &sts.Spec.Template.Spec, cm, common.EntrypointFileName(&ctx.Obj.Spec.PodSet)); err != nil {
+		return err
+	}
+	return common.SyncConfigMap(ctx, &sts.Spec.Template.Spec, cm, common.ConfigFileName(&ctx.Obj.Spec.PodSet, common.ConfigFile))
 }
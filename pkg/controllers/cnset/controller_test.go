@@ -20,6 +20,7 @@ import (
 	"github.com/golang/mock/gomock"
 	"github.com/matrixorigin/controller-runtime/pkg/fake"
 	recon "github.com/matrixorigin/controller-runtime/pkg/reconciler"
+	"github.com/matrixorigin/controller-runtime/pkg/util"
 	"github.com/matrixorigin/matrixone-operator/api/core/v1alpha1"
 	"github.com/matrixorigin/matrixone-operator/pkg/controllers/common"
 	"github.com/matrixorigin/matrixone-operator/pkg/utils"
@@ -263,6 +264,33 @@ func TestCNSetVolumeMount(t *testing.T) {
 			sp:  v1alpha1.SharedStorageProvider{},
 			sts: &kruisev1.StatefulSet{},
 		},
+		{
+			name: "test volume mount with spill volume",
+			cnset: &v1alpha1.CNSet{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "default",
+					Name:      "test",
+				},
+				Spec: v1alpha1.CNSetSpec{
+					CNSetBasic: v1alpha1.CNSetBasic{
+						PodSet: v1alpha1.PodSet{
+							MainContainer: v1alpha1.MainContainer{
+								Image: "test:latest",
+							},
+							Replicas: 3,
+						},
+						SpillVolume: &v1alpha1.SpillVolume{
+							EmptyDir: &corev1.EmptyDirVolumeSource{},
+						},
+					},
+				},
+			},
+			client: &fake.Client{
+				Client: fake.KubeClientBuilder().WithScheme(s).Build(),
+			},
+			sp:  v1alpha1.SharedStorageProvider{},
+			sts: &kruisev1.StatefulSet{},
+		},
 	}
 
 	for _, tt := range tests {
@@ -294,6 +322,12 @@ func TestCNSetVolumeMount(t *testing.T) {
 			}
 			syncPodSpec(tt.cnset, tt.sts, tt.sp)
 
+			if probe := tt.sts.Spec.Template.Spec.Containers[0].ReadinessProbe; probe == nil || probe.TCPSocket == nil || probe.TCPSocket.Port.IntValue() != CNSQLPort {
+				t.Error("readiness probe should check the SQL port")
+			} else if probe.TimeoutSeconds == 0 || probe.SuccessThreshold == 0 || probe.FailureThreshold == 0 {
+				t.Errorf("readiness probe should set the fields defaulted by the API server, got %v", probe)
+			}
+
 			if tt.cnset.Spec.CacheVolume == nil {
 				// if cacheVolume not set, volumeClaimTemplates should be 0
 				// dataVolumeMount should not be created.
@@ -305,10 +339,34 @@ func TestCNSetVolumeMount(t *testing.T) {
 					t.Error("should not have a persistent volume for cache when cacheVolume is not set")
 				}
 			}
+			if tt.cnset.Spec.SpillVolume != nil {
+				if !utils.CheckVolumeMount(spillVolume, tt.sts.Spec.Template.Spec.Containers[0].VolumeMounts) {
+					t.Error("spill volume mount should be created")
+				}
+				if util.FindFirst(tt.sts.Spec.Template.Spec.Volumes, util.WithVolumeName(spillVolume)) == nil {
+					t.Error("spill volume should be created")
+				}
+				if env := util.FindFirst(tt.sts.Spec.Template.Spec.Containers[0].Env, func(e corev1.EnvVar) bool { return e.Name == tmpDirEnvKey }); env == nil || env.Value != spillPath {
+					t.Errorf("%s should point to the spill volume, got %v", tmpDirEnvKey, env)
+				}
+			}
 		})
 	}
 }
 
+func TestSetFrontendConfig(t *testing.T) {
+	g := NewGomegaWithT(t)
+	cfg := v1alpha1.NewTomlConfig(map[string]interface{}{})
+	setFrontendConfig(cfg, nil)
+	g.Expect(cfg.Get("cn", "frontend")).To(BeNil())
+
+	size := resource.MustParse("16Mi")
+	setFrontendConfig(cfg, &v1alpha1.FrontendConfig{
+		MaxMessageSize: &size,
+	})
+	g.Expect(cfg.Get("cn", "frontend", "maxMessageSize").MustInt()).To(Equal(int64(16 << 20)))
+}
+
 func newScheme() *runtime.Scheme {
 	scheme := runtime.NewScheme()
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
@@ -24,7 +24,6 @@ import (
 	"github.com/matrixorigin/matrixone-operator/api/core/v1alpha1"
 	"github.com/matrixorigin/matrixone-operator/pkg/controllers/common"
 	"github.com/matrixorigin/matrixone-operator/pkg/controllers/logset"
-	"github.com/openkruise/kruise-api/apps/pub"
 	kruise "github.com/openkruise/kruise-api/apps/v1beta1"
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
@@ -42,13 +41,21 @@ if [ -z "${HOSTNAME_UUID+guard}" ]; then
 else
   UUID=$(echo ${ADDR} | sha256sum | od -x | head -1 | awk '{OFS="-"; print $2$3,$4,$5,$6,$7$8$9}')
 fi
+SERVICE_ADDR="${ADDR}"
+{{ if .NetworkInterface -}}
+# advertise the address of the attached network so that the data-plane traffic goes through it
+SERVICE_ADDR=$(ip -4 -o addr show dev {{ .NetworkInterface }} | awk '{split($4, a, "/"); print a[1]; exit}')
+if [ -z "${SERVICE_ADDR}" ]; then
+  echo "no IPv4 address found on network interface {{ .NetworkInterface }}" >&2 && exit 1
+fi
+{{ end -}}
 conf=$(mktemp)
 bc=$(mktemp)
 cat <<EOF > ${bc}
 uuid = "${UUID}"
 listen-address = "0.0.0.0:{{ .CNRpcPort }}"
-service-address = "${ADDR}:{{ .CNRpcPort }}"
-sql-address = "${ADDR}:{{ .CNSQLPort }}"
+service-address = "${SERVICE_ADDR}:{{ .CNRpcPort }}"
+sql-address = "${SERVICE_ADDR}:{{ .CNSQLPort }}"
 EOF
 # build instance config
 sed "/\[cn\]/r ${bc}" {{ .ConfigFilePath }} > ${conf}
@@ -56,24 +63,42 @@ sed "/\[cn\]/r ${bc}" {{ .ConfigFilePath }} > ${conf}
 # append lock-service configs
 lsc=$(mktemp)
 cat <<EOF > ${lsc}
-service-address = "${ADDR}:{{ .LockServicePort }}"
+service-address = "${SERVICE_ADDR}:{{ .LockServicePort }}"
 EOF
 sed -i "/\[cn.lockservice\]/r ${lsc}" ${conf}
 
-echo "/mo-service -cfg ${conf}"
-exec /mo-service -cfg ${conf}
+echo "/mo-service -cfg ${conf} $@"
+exec /mo-service -cfg ${conf} "$@"
 `))
 
+const (
+	// spillVolume is the volume name of the spill volume
+	spillVolume = "mo-spill"
+	// spillPath is the path where the spill volume will be mounted to
+	spillPath = "/var/lib/matrixone-spill"
+	// tmpDirEnvKey points the temporary directory of MO to the spill volume, MO has no config
+	// key for the spill directory and writes the temporary files to the os.TempDir() of Go
+	tmpDirEnvKey = "TMPDIR"
+
+	// readinessProbePeriodSeconds is the period of the readiness probe of CN
+	readinessProbePeriodSeconds = 5
+	// readinessProbeFailureThreshold is the default of the API server
+	readinessProbeFailureThreshold = 3
+)
+
 type model struct {
 	ConfigFilePath string
 	CNSQLPort      int
 	CNRpcPort      int
 
 	LockServicePort int
+
+	// NetworkInterface is the interface whose address is advertised instead of the pod DNS name
+	NetworkInterface string
 }
 
 func buildHeadlessSvc(cn *v1alpha1.CNSet) *corev1.Service {
-	return common.HeadlessServiceTemplate(cn, headlessSvcName(cn))
+	return common.HeadlessServiceTemplate(cn, headlessSvcName(cn), &cn.Spec.PodSet)
 }
 
 func buildSvc(cn *v1alpha1.CNSet) *corev1.Service {
@@ -100,6 +125,7 @@ func syncPersistentVolumeClaim(cn *v1alpha1.CNSet, sts *kruise.StatefulSet) {
 	if cn.Spec.CacheVolume != nil {
 		dataPVC := common.PersistentVolumeClaimTemplate(cn.Spec.CacheVolume.Size, cn.Spec.CacheVolume.StorageClassName, common.DataVolume)
 		tpls := []corev1.PersistentVolumeClaim{dataPVC}
+		common.SyncVolumeMetadata(cn.Spec.VolumeMetadata, tpls)
 		cn.Spec.Overlay.AppendVolumeClaims(&tpls)
 		sts.Spec.VolumeClaimTemplates = tpls
 	}
@@ -107,6 +133,12 @@ func syncPersistentVolumeClaim(cn *v1alpha1.CNSet, sts *kruise.StatefulSet) {
 
 func syncReplicas(cn *v1alpha1.CNSet, sts *kruise.StatefulSet) {
 	sts.Spec.Replicas = &cn.Spec.Replicas
+	if cn.Spec.ScaleStrategy != nil {
+		// requires the Parallel pod management policy, which is set by the statefulset template
+		sts.Spec.ScaleStrategy = &kruise.StatefulSetScaleStrategy{MaxUnavailable: cn.Spec.ScaleStrategy.MaxUnavailable}
+	} else {
+		sts.Spec.ScaleStrategy = nil
+	}
 }
 
 func syncService(cn *v1alpha1.CNSet, svc *corev1.Service) {
@@ -119,9 +151,16 @@ func syncService(cn *v1alpha1.CNSet, svc *corev1.Service) {
 			svc.Spec.Ports[portIndex].NodePort = *cn.Spec.NodePort
 		}
 	}
+	if cn.Spec.ExternalTrafficPolicy != "" {
+		svc.Spec.ExternalTrafficPolicy = cn.Spec.ExternalTrafficPolicy
+	} else if svc.Spec.Type == corev1.ServiceTypeClusterIP {
+		// external traffic policy is not allowed for ClusterIP service
+		svc.Spec.ExternalTrafficPolicy = ""
+	}
 }
 
 func syncPodMeta(cn *v1alpha1.CNSet, sts *kruise.StatefulSet) {
+	common.SyncNetworkAttachment(cn.Spec.NetworkAttachment, &sts.Spec.Template.ObjectMeta)
 	cn.Spec.Overlay.OverlayPodMeta(&sts.Spec.Template.ObjectMeta)
 }
 
@@ -131,13 +170,13 @@ func syncPodSpec(cn *v1alpha1.CNSet, sts *kruise.StatefulSet, sp v1alpha1.Shared
 	mainRef := util.FindFirst(specRef.Containers, func(c corev1.Container) bool {
 		return c.Name == v1alpha1.ContainerMain
 	})
+	existing := mainRef != nil
 	if mainRef == nil {
 		mainRef = &corev1.Container{Name: v1alpha1.ContainerMain}
 	}
 	mainRef.Image = cn.Spec.Image
 	mainRef.Resources = cn.Spec.Resources
 
-	mainRef.Command = []string{"/bin/sh", fmt.Sprintf("%s/%s", common.ConfigPath, common.Entrypoint)}
 	volumeMountsList := []corev1.VolumeMount{
 		{
 			Name:      common.ConfigVolume,
@@ -154,7 +193,15 @@ func syncPodSpec(cn *v1alpha1.CNSet, sts *kruise.StatefulSet, sp v1alpha1.Shared
 	if cn.Spec.CacheVolume != nil {
 		volumeMountsList = append(volumeMountsList, dataVolume)
 	}
+	if cn.Spec.SpillVolume != nil {
+		volumeMountsList = append(volumeMountsList, corev1.VolumeMount{
+			Name:      spillVolume,
+			MountPath: spillPath,
+		})
+	}
+	mainRef.Args = cn.Spec.ExtraServiceArgs
 	mainRef.VolumeMounts = volumeMountsList
+	common.SyncEntrypoint(&cn.Spec.PodSet, mainRef, common.ConfigPath, common.EntrypointFileName(&cn.Spec.PodSet))
 
 	mainRef.Env = []corev1.EnvVar{
 		util.FieldRefEnv(common.PodNameEnvKey, "metadata.name"),
@@ -162,21 +209,66 @@ func syncPodSpec(cn *v1alpha1.CNSet, sts *kruise.StatefulSet, sp v1alpha1.Shared
 		{Name: common.HeadlessSvcEnvKey, Value: headlessSvcName(cn)},
 	}
 	if cn.Spec.DNSBasedIdentity {
-		mainRef.Env = append(mainRef.Env, corev1.EnvVar{Name: "HOSTNAME_UUID", Value: "y"})
+		mainRef.Env = append(mainRef.Env, corev1.EnvVar{Name: common.HostnameUUIDEnvKey, Value: "y"})
+	}
+	if cn.Spec.SpillVolume != nil {
+		mainRef.Env = append(mainRef.Env, corev1.EnvVar{Name: tmpDirEnvKey, Value: spillPath})
+	}
+	// CN listens on the SQL port after it has joined the cluster, keep the pod out of the
+	// service endpoints before that to avoid routing clients to a CN that cannot serve SQL.
+	// The pods of the sets created before the probe is generated are not rolled by the operator
+	// upgrade, the probe is added when the pods are rolled by other changes
+	if !existing || mainRef.ReadinessProbe != nil {
+		mainRef.ReadinessProbe = common.TCPProbe(CNSQLPort, readinessProbePeriodSeconds, readinessProbeFailureThreshold)
 	}
+	common.SyncContainerSecurityContext(cn.Spec.ContainerSecurityContext, mainRef)
 
 	cn.Spec.Overlay.OverlayMainContainer(mainRef)
 
 	specRef.Containers = []corev1.Container{*mainRef}
-	specRef.ReadinessGates = []corev1.PodReadinessGate{{
-		ConditionType: pub.InPlaceUpdateReady,
-	}}
+	syncSpillVolume(cn, specRef)
+	common.SyncTmpVolume(cn.Spec.TmpVolume, specRef)
+	common.SyncEphemeralStorage(cn.Spec.EphemeralStorage, specRef)
+	common.SyncReadinessGates(&cn.Spec.PodSet, specRef, true)
 	specRef.NodeSelector = cn.Spec.NodeSelector
+	specRef.ServiceAccountName = cn.Spec.ServiceAccountName
 	common.SetStorageProviderConfig(sp, specRef)
-	common.SyncTopology(cn.Spec.TopologyEvenSpread, specRef)
+	common.SyncTopology(cn.Spec.TopologyEvenSpread, cn.Spec.TopologySpreadPolicy, cn, specRef)
+	common.SyncSysctls(cn.Spec.Sysctls, specRef)
+	common.SyncAntiAffinity(common.AntiAffinityPolicyOf(&cn.Spec.PodSet, cn, specRef, existing), cn, specRef)
 	cn.Spec.Overlay.OverlayPodSpec(specRef)
 }
 
+func syncSpillVolume(cn *v1alpha1.CNSet, specRef *corev1.PodSpec) {
+	var volumes []corev1.Volume
+	for _, v := range specRef.Volumes {
+		if v.Name != spillVolume {
+			volumes = append(volumes, v)
+		}
+	}
+	if sv := cn.Spec.SpillVolume; sv != nil {
+		volumes = append(volumes, corev1.Volume{
+			Name: spillVolume,
+			VolumeSource: corev1.VolumeSource{
+				EmptyDir: sv.EmptyDir,
+				HostPath: sv.HostPath,
+			},
+		})
+	}
+	specRef.Volumes = volumes
+}
+
+// setFrontendConfig renders the frontend limits to the CN config
+func setFrontendConfig(cfg *v1alpha1.TomlConfig, f *v1alpha1.FrontendConfig) {
+	if f == nil {
+		return
+	}
+	// the frontend config of MO uses camelCase keys
+	if f.MaxMessageSize != nil {
+		cfg.Set([]string{"cn", "frontend", "maxMessageSize"}, f.MaxMessageSize.Value())
+	}
+}
+
 func buildCNSetConfigMap(cn *v1alpha1.CNSet, ls *v1alpha1.LogSet) (*corev1.ConfigMap, error) {
 	if ls.Status.Discovery == nil {
 		return nil, errors.New("logset had not yet exposed HAKeeper discovery address")
@@ -185,22 +277,29 @@ func buildCNSetConfigMap(cn *v1alpha1.CNSet, ls *v1alpha1.LogSet) (*corev1.Confi
 	if cfg == nil {
 		cfg = v1alpha1.NewTomlConfig(map[string]interface{}{})
 	}
-	cfg.Merge(common.FileServiceConfig(fmt.Sprintf("%s/%s", common.DataPath, common.DataDir), ls.Spec.SharedStorage, cn.Spec.CacheVolume, &cn.Spec.SharedStorageCache))
+	fsConfig := common.FileServiceConfig(fmt.Sprintf("%s/%s", common.DataPath, common.DataDirName(&cn.Spec.PodSet)), ls.Spec.SharedStorage, cn.Spec.CacheVolume, &cn.Spec.SharedStorageCache)
+	if cn.Spec.CacheSharingMode == v1alpha1.CacheSharingModeDistributed {
+		common.EnableRemoteCache(fsConfig)
+	}
+	cfg.Merge(fsConfig)
 	cfg.Set([]string{"service-type"}, "CN")
 	cfg.Set([]string{"hakeeper-client", "service-addresses"}, logset.HaKeeperAdds(ls))
 	// cfg.Set([]string{"hakeeper-client", "discovery-address"}, ls.Status.Discovery.String())
 	cfg.Set([]string{"cn", "role"}, cn.Spec.Role)
 	cfg.Set([]string{"cn", "lockservice", "listen-address"}, fmt.Sprintf("0.0.0.0:%d", common.LockServicePort))
+	setFrontendConfig(cfg, cn.Spec.Frontend)
 	s, err := cfg.ToString()
 	if err != nil {
 		return nil, err
 	}
+	configFile := common.ConfigFileName(&cn.Spec.PodSet, common.ConfigFile)
 	buff := new(bytes.Buffer)
 	err = startScriptTpl.Execute(buff, &model{
-		ConfigFilePath:  fmt.Sprintf("%s/%s", common.ConfigPath, common.ConfigFile),
-		CNSQLPort:       CNSQLPort,
-		CNRpcPort:       cnRPCPort,
-		LockServicePort: common.LockServicePort,
+		ConfigFilePath:   fmt.Sprintf("%s/%s", common.ConfigPath, configFile),
+		CNSQLPort:        CNSQLPort,
+		CNRpcPort:        cnRPCPort,
+		LockServicePort:  common.LockServicePort,
+		NetworkInterface: common.NetworkInterface(cn.Spec.NetworkAttachment),
 	})
 	if err != nil {
 		return nil, err
@@ -210,11 +309,11 @@ func buildCNSetConfigMap(cn *v1alpha1.CNSet, ls *v1alpha1.LogSet) (*corev1.Confi
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      configMapName(cn),
 			Namespace: cn.Namespace,
-			Labels:    common.SubResourceLabels(cn),
+			Labels:    common.ResourceLabels(cn),
 		},
 		Data: map[string]string{
-			common.ConfigFile: s,
-			common.Entrypoint: buff.String(),
+			configFile: s,
+			common.EntrypointFileName(&cn.Spec.PodSet): buff.String(),
 		},
 	}, nil
 }
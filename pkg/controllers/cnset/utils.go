@@ -16,6 +16,7 @@ package cnset
 
 import (
 	"github.com/matrixorigin/matrixone-operator/api/core/v1alpha1"
+	"github.com/matrixorigin/matrixone-operator/pkg/controllers/common"
 	corev1 "k8s.io/api/core/v1"
 )
 
@@ -26,6 +27,11 @@ const (
 	cnRPCPort  = 6002
 )
 
+// Ports returns the ports that the CNSet pods listen on
+func Ports() []int32 {
+	return []int32{CNSQLPort, cnRPCPort, common.LockServicePort}
+}
+
 func getCNServicePort() corev1.ServicePort {
 	return corev1.ServicePort{
 		Name: portName,
@@ -34,22 +40,30 @@ func getCNServicePort() corev1.ServicePort {
 }
 
 func headlessSvcName(cn *v1alpha1.CNSet) string {
-	return resourceName(cn) + "-headless"
+	return common.ResourceName(resourceName(cn), "-headless", common.MaxServiceNameLength)
 }
 
 func svcName(cn *v1alpha1.CNSet) string {
-	return resourceName(cn)
+	return common.ResourceName(resourceName(cn), "", common.MaxServiceNameLength)
+}
+
+// ServiceName returns the name of the service that accepts the SQL connections to the CN set
+func ServiceName(cn *v1alpha1.CNSet) string {
+	return svcName(cn)
 }
 
 func stsName(cn *v1alpha1.CNSet) string {
-	return resourceName(cn)
+	return common.ResourceName(resourceName(cn), "", common.MaxStatefulSetNameLength)
 }
 
 func configMapName(cn *v1alpha1.CNSet) string {
-	return resourceName(cn) + "-config"
+	return common.ResourceName(resourceName(cn), "-config", common.MaxConfigMapNameLength)
 
 }
 
 func resourceName(cn *v1alpha1.CNSet) string {
+	if cn.Spec.NameOverride != "" {
+		return cn.Spec.NameOverride
+	}
 	return cn.Name + nameSuffix
 }
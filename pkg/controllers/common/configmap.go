@@ -20,9 +20,11 @@ import (
 	"github.com/cespare/xxhash"
 	recon "github.com/matrixorigin/controller-runtime/pkg/reconciler"
 	"github.com/matrixorigin/controller-runtime/pkg/util"
+	"github.com/matrixorigin/matrixone-operator/api/core/v1alpha1"
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 const (
@@ -34,31 +36,163 @@ const (
 	ConfigFile = "config.toml"
 	// Entrypoint is the entrypoint of mo container
 	Entrypoint = "start.sh"
+	// EntrypointVolume is the volume name of the separated entrypoint configmap
+	EntrypointVolume = "entrypoint"
+	// EntrypointPath is the path where the entrypoint volume will be mounted to
+	EntrypointPath = "/etc/matrixone/entrypoint"
 )
 
+// ConfigFileName returns the name of the config file of the set, which is also the key of
+// the config file in the configmap
+func ConfigFileName(ps *v1alpha1.PodSet, defaultName string) string {
+	if ps.ConfigFileName != "" {
+		return ps.ConfigFileName
+	}
+	return defaultName
+}
+
+// EntrypointFileName returns the name of the entrypoint script of the set, which is also the
+// key of the script in the configmap
+func EntrypointFileName(ps *v1alpha1.PodSet) string {
+	if ps.EntrypointFileName != "" {
+		return ps.EntrypointFileName
+	}
+	return Entrypoint
+}
+
 // SyncConfigMap syncs the desired configmap for pods, which will cause rolling-update if the
 // data of the configmap is changed
-func SyncConfigMap(kubeCli recon.KubeClient, podSpec *corev1.PodSpec, cm *corev1.ConfigMap) error {
+func SyncConfigMap(kubeCli recon.KubeClient, podSpec *corev1.PodSpec, cm *corev1.ConfigMap, configFile string) error {
+	if err := checkConfigFile(cm, configFile); err != nil {
+		return err
+	}
 	var currentCmName string
-	vp := util.FindFirst(podSpec.Volumes, util.WithVolumeName("config"))
-	if vp != nil {
-		currentCmName = vp.Name
+	vp := util.FindFirst(podSpec.Volumes, util.WithVolumeName(ConfigVolume))
+	if vp != nil && vp.ConfigMap != nil {
+		currentCmName = vp.ConfigMap.Name
 	}
 	// TODO(aylei): GC stale configmaps (maybe in another worker?)
 	desiredName, err := ensureConfigMap(kubeCli, currentCmName, cm)
 	if err != nil {
 		return err
 	}
+	return setConfigMapVolume(podSpec, ConfigVolume, desiredName)
+}
+
+// SyncEntrypointConfigMap moves the entrypoint script of the configmap to a dedicated configmap
+// if the set requires, so that the changes of the config and the entrypoint do not churn each other.
+// It must be called before the configmap is synced.
+func SyncEntrypointConfigMap(kubeCli recon.KubeClient, ps *v1alpha1.PodSet, podSpec *corev1.PodSpec, cm *corev1.ConfigMap, key string) error {
+	if !ps.SeparateEntrypointConfigMap {
+		removeVolume(podSpec, EntrypointVolume)
+		return nil
+	}
+	var currentCmName string
+	vp := util.FindFirst(podSpec.Volumes, util.WithVolumeName(EntrypointVolume))
+	if vp != nil && vp.ConfigMap != nil {
+		currentCmName = vp.ConfigMap.Name
+	}
+	desiredName, err := ensureConfigMap(kubeCli, currentCmName, splitEntrypoint(cm, key))
+	if err != nil {
+		return err
+	}
+	return setConfigMapVolume(podSpec, EntrypointVolume, desiredName)
+}
+
+// RenderEntrypointConfigMap renders the separated entrypoint configmap if the set requires, without
+// touching the cluster. It must be called before the configmap is rendered.
+func RenderEntrypointConfigMap(ps *v1alpha1.PodSet, podSpec *corev1.PodSpec, cm *corev1.ConfigMap, key string) (*corev1.ConfigMap, error) {
+	if !ps.SeparateEntrypointConfigMap {
+		removeVolume(podSpec, EntrypointVolume)
+		return nil, nil
+	}
+	ep := splitEntrypoint(cm, key)
+	if err := addConfigMapDigest(ep); err != nil {
+		return nil, err
+	}
+	if err := setConfigMapVolume(podSpec, EntrypointVolume, ep.Name); err != nil {
+		return nil, err
+	}
+	return ep, nil
+}
+
+// SyncEntrypoint sets the command of the main container to run the entrypoint script, which resides in
+// either the config volume or the separated entrypoint volume. It must be called after the volume mounts
+// of the container are set.
+func SyncEntrypoint(ps *v1alpha1.PodSet, c *corev1.Container, configPath string, entrypoint string) {
+	dir := configPath
+	if ps.SeparateEntrypointConfigMap {
+		dir = EntrypointPath
+		c.VolumeMounts = append(c.VolumeMounts, corev1.VolumeMount{
+			Name:      EntrypointVolume,
+			ReadOnly:  true,
+			MountPath: EntrypointPath,
+		})
+	}
+	c.Command = []string{"/bin/sh", fmt.Sprintf("%s/%s", dir, entrypoint)}
+}
+
+func removeVolume(podSpec *corev1.PodSpec, name string) {
+	var volumes []corev1.Volume
+	for _, v := range podSpec.Volumes {
+		if v.Name != name {
+			volumes = append(volumes, v)
+		}
+	}
+	podSpec.Volumes = volumes
+}
+
+// splitEntrypoint moves the entrypoint script of the configmap to a new configmap
+func splitEntrypoint(cm *corev1.ConfigMap, key string) *corev1.ConfigMap {
+	ep := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: cm.Namespace,
+			Name:      cm.Name + "-" + EntrypointVolume,
+			Labels:    cm.Labels,
+		},
+		Data: map[string]string{key: cm.Data[key]},
+	}
+	delete(cm.Data, key)
+	return ep
+}
+
+// RenderConfigMap renders the desired configmap for pods and refers the config volume of the pods
+// to it, without touching the cluster
+func RenderConfigMap(podSpec *corev1.PodSpec, cm *corev1.ConfigMap, configFile string) (*corev1.ConfigMap, error) {
+	if err := checkConfigFile(cm, configFile); err != nil {
+		return nil, err
+	}
+	c := cm.DeepCopy()
+	if err := addConfigMapDigest(c); err != nil {
+		return nil, err
+	}
+	if err := setConfigMapVolume(podSpec, ConfigVolume, c.Name); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// checkConfigFile ensures the config file the entrypoint reads is present in the configmap, since
+// the whole configmap is mounted and the key is the file name
+func checkConfigFile(cm *corev1.ConfigMap, configFile string) error {
+	if _, ok := cm.Data[configFile]; !ok {
+		return errors.Errorf("config file %s not found in configmap %s", configFile, cm.Name)
+	}
+	return nil
+}
+
+func setConfigMapVolume(podSpec *corev1.PodSpec, volumeName string, desiredName string) error {
+	vp := util.FindFirst(podSpec.Volumes, util.WithVolumeName(volumeName))
 	if vp != nil {
 		// update existing config volume ref
 		if vp.VolumeSource.ConfigMap == nil {
-			return errors.New("config volume must be sourced by a ConfigMap")
+			return errors.Errorf("%s volume must be sourced by a ConfigMap", volumeName)
 		}
 		vp.VolumeSource.ConfigMap.Name = desiredName
 	} else {
 		// insert new config volume ref
 		podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
-			Name:         "config",
+			Name:         volumeName,
 			VolumeSource: util.ConfigMapVolume(desiredName),
 		})
 	}
@@ -93,3 +227,21 @@ func addConfigMapDigest(cm *corev1.ConfigMap) error {
 	cm.Name = fmt.Sprintf("%s-%s", cm.Name, suffix)
 	return nil
 }
+
+// SetConfigBuiltCondition records the result of building the config to the status, so that
+// users can see why a set is stuck without reading the operator logs
+func SetConfigBuiltCondition(s *v1alpha1.ConditionalStatus, err error) {
+	if err != nil {
+		s.SetCondition(metav1.Condition{
+			Type:    ConditionTypeConfigBuilt,
+			Status:  metav1.ConditionFalse,
+			Reason:  ReasonConfigBuildFailed,
+			Message: err.Error(),
+		})
+		return
+	}
+	s.SetCondition(metav1.Condition{
+		Type:   ConditionTypeConfigBuilt,
+		Status: metav1.ConditionTrue,
+	})
+}
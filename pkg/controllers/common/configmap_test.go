@@ -16,12 +16,61 @@ package common
 import (
 	"testing"
 
+	recon "github.com/matrixorigin/controller-runtime/pkg/reconciler"
+	"github.com/matrixorigin/matrixone-operator/api/core/v1alpha1"
 	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
 	"golang.org/x/exp/utf8string"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
+func TestSetConfigBuiltCondition(t *testing.T) {
+	g := NewGomegaWithT(t)
+	s := &v1alpha1.ConditionalStatus{}
+	SetConfigBuiltCondition(s, errors.New("HAKeeper discovery address not ready"))
+	c := meta.FindStatusCondition(s.Conditions, ConditionTypeConfigBuilt)
+	g.Expect(c).ToNot(BeNil())
+	g.Expect(c.Status).To(Equal(metav1.ConditionFalse))
+	g.Expect(c.Reason).To(Equal(ReasonConfigBuildFailed))
+	g.Expect(c.Message).To(Equal("HAKeeper discovery address not ready"))
+
+	SetConfigBuiltCondition(s, nil)
+	c = meta.FindStatusCondition(s.Conditions, ConditionTypeConfigBuilt)
+	g.Expect(c.Status).To(Equal(metav1.ConditionTrue))
+}
+
+// createCounter counts the objects created through the client
+type createCounter struct {
+	recon.KubeClient
+	created int
+}
+
+func (c *createCounter) CreateOwned(_ client.Object, _ ...client.CreateOption) error {
+	c.created++
+	return nil
+}
+
+func TestSyncConfigMap(t *testing.T) {
+	g := NewGomegaWithT(t)
+	cli := &createCounter{}
+	podSpec := &corev1.PodSpec{}
+	cm := newCM("hello world")
+	cm.Data[ConfigFile] = "service-type = \"CN\""
+	g.Expect(SyncConfigMap(cli, podSpec, cm.DeepCopy(), ConfigFile)).To(Succeed())
+	g.Expect(cli.created).To(Equal(1))
+
+	// the configmap is not created again if its data is not changed
+	g.Expect(SyncConfigMap(cli, podSpec, cm.DeepCopy(), ConfigFile)).To(Succeed())
+	g.Expect(cli.created).To(Equal(1))
+
+	cm.Data[ConfigFile] = "service-type = \"DN\""
+	g.Expect(SyncConfigMap(cli, podSpec, cm.DeepCopy(), ConfigFile)).To(Succeed())
+	g.Expect(cli.created).To(Equal(2))
+}
+
 func TestAddConfigMapDigest(t *testing.T) {
 	// need fuzz?
 	cmList := []*corev1.ConfigMap{
@@ -46,3 +95,60 @@ func newCM(data string) *corev1.ConfigMap {
 		},
 	}
 }
+
+func TestRenderEntrypointConfigMap(t *testing.T) {
+	g := NewGomegaWithT(t)
+	newSource := func() *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "mo-dn"},
+			Data: map[string]string{
+				ConfigFile: "service-type = \"DN\"",
+				Entrypoint: "exec mo-service",
+			},
+		}
+	}
+	podSpec := &corev1.PodSpec{}
+	ps := &v1alpha1.PodSet{}
+
+	cm := newSource()
+	ep, err := RenderEntrypointConfigMap(ps, podSpec, cm, Entrypoint)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(ep).To(BeNil())
+	g.Expect(cm.Data).To(HaveKey(Entrypoint))
+
+	ps.SeparateEntrypointConfigMap = true
+	ep, err = RenderEntrypointConfigMap(ps, podSpec, cm, Entrypoint)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(ep.Namespace).To(Equal("default"))
+	g.Expect(ep.Data).To(Equal(map[string]string{Entrypoint: "exec mo-service"}))
+	g.Expect(cm.Data).ToNot(HaveKey(Entrypoint))
+	g.Expect(podSpec.Volumes).To(HaveLen(1))
+	g.Expect(podSpec.Volumes[0].Name).To(Equal(EntrypointVolume))
+	g.Expect(podSpec.Volumes[0].ConfigMap.Name).To(Equal(ep.Name))
+
+	ps.SeparateEntrypointConfigMap = false
+	_, err = RenderEntrypointConfigMap(ps, podSpec, newSource(), Entrypoint)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(podSpec.Volumes).To(BeEmpty())
+}
+
+func TestConfigFileName(t *testing.T) {
+	g := NewGomegaWithT(t)
+	ps := &v1alpha1.PodSet{}
+	g.Expect(ConfigFileName(ps, ConfigFile)).To(Equal(ConfigFile))
+	g.Expect(EntrypointFileName(ps)).To(Equal(Entrypoint))
+	ps.ConfigFileName = "mo.toml"
+	ps.EntrypointFileName = "run.sh"
+	g.Expect(ConfigFileName(ps, ConfigFile)).To(Equal("mo.toml"))
+	g.Expect(EntrypointFileName(ps)).To(Equal("run.sh"))
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "mo-cn"},
+		Data:       map[string]string{"mo.toml": "service-type = \"CN\""},
+	}
+	_, err := RenderConfigMap(&corev1.PodSpec{}, cm, ConfigFile)
+	g.Expect(err).To(HaveOccurred())
+	rendered, err := RenderConfigMap(&corev1.PodSpec{}, cm, "mo.toml")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(rendered.Data).To(HaveKey("mo.toml"))
+}
@@ -0,0 +1,85 @@
+// Copyright 2023 Matrix Origin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"net"
+	"sync"
+	"time"
+
+	recon "github.com/matrixorigin/controller-runtime/pkg/reconciler"
+	"github.com/matrixorigin/matrixone-operator/api/core/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	externalProbeTimeout = 3 * time.Second
+	// externalProbeTTL is how long a probe result of an endpoint is reused, which bounds the
+	// dials to an endpoint shared by many sets to one per TTL
+	externalProbeTTL = 10 * time.Second
+	// externalProbeRetry is the fixed delay to retry the reconciliation if the endpoint is unreachable
+	externalProbeRetry = 10 * time.Second
+)
+
+type probeResult struct {
+	err      error
+	probedAt time.Time
+}
+
+// externalProbes caches the last probe result of each HAKeeper endpoint
+var externalProbes = struct {
+	sync.Mutex
+	results map[string]probeResult
+}{results: map[string]probeResult{}}
+
+// CheckExternalLogSet probes the HAKeeper endpoint of the external LogSet if the set is configured to
+// wait for it. The Ready condition is set to False and the reconciliation is retried after a fixed
+// delay if the endpoint is not reachable, so that the pods are not bootstrapped against an unhealthy
+// LogSet. The probe result is cached for a short TTL so that the reconciliation is not blocked by
+// dialing the endpoint on every pass.
+func CheckExternalLogSet(ref *v1alpha1.LogSetRef, status *v1alpha1.ConditionalStatus) error {
+	ext := ref.ExternalLogSet
+	if ext == nil || !ext.WaitForReady {
+		return nil
+	}
+	err := probeEndpoint(ext.HAKeeperEndpoint)
+	if err != nil {
+		status.SetCondition(metav1.Condition{
+			Type:    recon.ConditionTypeReady,
+			Status:  metav1.ConditionFalse,
+			Reason:  ReasonDependencyNotReady,
+			Message: err.Error(),
+		})
+		return recon.ErrReSync("external LogSet "+ext.HAKeeperEndpoint+" is not reachable", externalProbeRetry)
+	}
+	return nil
+}
+
+func probeEndpoint(endpoint string) error {
+	externalProbes.Lock()
+	r, ok := externalProbes.results[endpoint]
+	externalProbes.Unlock()
+	if ok && time.Since(r.probedAt) < externalProbeTTL {
+		return r.err
+	}
+	conn, err := net.DialTimeout("tcp", endpoint, externalProbeTimeout)
+	if err == nil {
+		err = conn.Close()
+	}
+	externalProbes.Lock()
+	externalProbes.results[endpoint] = probeResult{err: err, probedAt: time.Now()}
+	externalProbes.Unlock()
+	return err
+}
@@ -0,0 +1,58 @@
+// Copyright 2023 Matrix Origin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"net"
+	"testing"
+
+	recon "github.com/matrixorigin/controller-runtime/pkg/reconciler"
+	"github.com/matrixorigin/matrixone-operator/api/core/v1alpha1"
+	. "github.com/onsi/gomega"
+)
+
+func TestCheckExternalLogSet(t *testing.T) {
+	g := NewGomegaWithT(t)
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	g.Expect(err).To(Succeed())
+	reachable := l.Addr().String()
+	l2, err := net.Listen("tcp", "127.0.0.1:0")
+	g.Expect(err).To(Succeed())
+	unreachable := l2.Addr().String()
+	g.Expect(l2.Close()).To(Succeed())
+
+	status := &v1alpha1.ConditionalStatus{}
+	ref := &v1alpha1.LogSetRef{ExternalLogSet: &v1alpha1.ExternalLogSet{HAKeeperEndpoint: unreachable}}
+	g.Expect(CheckExternalLogSet(ref, status)).To(Succeed(), "the endpoint is not probed if not required")
+
+	ref.ExternalLogSet.WaitForReady = true
+	err = CheckExternalLogSet(ref, status)
+	g.Expect(err).To(BeAssignableToTypeOf(&recon.ReSync{}), "the reconciliation is retried after a fixed delay")
+	cond, ok := recon.GetCondition(status, recon.ConditionTypeReady)
+	g.Expect(ok).To(BeTrue())
+	g.Expect(cond.Reason).To(Equal(ReasonDependencyNotReady))
+
+	ref.ExternalLogSet.HAKeeperEndpoint = reachable
+	g.Expect(CheckExternalLogSet(ref, status)).To(Succeed())
+
+	g.Expect(l.Close()).To(Succeed())
+	g.Expect(CheckExternalLogSet(ref, status)).To(Succeed(), "the probe result is cached")
+	externalProbes.Lock()
+	r := externalProbes.results[reachable]
+	r.probedAt = r.probedAt.Add(-externalProbeTTL)
+	externalProbes.results[reachable] = r
+	externalProbes.Unlock()
+	g.Expect(CheckExternalLogSet(ref, status)).NotTo(Succeed(), "the endpoint is probed again after the TTL")
+}
@@ -0,0 +1,139 @@
+// Copyright 2023 Matrix Origin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	recon "github.com/matrixorigin/controller-runtime/pkg/reconciler"
+	"github.com/matrixorigin/matrixone-operator/api/core/v1alpha1"
+	"github.com/openkruise/kruise-api/apps/pub"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// reasonDrained marks the readiness gate condition that is set to False by the drain
+	reasonDrained = "Drained"
+
+	// ServingCondition is the readiness gate condition of the drainable pods that are not updated in
+	// place, which is True unless the pod is drained
+	ServingCondition corev1.PodConditionType = "matrixorigin.io/serving"
+)
+
+// IsDrained returns whether the pod is requested to be drained by the DrainAnnotation
+func IsDrained(pod *corev1.Pod) bool {
+	return pod.Annotations[v1alpha1.DrainAnnotation] == "true"
+}
+
+// SyncDrainedPods marks the drained pods not ready through the readiness gate of the pods, and
+// reverts the readiness gate of the pods that are no longer drained. For the InPlaceUpdateReady
+// gate, only the condition set by the drain is reverted and the condition maintained by the in-place
+// update is left untouched. The ServingCondition is owned by the drain and is set on every pod gated by it.
+func SyncDrainedPods[T client.Object](ctx *recon.Context[T], pods []corev1.Pod) error {
+	for i := range pods {
+		pod := &pods[i]
+		if pod.DeletionTimestamp != nil {
+			continue
+		}
+		var desired *corev1.PodCondition
+		switch {
+		case hasReadinessGate(pod, pub.InPlaceUpdateReady):
+			desired = inPlaceUpdateReadyOf(pod)
+		case hasReadinessGate(pod, ServingCondition):
+			desired = servingConditionOf(pod)
+		}
+		if desired == nil {
+			continue
+		}
+		desired.LastTransitionTime = metav1.Now()
+		setPodCondition(pod, *desired)
+		if err := ctx.UpdateStatus(pod); err != nil {
+			return errors.Wrapf(err, "sync drain of pod %s", pod.Name)
+		}
+	}
+	return nil
+}
+
+// inPlaceUpdateReadyOf returns the desired InPlaceUpdateReady condition of the pod, or nil if
+// the condition need not be changed
+func inPlaceUpdateReadyOf(pod *corev1.Pod) *corev1.PodCondition {
+	c := findPodCondition(pod, pub.InPlaceUpdateReady)
+	switch {
+	case IsDrained(pod) && (c == nil || c.Status != corev1.ConditionFalse):
+		return &corev1.PodCondition{
+			Type:    pub.InPlaceUpdateReady,
+			Status:  corev1.ConditionFalse,
+			Reason:  reasonDrained,
+			Message: "pod is drained by the " + v1alpha1.DrainAnnotation + " annotation",
+		}
+	case !IsDrained(pod) && c != nil && c.Reason == reasonDrained:
+		return &corev1.PodCondition{
+			Type:   pub.InPlaceUpdateReady,
+			Status: corev1.ConditionTrue,
+		}
+	}
+	return nil
+}
+
+// servingConditionOf returns the desired ServingCondition of the pod, or nil if the condition
+// need not be changed
+func servingConditionOf(pod *corev1.Pod) *corev1.PodCondition {
+	c := findPodCondition(pod, ServingCondition)
+	if IsDrained(pod) {
+		if c != nil && c.Status == corev1.ConditionFalse {
+			return nil
+		}
+		return &corev1.PodCondition{
+			Type:    ServingCondition,
+			Status:  corev1.ConditionFalse,
+			Reason:  reasonDrained,
+			Message: "pod is drained by the " + v1alpha1.DrainAnnotation + " annotation",
+		}
+	}
+	if c != nil && c.Status == corev1.ConditionTrue {
+		return nil
+	}
+	return &corev1.PodCondition{
+		Type:   ServingCondition,
+		Status: corev1.ConditionTrue,
+	}
+}
+
+func hasReadinessGate(pod *corev1.Pod, t corev1.PodConditionType) bool {
+	for _, g := range pod.Spec.ReadinessGates {
+		if g.ConditionType == t {
+			return true
+		}
+	}
+	return false
+}
+
+func findPodCondition(pod *corev1.Pod, t corev1.PodConditionType) *corev1.PodCondition {
+	for i := range pod.Status.Conditions {
+		if pod.Status.Conditions[i].Type == t {
+			return &pod.Status.Conditions[i]
+		}
+	}
+	return nil
+}
+
+func setPodCondition(pod *corev1.Pod, condition corev1.PodCondition) {
+	if c := findPodCondition(pod, condition.Type); c != nil {
+		*c = condition
+		return
+	}
+	pod.Status.Conditions = append(pod.Status.Conditions, condition)
+}
@@ -0,0 +1,77 @@
+// Copyright 2023 Matrix Origin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"testing"
+
+	"github.com/matrixorigin/matrixone-operator/api/core/v1alpha1"
+	. "github.com/onsi/gomega"
+	"github.com/openkruise/kruise-api/apps/pub"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestCollectStoreStatusDrained(t *testing.T) {
+	g := NewGomegaWithT(t)
+	pod := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "drained",
+			Annotations: map[string]string{v1alpha1.DrainAnnotation: "true"},
+		},
+		Status: corev1.PodStatus{Conditions: []corev1.PodCondition{{
+			Type:   pub.InPlaceUpdateReady,
+			Status: corev1.ConditionFalse,
+			Reason: reasonDrained,
+		}}},
+	}
+	status := &v1alpha1.FailoverStatus{}
+	CollectStoreStatus(status, []corev1.Pod{pod}, 0)
+	g.Expect(status.FailedStores).To(BeEmpty())
+	g.Expect(status.AvailableStores).To(HaveLen(1))
+}
+
+func TestDrainConditions(t *testing.T) {
+	g := NewGomegaWithT(t)
+	drained := map[string]string{v1alpha1.DrainAnnotation: "true"}
+
+	// the InPlaceUpdateReady condition maintained by kruise is only reverted if it is set by the drain
+	pod := &corev1.Pod{Spec: corev1.PodSpec{ReadinessGates: []corev1.PodReadinessGate{{ConditionType: pub.InPlaceUpdateReady}}}}
+	g.Expect(inPlaceUpdateReadyOf(pod)).To(BeNil())
+	pod.Annotations = drained
+	c := inPlaceUpdateReadyOf(pod)
+	g.Expect(c.Status).To(Equal(corev1.ConditionFalse))
+	setPodCondition(pod, *c)
+	g.Expect(inPlaceUpdateReadyOf(pod)).To(BeNil())
+	pod.Annotations = nil
+	g.Expect(inPlaceUpdateReadyOf(pod).Status).To(Equal(corev1.ConditionTrue))
+	pod.Status.Conditions = []corev1.PodCondition{{Type: pub.InPlaceUpdateReady, Status: corev1.ConditionFalse}}
+	g.Expect(inPlaceUpdateReadyOf(pod)).To(BeNil())
+
+	// the ServingCondition is owned by the drain and is set on the new pods
+	pod = &corev1.Pod{Spec: corev1.PodSpec{ReadinessGates: []corev1.PodReadinessGate{{ConditionType: ServingCondition}}}}
+	c = servingConditionOf(pod)
+	g.Expect(c.Status).To(Equal(corev1.ConditionTrue))
+	setPodCondition(pod, *c)
+	g.Expect(servingConditionOf(pod)).To(BeNil())
+	pod.Annotations = drained
+	c = servingConditionOf(pod)
+	g.Expect(c.Status).To(Equal(corev1.ConditionFalse))
+	g.Expect(c.Reason).To(Equal(reasonDrained))
+	setPodCondition(pod, *c)
+	g.Expect(servingConditionOf(pod)).To(BeNil())
+	pod.Annotations = nil
+	g.Expect(servingConditionOf(pod).Status).To(Equal(corev1.ConditionTrue))
+}
@@ -0,0 +1,61 @@
+// Copyright 2023 Matrix Origin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"fmt"
+
+	recon "github.com/matrixorigin/controller-runtime/pkg/reconciler"
+)
+
+// Reasons of the events emitted on the reconcile milestones. The events are recorded by the event
+// recorder of the manager, which aggregates the repeated events of an object and rate-limits them,
+// so the messages must not contain varying content (e.g. timestamps) to be deduplicated.
+const (
+	ReasonBootstrapped      = "Bootstrapped"
+	ReasonFailoverTriggered = "FailoverTriggered"
+	ReasonUpgradeStarted    = "UpgradeStarted"
+	ReasonScaledUp          = "ScaledUp"
+	ReasonScaledDown        = "ScaledDown"
+	ReasonStuckPodDeleted   = "StuckPodDeleted"
+)
+
+// EmitScaleEvent emits a ScaledUp or ScaledDown event if the replicas changes
+func EmitScaleEvent(e recon.EventEmitter, from int32, to int32) {
+	switch {
+	case to > from:
+		e.EmitEventGeneric(ReasonScaledUp, fmt.Sprintf("scaled up from %d to %d replicas", from, to), nil)
+	case to < from:
+		e.EmitEventGeneric(ReasonScaledDown, fmt.Sprintf("scaled down from %d to %d replicas", from, to), nil)
+	}
+}
+
+// EmitUpgradeEvent emits an UpgradeStarted event if the image of the main container changes
+func EmitUpgradeEvent(e recon.EventEmitter, from string, to string) {
+	if from == "" || to == "" || from == to {
+		return
+	}
+	e.EmitEventGeneric(ReasonUpgradeStarted, fmt.Sprintf("upgrading from %s to %s", from, to), nil)
+}
+
+// EmitStuckPodDeletedEvent emits a StuckPodDeleted event for the pod force-deleted from the unreachable node
+func EmitStuckPodDeletedEvent(e recon.EventEmitter, podName string, nodeName string) {
+	e.EmitEventGeneric(ReasonStuckPodDeleted, fmt.Sprintf("force deleted pod %s stuck terminating on unreachable node %s", podName, nodeName), nil)
+}
+
+// EmitFailoverEvent emits a FailoverTriggered event for the failed store
+func EmitFailoverEvent(e recon.EventEmitter, podName string) {
+	e.EmitEventGeneric(ReasonFailoverTriggered, fmt.Sprintf("failover the store of pod %s", podName), nil)
+}
@@ -0,0 +1,43 @@
+// Copyright 2023 Matrix Origin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"testing"
+
+	recon "github.com/matrixorigin/controller-runtime/pkg/reconciler"
+	. "github.com/onsi/gomega"
+)
+
+type fakeEmitter struct {
+	recon.EventEmitter
+	reasons []string
+}
+
+func (e *fakeEmitter) EmitEventGeneric(reason, _ string, _ error) {
+	e.reasons = append(e.reasons, reason)
+}
+
+func TestEmitEvents(t *testing.T) {
+	g := NewGomegaWithT(t)
+	e := &fakeEmitter{}
+	EmitScaleEvent(e, 1, 3)
+	EmitScaleEvent(e, 3, 3)
+	EmitScaleEvent(e, 3, 2)
+	EmitUpgradeEvent(e, "", "mo:1.0.0")
+	EmitUpgradeEvent(e, "mo:1.0.0", "mo:1.0.0")
+	EmitUpgradeEvent(e, "mo:1.0.0", "mo:1.1.0")
+	g.Expect(e.reasons).To(Equal([]string{ReasonScaledUp, ReasonScaledDown, ReasonUpgradeStarted}))
+}
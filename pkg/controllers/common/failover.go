@@ -19,6 +19,7 @@ import (
 	"github.com/matrixorigin/matrixone-operator/api/core/v1alpha1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"time"
 )
 
@@ -30,16 +31,22 @@ const (
 type StoreFn func(store *v1alpha1.Store)
 
 // CollectStoreStatus is a template method to collect store status.
+// A down store is only considered failed after it has been down continuously for detectionDelay,
+// before that it is listed as a suspected store.
 // fns allows the caller to pass a list of functions set the store status according to other information (e.g. query HA Keeper)
-func CollectStoreStatus(status *v1alpha1.FailoverStatus, pods []corev1.Pod, fns ...StoreFn) {
+func CollectStoreStatus(status *v1alpha1.FailoverStatus, pods []corev1.Pod, detectionDelay time.Duration, fns ...StoreFn) {
 	previousStore := map[string]v1alpha1.Store{}
 	for _, store := range status.FailedStores {
 		previousStore[store.PodName] = store
 	}
+	for _, store := range status.SuspectedStores {
+		previousStore[store.PodName] = store
+	}
 	for _, store := range status.AvailableStores {
 		previousStore[store.PodName] = store
 	}
 	var availableStores []v1alpha1.Store
+	var suspectedStores []v1alpha1.Store
 	var failedStores []v1alpha1.Store
 	for _, pod := range pods {
 		store := v1alpha1.Store{
@@ -47,7 +54,8 @@ func CollectStoreStatus(status *v1alpha1.FailoverStatus, pods []corev1.Pod, fns
 			Phase:              v1alpha1.StorePhaseUp,
 			LastTransitionTime: metav1.Time{Time: time.Now()},
 		}
-		if !util.IsPodAvailable(&pod, minReadySeconds, metav1.Time{Time: time.Now()}) {
+		// a drained pod is not ready on purpose and should not be failed over
+		if !util.IsPodAvailable(&pod, minReadySeconds, metav1.Time{Time: time.Now()}) && !IsDrained(&pod) {
 			store.Phase = v1alpha1.StorePhaseDown
 		}
 		for _, fn := range fns {
@@ -60,13 +68,51 @@ func CollectStoreStatus(status *v1alpha1.FailoverStatus, pods []corev1.Pod, fns
 				store.LastTransitionTime = previous.LastTransitionTime
 			}
 		}
-		if store.Phase == v1alpha1.StorePhaseUp {
+		switch {
+		case store.Forced:
+			failedStores = append(failedStores, store)
+		case store.Phase == v1alpha1.StorePhaseUp:
 			availableStores = append(availableStores, store)
-		} else {
+		case time.Since(store.LastTransitionTime.Time) < detectionDelay:
+			suspectedStores = append(suspectedStores, store)
+		default:
 			failedStores = append(failedStores, store)
 		}
 	}
 	status.AvailableStores = availableStores
+	status.SuspectedStores = suspectedStores
 	status.FailedStores = failedStores
 	return
 }
+
+// ForceFailover returns a StoreFn that marks the store specified by the ForceFailoverAnnotation of the object as failed
+func ForceFailover(obj client.Object) StoreFn {
+	podName := obj.GetAnnotations()[v1alpha1.ForceFailoverAnnotation]
+	return func(store *v1alpha1.Store) {
+		if podName != "" && store.PodName == podName {
+			store.Phase = v1alpha1.StorePhaseDown
+			store.Forced = true
+		}
+	}
+}
+
+// CollectPodSummary counts the pods by their phases, a pod being deleted is counted as terminating
+// regardless of its phase
+func CollectPodSummary(pods []corev1.Pod) *v1alpha1.PodSummary {
+	s := &v1alpha1.PodSummary{}
+	for _, pod := range pods {
+		if pod.DeletionTimestamp != nil {
+			s.Terminating++
+			continue
+		}
+		switch pod.Status.Phase {
+		case corev1.PodRunning:
+			s.Running++
+		case corev1.PodPending:
+			s.Pending++
+		case corev1.PodFailed:
+			s.Failed++
+		}
+	}
+	return s
+}
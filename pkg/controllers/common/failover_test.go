@@ -0,0 +1,123 @@
+// Copyright 2023 Matrix Origin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"testing"
+	"time"
+
+	"github.com/matrixorigin/matrixone-operator/api/core/v1alpha1"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestCollectStoreStatus(t *testing.T) {
+	upPod := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "up"},
+		Status: corev1.PodStatus{
+			Conditions: []corev1.PodCondition{{
+				Type:               corev1.PodReady,
+				Status:             corev1.ConditionTrue,
+				LastTransitionTime: metav1.Time{Time: time.Now().Add(-time.Hour)},
+			}},
+		},
+	}
+	downPod := corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "down"}}
+	tests := []struct {
+		name          string
+		status        v1alpha1.FailoverStatus
+		delay         time.Duration
+		wantSuspected int
+		wantFailed    int
+	}{{
+		name:       "no delay",
+		delay:      0,
+		wantFailed: 1,
+	}, {
+		name:          "suspected",
+		delay:         time.Minute,
+		wantSuspected: 1,
+	}, {
+		name: "confirmed after delay",
+		status: v1alpha1.FailoverStatus{
+			SuspectedStores: []v1alpha1.Store{{
+				PodName:            "down",
+				Phase:              v1alpha1.StorePhaseDown,
+				LastTransitionTime: metav1.Time{Time: time.Now().Add(-2 * time.Minute)},
+			}},
+		},
+		delay:      time.Minute,
+		wantFailed: 1,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			status := tt.status.DeepCopy()
+			CollectStoreStatus(status, []corev1.Pod{upPod, downPod}, tt.delay)
+			g.Expect(status.AvailableStores).To(HaveLen(1))
+			g.Expect(status.SuspectedStores).To(HaveLen(tt.wantSuspected))
+			g.Expect(status.FailedStores).To(HaveLen(tt.wantFailed))
+		})
+	}
+}
+
+func TestForceFailover(t *testing.T) {
+	g := NewGomegaWithT(t)
+	upPod := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "up"},
+		Status: corev1.PodStatus{
+			Conditions: []corev1.PodCondition{{
+				Type:               corev1.PodReady,
+				Status:             corev1.ConditionTrue,
+				LastTransitionTime: metav1.Time{Time: time.Now().Add(-time.Hour)},
+			}},
+		},
+	}
+	ls := &v1alpha1.LogSet{ObjectMeta: metav1.ObjectMeta{
+		Annotations: map[string]string{v1alpha1.ForceFailoverAnnotation: "up"},
+	}}
+	status := &v1alpha1.FailoverStatus{}
+	CollectStoreStatus(status, []corev1.Pod{upPod}, time.Minute, ForceFailover(ls))
+	g.Expect(status.AvailableStores).To(BeEmpty())
+	g.Expect(status.SuspectedStores).To(BeEmpty())
+	g.Expect(status.FailedStores).To(HaveLen(1))
+	g.Expect(status.FailedStores[0].Forced).To(BeTrue())
+	g.Expect(status.StoresFailedFor(time.Hour)).To(HaveLen(1), "forced store should bypass the failure timeout")
+}
+
+func TestCollectPodSummary(t *testing.T) {
+	g := NewGomegaWithT(t)
+	pod := func(phase corev1.PodPhase, deleting bool) corev1.Pod {
+		p := corev1.Pod{Status: corev1.PodStatus{Phase: phase}}
+		if deleting {
+			p.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+		}
+		return p
+	}
+	s := CollectPodSummary([]corev1.Pod{
+		pod(corev1.PodRunning, false),
+		pod(corev1.PodRunning, false),
+		pod(corev1.PodPending, false),
+		pod(corev1.PodFailed, false),
+		pod(corev1.PodRunning, true),
+		pod(corev1.PodSucceeded, false),
+	})
+	g.Expect(*s).To(Equal(v1alpha1.PodSummary{Running: 2, Pending: 1, Failed: 1, Terminating: 1}))
+
+	s.Add(&v1alpha1.PodSummary{Running: 1, Terminating: 2})
+	s.Add(nil)
+	g.Expect(*s).To(Equal(v1alpha1.PodSummary{Running: 3, Pending: 1, Failed: 1, Terminating: 3}))
+}
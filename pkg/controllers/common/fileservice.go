@@ -15,11 +15,18 @@
 package common
 
 import (
+	"crypto/x509"
 	"fmt"
+	"strings"
+
+	recon "github.com/matrixorigin/controller-runtime/pkg/reconciler"
 	"github.com/matrixorigin/controller-runtime/pkg/util"
 	"github.com/matrixorigin/matrixone-operator/api/core/v1alpha1"
+	"github.com/pkg/errors"
+	"github.com/samber/lo"
 	corev1 "k8s.io/api/core/v1"
-	"strings"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 const (
@@ -39,10 +46,21 @@ const (
 	awsSecretAccessKey = "AWS_SECRET_ACCESS_KEY"
 	awsRegion          = "AWS_REGION"
 	defaultAWSRegion   = "us-west-2"
+
+	// s3CAVolume is the volume name of the CA bundle of the S3 endpoint
+	s3CAVolume = "s3-ca"
+	// s3CAPath is where the CA bundle of the S3 endpoint is mounted
+	s3CAPath   = "/etc/mo/s3-ca"
+	s3CAFile   = "ca.crt"
+	sslCertDir = "SSL_CERT_DIR"
+	// systemCertDir is the directory of the system CAs, which is kept in SSL_CERT_DIR so that the
+	// system CAs are still trusted
+	systemCertDir = "/etc/ssl/certs"
 )
 
 // SetStorageProviderConfig set inject configuration of storage provider to Pods
 func SetStorageProviderConfig(sp v1alpha1.SharedStorageProvider, podSpec *corev1.PodSpec) {
+	setS3CABundle(sp.S3, podSpec)
 	for i := range podSpec.Containers {
 		if s3p := sp.S3; s3p != nil {
 			if s3p.SecretRef != nil {
@@ -64,6 +82,67 @@ func SetStorageProviderConfig(sp v1alpha1.SharedStorageProvider, podSpec *corev1
 	}
 }
 
+// setS3CABundle mounts the CA bundle of the S3 endpoint into the containers, the bundle is
+// loaded by the Go TLS stack of MO through SSL_CERT_DIR along with the system CAs
+func setS3CABundle(s3p *v1alpha1.S3Provider, podSpec *corev1.PodSpec) {
+	if s3p == nil || s3p.CABundleSecretRef == nil {
+		removeVolume(podSpec, s3CAVolume)
+		for i := range podSpec.Containers {
+			c := &podSpec.Containers[i]
+			c.VolumeMounts = lo.Filter(c.VolumeMounts, func(m corev1.VolumeMount, _ int) bool { return m.Name != s3CAVolume })
+			c.Env = lo.Filter(c.Env, func(e corev1.EnvVar, _ int) bool { return e.Name != sslCertDir })
+		}
+		return
+	}
+	ref := s3p.CABundleSecretRef
+	podSpec.Volumes = util.UpsertByKey(podSpec.Volumes, corev1.Volume{
+		Name: s3CAVolume,
+		VolumeSource: corev1.VolumeSource{
+			Secret: &corev1.SecretVolumeSource{
+				SecretName: ref.Name,
+				Items:      []corev1.KeyToPath{{Key: ref.Key, Path: s3CAFile}},
+			},
+		},
+	}, func(v corev1.Volume) string {
+		return v.Name
+	})
+	for i := range podSpec.Containers {
+		c := &podSpec.Containers[i]
+		c.VolumeMounts = util.UpsertByKey(c.VolumeMounts, corev1.VolumeMount{
+			Name:      s3CAVolume,
+			MountPath: s3CAPath,
+			ReadOnly:  true,
+		}, func(m corev1.VolumeMount) string {
+			return m.Name
+		})
+		c.Env = util.UpsertByKey(c.Env, corev1.EnvVar{Name: sslCertDir, Value: systemCertDir + ":" + s3CAPath}, util.EnvVarKey)
+	}
+}
+
+// CheckS3CABundle checks that the CA bundle of the S3 endpoint contains PEM encoded certificates, the
+// Ready condition is set to False if not so that a broken bundle is not silently ignored by the pods
+func CheckS3CABundle[T client.Object](ctx *recon.Context[T], s3p *v1alpha1.S3Provider, status *v1alpha1.ConditionalStatus) error {
+	if s3p == nil || s3p.CABundleSecretRef == nil {
+		return nil
+	}
+	ref := s3p.CABundleSecretRef
+	sec := &corev1.Secret{}
+	if err := ctx.Get(client.ObjectKey{Namespace: ctx.Obj.GetNamespace(), Name: ref.Name}, sec); err != nil {
+		return errors.Wrap(err, "get CA bundle secret of S3")
+	}
+	if x509.NewCertPool().AppendCertsFromPEM(sec.Data[ref.Key]) {
+		return nil
+	}
+	msg := fmt.Sprintf("key %s of secret %s contains no PEM encoded certificate", ref.Key, ref.Name)
+	status.SetCondition(metav1.Condition{
+		Type:    recon.ConditionTypeReady,
+		Status:  metav1.ConditionFalse,
+		Reason:  ReasonInvalidCABundle,
+		Message: msg,
+	})
+	return errors.New(msg)
+}
+
 // FileServiceConfig generate the fileservice config for an MO component
 func FileServiceConfig(localPath string, sp v1alpha1.SharedStorageProvider, v *v1alpha1.Volume, cache *v1alpha1.SharedStorageCache) map[string]interface{} {
 	localFS := map[string]interface{}{
@@ -92,6 +171,25 @@ func FileServiceConfig(localPath string, sp v1alpha1.SharedStorageProvider, v *v
 	}
 }
 
+// EnableRemoteCache enables the remote cache of the shared fileservice in the config generated by
+// FileServiceConfig, so that the data cached by the other CN pods can be read through RPC
+func EnableRemoteCache(conf map[string]interface{}) {
+	fss, _ := conf["fileservice"].([]map[string]interface{})
+	for _, fs := range fss {
+		if fs["name"] != s3FileServiceName {
+			continue
+		}
+		cache := map[string]interface{}{}
+		if c, ok := fs["cache"].(map[string]string); ok {
+			for k, v := range c {
+				cache[k] = v
+			}
+		}
+		cache["remote-cache-enabled"] = true
+		fs["cache"] = cache
+	}
+}
+
 func sharedFileServiceConfig(sp v1alpha1.SharedStorageProvider, cache *v1alpha1.SharedStorageCache, name, subDir string) map[string]interface{} {
 	m := map[string]interface{}{
 		"name": name,
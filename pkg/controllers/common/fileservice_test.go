@@ -138,3 +138,50 @@ func TestFileServiceConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestEnableRemoteCache(t *testing.T) {
+	quantity1Gi := resource.MustParse("1Gi")
+	conf := FileServiceConfig("/test", v1alpha1.SharedStorageProvider{
+		S3: &v1alpha1.S3Provider{Path: "/bucket/prefix"},
+	}, nil, &v1alpha1.SharedStorageCache{MemoryCacheSize: &quantity1Gi})
+	EnableRemoteCache(conf)
+	fss := conf["fileservice"].([]map[string]interface{})
+	if diff := cmp.Diff(map[string]interface{}{
+		"memory-capacity":      "1Gi",
+		"remote-cache-enabled": true,
+	}, fss[1]["cache"]); diff != "" {
+		t.Errorf("EnableRemoteCache(), S3 cache diff:\n %s", diff)
+	}
+	if diff := cmp.Diff(map[string]string{"memory-capacity": "1Gi"}, fss[2]["cache"]); diff != "" {
+		t.Errorf("EnableRemoteCache(), ETL cache diff:\n %s", diff)
+	}
+}
+
+func TestSetStorageProviderConfigCABundle(t *testing.T) {
+	podSpec := &corev1.PodSpec{Containers: []corev1.Container{{Name: v1alpha1.ContainerMain}}}
+	sp := v1alpha1.SharedStorageProvider{S3: &v1alpha1.S3Provider{
+		Path: "bucket",
+		CABundleSecretRef: &corev1.SecretKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{Name: "minio-ca"},
+			Key:                  "bundle.pem",
+		},
+	}}
+	SetStorageProviderConfig(sp, podSpec)
+	if len(podSpec.Volumes) != 1 || podSpec.Volumes[0].Secret.Items[0].Key != "bundle.pem" {
+		t.Errorf("CA bundle volume not set, got %v", podSpec.Volumes)
+	}
+	c := podSpec.Containers[0]
+	if len(c.VolumeMounts) != 1 || c.VolumeMounts[0].MountPath != s3CAPath {
+		t.Errorf("CA bundle not mounted, got %v", c.VolumeMounts)
+	}
+	if !cmp.Equal(c.Env[0], corev1.EnvVar{Name: sslCertDir, Value: "/etc/ssl/certs:/etc/mo/s3-ca"}) {
+		t.Errorf("SSL_CERT_DIR not set, got %v", c.Env)
+	}
+
+	sp.S3.CABundleSecretRef = nil
+	SetStorageProviderConfig(sp, podSpec)
+	c = podSpec.Containers[0]
+	if len(podSpec.Volumes) != 0 || len(c.VolumeMounts) != 0 || len(c.Env) != 1 || c.Env[0].Name != awsRegion {
+		t.Errorf("CA bundle not removed, got volumes %v, container %v", podSpec.Volumes, c)
+	}
+}
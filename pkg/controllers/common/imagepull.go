@@ -0,0 +1,105 @@
+// Copyright 2023 Matrix Origin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/matrixorigin/matrixone-operator/api/core/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// ConditionTypeImagePullSlow indicates whether there are pods still waiting for their images after the image pull deadline
+	ConditionTypeImagePullSlow = "ImagePullSlow"
+	// ConditionTypeImagePullFailed indicates whether there are pods failed to pull their images
+	ConditionTypeImagePullFailed = "ImagePullFailed"
+
+	reasonImagePulled = "ImagePulled"
+	// reasonImagePullPending means the containers are created but their images are not pulled yet
+	reasonImagePullPending = "ImagePullPending"
+)
+
+// imagePullFailureReasons are the waiting reasons of a container reported by the kubelet when the image pull fails
+var imagePullFailureReasons = map[string]bool{
+	"ErrImagePull":      true,
+	"ImagePullBackOff":  true,
+	"InvalidImageName":  true,
+	"ErrImageNeverPull": true,
+}
+
+// CollectImagePullStatus sets the ImagePullSlow and ImagePullFailed conditions according to the
+// waiting state of the containers, which is what the kubelet reports in the pulling events of the pods.
+// A container that is created but has no image ID yet is considered pulling, and is reported slow
+// after its pod has been scheduled for longer than the deadline.
+func CollectImagePullStatus(status *v1alpha1.ConditionalStatus, pods []corev1.Pod, deadline time.Duration) {
+	var slow, failed []string
+	failedReason := ""
+	for i := range pods {
+		pod := &pods[i]
+		if pod.DeletionTimestamp != nil {
+			continue
+		}
+		statuses := append(append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+		for _, cs := range statuses {
+			w := cs.State.Waiting
+			if w == nil {
+				continue
+			}
+			if imagePullFailureReasons[w.Reason] {
+				failed = append(failed, fmt.Sprintf("%s/%s: %s", pod.Name, cs.Name, w.Message))
+				failedReason = w.Reason
+				break
+			}
+			if cs.ImageID == "" && w.Reason == "ContainerCreating" && time.Since(scheduledTime(pod)) > deadline {
+				slow = append(slow, fmt.Sprintf("%s/%s", pod.Name, cs.Name))
+				break
+			}
+		}
+	}
+	status.SetCondition(imagePullCondition(ConditionTypeImagePullFailed, failedReason, failed))
+	status.SetCondition(imagePullCondition(ConditionTypeImagePullSlow, reasonImagePullPending, slow))
+}
+
+func imagePullCondition(conditionType string, reason string, containers []string) metav1.Condition {
+	if len(containers) == 0 {
+		return metav1.Condition{
+			Type:   conditionType,
+			Status: metav1.ConditionFalse,
+			Reason: reasonImagePulled,
+		}
+	}
+	sort.Strings(containers)
+	return metav1.Condition{
+		Type:    conditionType,
+		Status:  metav1.ConditionTrue,
+		Reason:  reason,
+		Message: strings.Join(containers, "; "),
+	}
+}
+
+// scheduledTime returns when the pod was scheduled, or when it was created if not scheduled yet
+func scheduledTime(pod *corev1.Pod) time.Time {
+	for _, c := range pod.Status.Conditions {
+		if c.Type == corev1.PodScheduled && c.Status == corev1.ConditionTrue {
+			return c.LastTransitionTime.Time
+		}
+	}
+	return pod.CreationTimestamp.Time
+}
@@ -0,0 +1,75 @@
+// Copyright 2023 Matrix Origin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"testing"
+	"time"
+
+	"github.com/matrixorigin/matrixone-operator/api/core/v1alpha1"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestCollectImagePullStatus(t *testing.T) {
+	waitingPod := func(name string, reason string, scheduledAgo time.Duration) corev1.Pod {
+		return corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status: corev1.PodStatus{
+				Conditions: []corev1.PodCondition{{
+					Type:               corev1.PodScheduled,
+					Status:             corev1.ConditionTrue,
+					LastTransitionTime: metav1.Time{Time: time.Now().Add(-scheduledAgo)},
+				}},
+				ContainerStatuses: []corev1.ContainerStatus{{
+					Name:  "main",
+					State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: reason}},
+				}},
+			},
+		}
+	}
+	tests := []struct {
+		name       string
+		pods       []corev1.Pod
+		wantSlow   metav1.ConditionStatus
+		wantFailed metav1.ConditionStatus
+	}{{
+		name:       "pulling within deadline",
+		pods:       []corev1.Pod{waitingPod("a", "ContainerCreating", time.Minute)},
+		wantSlow:   metav1.ConditionFalse,
+		wantFailed: metav1.ConditionFalse,
+	}, {
+		name:       "pulling after deadline",
+		pods:       []corev1.Pod{waitingPod("a", "ContainerCreating", time.Hour)},
+		wantSlow:   metav1.ConditionTrue,
+		wantFailed: metav1.ConditionFalse,
+	}, {
+		name:       "pull failed",
+		pods:       []corev1.Pod{waitingPod("a", "ContainerCreating", time.Minute), waitingPod("b", "ImagePullBackOff", time.Minute)},
+		wantSlow:   metav1.ConditionFalse,
+		wantFailed: metav1.ConditionTrue,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			status := &v1alpha1.ConditionalStatus{}
+			CollectImagePullStatus(status, tt.pods, 10*time.Minute)
+			g.Expect(meta.FindStatusCondition(status.Conditions, ConditionTypeImagePullSlow).Status).To(Equal(tt.wantSlow))
+			g.Expect(meta.FindStatusCondition(status.Conditions, ConditionTypeImagePullFailed).Status).To(Equal(tt.wantFailed))
+		})
+	}
+}
@@ -0,0 +1,48 @@
+// Copyright 2023 Matrix Origin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"fmt"
+
+	"github.com/cespare/xxhash"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	// MaxStatefulSetNameLength is the max length of the StatefulSet names, which is limited by
+	// the controller-revision-hash label of the pods of a StatefulSet (63 - len("-") - 10)
+	MaxStatefulSetNameLength = 52
+	// MaxServiceNameLength is the max length of the Service names, which must be DNS-1035 labels
+	MaxServiceNameLength = validation.DNS1035LabelMaxLength
+	// MaxConfigMapNameLength is the max length of the ConfigMap names, which leaves room for the
+	// digest suffix appended to the ConfigMaps that are rolled on change
+	MaxConfigMapNameLength = validation.DNS1123SubdomainMaxLength - 8
+
+	nameHashLength = 8
+)
+
+// ResourceName generates the name of a resource from the base name and suffix, the base name
+// is truncated and appended with its hash only if the full name exceeds the max length of the
+// resource kind. Names that are valid for the kind are never truncated, so that the resources
+// created before the truncation was introduced keep their names.
+func ResourceName(base string, suffix string, maxLength int) string {
+	if len(base)+len(suffix) <= maxLength {
+		return base + suffix
+	}
+	hash := fmt.Sprintf("%016x", xxhash.Sum64String(base))[:nameHashLength]
+	truncated := base[:maxLength-len(suffix)-nameHashLength-1]
+	return fmt.Sprintf("%s-%s%s", truncated, hash, suffix)
+}
@@ -0,0 +1,83 @@
+// Copyright 2023 Matrix Origin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestResourceName(t *testing.T) {
+	long := strings.Repeat("a", 60)
+	tests := []struct {
+		name      string
+		base      string
+		suffix    string
+		maxLength int
+		want      string
+	}{{
+		name:      "short",
+		base:      "test-cn",
+		suffix:    "-headless",
+		maxLength: MaxServiceNameLength,
+		want:      "test-cn-headless",
+	}, {
+		name:      "exactly max length",
+		base:      strings.Repeat("a", 43),
+		suffix:    "",
+		maxLength: MaxStatefulSetNameLength,
+		want:      strings.Repeat("a", 43),
+	}, {
+		name:      "valid service name is kept",
+		base:      strings.Repeat("a", 50),
+		suffix:    "-headless",
+		maxLength: MaxServiceNameLength,
+		want:      strings.Repeat("a", 50) + "-headless",
+	}, {
+		name:      "valid configmap name is kept",
+		base:      long,
+		suffix:    "-config",
+		maxLength: MaxConfigMapNameLength,
+		want:      long + "-config",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ResourceName(tt.base, tt.suffix, tt.maxLength); got != tt.want {
+				t.Errorf("ResourceName() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	for _, suffix := range []string{"", "-headless", "-discovery"} {
+		for _, maxLength := range []int{MaxStatefulSetNameLength, MaxServiceNameLength} {
+			got := ResourceName(long+long, suffix, maxLength)
+			if len(got) > maxLength {
+				t.Errorf("ResourceName() = %v, exceeds max length %d", got, maxLength)
+			}
+			if !strings.HasSuffix(got, suffix) {
+				t.Errorf("ResourceName() = %v, want suffix %v", got, suffix)
+			}
+		}
+	}
+	if got := ResourceName(strings.Repeat(long, 5), "-config", MaxConfigMapNameLength); len(got) > MaxConfigMapNameLength {
+		t.Errorf("ResourceName() = %v, exceeds max length %d", got, MaxConfigMapNameLength)
+	}
+	if ResourceName(long+"-x", "", MaxStatefulSetNameLength) == ResourceName(long+"-y", "", MaxStatefulSetNameLength) {
+		t.Errorf("ResourceName() should not collide for long names sharing the same prefix")
+	}
+	if ResourceName(long, "", MaxStatefulSetNameLength) != ResourceName(long, "", MaxStatefulSetNameLength) {
+		t.Errorf("ResourceName() should be stable")
+	}
+}
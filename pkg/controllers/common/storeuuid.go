@@ -0,0 +1,111 @@
+// Copyright 2023 Matrix Origin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	recon "github.com/matrixorigin/controller-runtime/pkg/reconciler"
+	"github.com/matrixorigin/controller-runtime/pkg/util"
+	"github.com/matrixorigin/matrixone-operator/api/core/v1alpha1"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// StoreType is the type digit embedded in the ordinal-based store UUID by the entrypoint
+type StoreType int
+
+const (
+	StoreTypeLogService StoreType = 0
+	StoreTypeDN         StoreType = 1
+	StoreTypeCN         StoreType = 2
+)
+
+// StoreUUID computes the UUID of the store that runs in the pod the same way as the entrypoint
+// does: from the pod ordinal by default, or from the pod DNS name if the HostnameUUIDEnvKey
+// env is set on the main container
+func StoreUUID(pod *corev1.Pod, t StoreType) (string, error) {
+	var main *corev1.Container
+	for i := range pod.Spec.Containers {
+		if pod.Spec.Containers[i].Name == v1alpha1.ContainerMain {
+			main = &pod.Spec.Containers[i]
+		}
+	}
+	if main == nil {
+		return "", errors.Errorf("main container not found in pod %s", pod.Name)
+	}
+	var hostnameUUID bool
+	var headlessSvc string
+	for _, env := range main.Env {
+		switch env.Name {
+		case HostnameUUIDEnvKey:
+			hostnameUUID = true
+		case HeadlessSvcEnvKey:
+			headlessSvc = env.Value
+		}
+	}
+	if hostnameUUID {
+		return hostnameStoreUUID(fmt.Sprintf("%s.%s.%s.svc", pod.Name, headlessSvc, pod.Namespace)), nil
+	}
+	ordinal, err := util.PodOrdinal(pod.Name)
+	if err != nil {
+		return "", errors.Wrapf(err, "parse ordinal of pod %s", pod.Name)
+	}
+	return fmt.Sprintf("00000000-0000-0000-0000-%d%011x", t, ordinal), nil
+}
+
+// hostnameStoreUUID mirrors `echo ${ADDR} | sha256sum | od -x | head -1`: the first 16 characters of
+// the hex digest are dumped as little-endian 16-bit words and joined in the UUID layout
+func hostnameStoreUUID(addr string) string {
+	sum := sha256.Sum256([]byte(addr + "\n"))
+	digest := hex.EncodeToString(sum[:])
+	words := make([]string, 8)
+	for i := range words {
+		words[i] = fmt.Sprintf("%02x%02x", digest[2*i+1], digest[2*i])
+	}
+	return fmt.Sprintf("%s%s-%s-%s-%s-%s%s%s", words[0], words[1], words[2], words[3], words[4], words[5], words[6], words[7])
+}
+
+// SyncStoreUUIDLabels labels the pods with the UUID of their stores so that the pods can be mapped
+// to the stores reported by MO
+func SyncStoreUUIDLabels[T client.Object](ctx *recon.Context[T], pods []corev1.Pod, t StoreType) error {
+	for i := range pods {
+		pod := &pods[i]
+		if pod.DeletionTimestamp != nil {
+			continue
+		}
+		uuid, err := StoreUUID(pod, t)
+		if err != nil {
+			ctx.Log.Info("skip labeling the store uuid", "pod", pod.Name, "error", err.Error())
+			continue
+		}
+		if pod.Labels[StoreUUIDLabelKey] == uuid {
+			continue
+		}
+		if err := ctx.Patch(pod, func() error {
+			if pod.Labels == nil {
+				pod.Labels = map[string]string{}
+			}
+			pod.Labels[StoreUUIDLabelKey] = uuid
+			return nil
+		}); err != nil {
+			return errors.Wrapf(err, "label store uuid of pod %s", pod.Name)
+		}
+	}
+	return nil
+}
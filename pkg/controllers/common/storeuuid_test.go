@@ -0,0 +1,70 @@
+// Copyright 2023 Matrix Origin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"testing"
+
+	"github.com/matrixorigin/matrixone-operator/api/core/v1alpha1"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestStoreUUID(t *testing.T) {
+	pod := func(env ...corev1.EnvVar) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-dn-12", Namespace: "default"},
+			Spec: corev1.PodSpec{Containers: []corev1.Container{{
+				Name: v1alpha1.ContainerMain,
+				Env:  append([]corev1.EnvVar{{Name: HeadlessSvcEnvKey, Value: "test-dn-headless"}}, env...),
+			}}},
+		}
+	}
+	tests := []struct {
+		name    string
+		pod     *corev1.Pod
+		t       StoreType
+		want    string
+		wantErr bool
+	}{{
+		name: "ordinal",
+		pod:  pod(),
+		t:    StoreTypeDN,
+		want: "00000000-0000-0000-0000-10000000000c",
+	}, {
+		// echo test-dn-12.test-dn-headless.default.svc | sha256sum | od -x | head -1 | awk '{OFS="-"; print $2$3,$4,$5,$6,$7$8$9}'
+		name: "hostname",
+		pod:  pod(corev1.EnvVar{Name: HostnameUUIDEnvKey, Value: "y"}),
+		t:    StoreTypeDN,
+		want: "62366139-3230-3036-3631-643966656161",
+	}, {
+		name:    "no main container",
+		pod:     &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "test-dn-0"}},
+		wantErr: true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			uuid, err := StoreUUID(tt.pod, tt.t)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).To(Succeed())
+			g.Expect(uuid).To(Equal(tt.want))
+		})
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright 2023 Matrix Origin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"time"
+
+	recon "github.com/matrixorigin/controller-runtime/pkg/reconciler"
+	"github.com/matrixorigin/matrixone-operator/api/core/v1alpha1"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ForceDeleteStuckPods force-deletes the pods that have been terminating for longer than the timeout
+// of the policy on a node that is gone or unreachable, so that the StatefulSet can recreate them.
+// A node that is NotReady but still reporting is not considered unreachable, the kubelet on it is
+// still able to terminate the pods.
+func ForceDeleteStuckPods[T client.Object](ctx *recon.Context[T], p *v1alpha1.StuckPodPolicy, pods []corev1.Pod) error {
+	if p == nil {
+		return nil
+	}
+	timeout := p.GetTimeout()
+	now := time.Now()
+	for i := range pods {
+		pod := &pods[i]
+		if !isStuckTerminating(pod, timeout, now) {
+			continue
+		}
+		node := &corev1.Node{}
+		err := ctx.Get(client.ObjectKey{Name: pod.Spec.NodeName}, node)
+		if err != nil && !apierrors.IsNotFound(err) {
+			// best effort, the operator may not be permitted to read the nodes
+			ctx.Log.Error(err, "failed to get the node of the stuck pod", "pod", pod.Name, "node", pod.Spec.NodeName)
+			continue
+		}
+		if err == nil && !isNodeUnreachable(node, timeout, now) {
+			continue
+		}
+		ctx.Log.Info("force delete stuck pod", "pod", pod.Name, "node", pod.Spec.NodeName)
+		if err := ctx.Delete(pod, client.GracePeriodSeconds(0)); err != nil && !apierrors.IsNotFound(err) {
+			return errors.Wrapf(err, "force delete stuck pod %s", pod.Name)
+		}
+		EmitStuckPodDeletedEvent(ctx.Event, pod.Name, pod.Spec.NodeName)
+	}
+	return nil
+}
+
+// isStuckTerminating returns whether the pod is still not removed after the grace period plus the timeout,
+// the deletion timestamp of a pod is already postponed by its grace period
+func isStuckTerminating(pod *corev1.Pod, timeout time.Duration, now time.Time) bool {
+	if pod.DeletionTimestamp == nil || pod.Spec.NodeName == "" {
+		return false
+	}
+	return now.Sub(pod.DeletionTimestamp.Time) > timeout
+}
+
+// isNodeUnreachable returns whether the kubelet on the node has stopped reporting for longer than the timeout
+func isNodeUnreachable(node *corev1.Node, timeout time.Duration, now time.Time) bool {
+	for _, c := range node.Status.Conditions {
+		if c.Type == corev1.NodeReady {
+			return c.Status == corev1.ConditionUnknown && now.Sub(c.LastTransitionTime.Time) > timeout
+		}
+	}
+	return false
+}
@@ -0,0 +1,78 @@
+// Copyright 2023 Matrix Origin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestIsStuckTerminating(t *testing.T) {
+	g := NewGomegaWithT(t)
+	now := time.Now()
+	pod := &corev1.Pod{Spec: corev1.PodSpec{NodeName: "node-1"}}
+	g.Expect(isStuckTerminating(pod, time.Minute, now)).To(BeFalse())
+
+	pod.DeletionTimestamp = &metav1.Time{Time: now.Add(-30 * time.Second)}
+	g.Expect(isStuckTerminating(pod, time.Minute, now)).To(BeFalse())
+
+	pod.DeletionTimestamp = &metav1.Time{Time: now.Add(-2 * time.Minute)}
+	g.Expect(isStuckTerminating(pod, time.Minute, now)).To(BeTrue())
+
+	pod.Spec.NodeName = ""
+	g.Expect(isStuckTerminating(pod, time.Minute, now)).To(BeFalse())
+}
+
+func TestIsNodeUnreachable(t *testing.T) {
+	now := time.Now()
+	newNode := func(status corev1.ConditionStatus, since time.Duration) *corev1.Node {
+		return &corev1.Node{Status: corev1.NodeStatus{Conditions: []corev1.NodeCondition{{
+			Type:               corev1.NodeReady,
+			Status:             status,
+			LastTransitionTime: metav1.Time{Time: now.Add(-since)},
+		}}}}
+	}
+	tests := []struct {
+		name string
+		node *corev1.Node
+		want bool
+	}{{
+		name: "ready",
+		node: newNode(corev1.ConditionTrue, time.Hour),
+	}, {
+		name: "not ready but reporting",
+		node: newNode(corev1.ConditionFalse, time.Hour),
+	}, {
+		name: "transient unknown",
+		node: newNode(corev1.ConditionUnknown, 30*time.Second),
+	}, {
+		name: "unreachable",
+		node: newNode(corev1.ConditionUnknown, 10*time.Minute),
+		want: true,
+	}, {
+		name: "no ready condition",
+		node: &corev1.Node{},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			g.Expect(isNodeUnreachable(tt.node, 5*time.Minute, now)).To(Equal(tt.want))
+		})
+	}
+}
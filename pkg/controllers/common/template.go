@@ -15,11 +15,19 @@
 package common
 
 import (
+	"strings"
+
+	recon "github.com/matrixorigin/controller-runtime/pkg/reconciler"
+	"github.com/matrixorigin/controller-runtime/pkg/util"
+	"github.com/matrixorigin/matrixone-operator/api/core/v1alpha1"
+	"github.com/openkruise/kruise-api/apps/pub"
 	kruise "github.com/openkruise/kruise-api/apps/v1beta1"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -30,6 +38,12 @@ const (
 	DataPath = "/var/lib/matrixone"
 	// DataDir is the directory under data path that will be used to store the data of mo disk backend
 	DataDir = "data"
+	// NetworksAnnotation is the Multus annotation that attaches the secondary networks to a pod
+	NetworksAnnotation = "k8s.v1.cni.cncf.io/networks"
+	// tmpVolume is the volume name of the tmp volume
+	tmpVolume = "mo-tmp"
+	// tmpPath is the path where the tmp volume will be mounted to
+	tmpPath = "/tmp"
 
 	// InstanceLabelKey labels the cluster instance name of the resource
 	InstanceLabelKey = "matrixorigin.io/instance"
@@ -43,6 +57,11 @@ const (
 	ActionRequiredLabelValue = "True"
 	// LogSetOwnerKey labels the owner of orphaned LogSet Pod that is left by failover
 	LogSetOwnerKey = "matrixorigin.io/logset-owner"
+	// CommonLabelsAnnotation lists the keys of the labels of the set that are inherited by the
+	// resources of the set, the keys are separated by comma
+	CommonLabelsAnnotation = "matrixorigin.io/common-labels"
+	// StoreUUIDLabelKey labels the pod with the UUID of the MO store that runs in the pod
+	StoreUUIDLabelKey = "matrixorigin.io/store-uuid"
 
 	// PodNameEnvKey is the container environment variable to reflect the name of the Pod that runs the container
 	PodNameEnvKey = "POD_NAME"
@@ -50,6 +69,15 @@ const (
 	HeadlessSvcEnvKey = "HEADLESS_SERVICE_NAME"
 	// NamespaceEnvKey  is the container environment variable to reflect the namespace of the Pod that runs the container
 	NamespaceEnvKey = "NAMESPACE"
+	// HostnameUUIDEnvKey is the container environment variable to derive the store UUID from the pod DNS name
+	HostnameUUIDEnvKey = "HOSTNAME_UUID"
+
+	// antiAffinityWeight is the weight of the preferred pod anti-affinity term
+	antiAffinityWeight = 100
+
+	// probeTimeoutSeconds and probeSuccessThreshold are the defaults of the API server
+	probeTimeoutSeconds   = 1
+	probeSuccessThreshold = 1
 )
 
 // SubResourceLabels generate labels for sub-resources
@@ -61,33 +89,289 @@ func SubResourceLabels(owner client.Object) map[string]string {
 	}
 }
 
-// SyncTopology syncs the topology even spread of PodSet to the underlying pods
-func SyncTopology(domains []string, podSpec *corev1.PodSpec) {
+// ResourceLabels generate labels for the metadata of sub-resources, which include the labels
+// inherited from the owner. Use SubResourceLabels instead for selectors.
+func ResourceLabels(owner client.Object) map[string]string {
+	labels := InheritedLabels(owner)
+	for k, v := range SubResourceLabels(owner) {
+		labels[k] = v
+	}
+	return labels
+}
+
+// InheritedLabels returns the labels of the owner that are listed in the CommonLabelsAnnotation
+func InheritedLabels(owner client.Object) map[string]string {
+	labels := map[string]string{}
+	keys := owner.GetAnnotations()[CommonLabelsAnnotation]
+	if keys == "" {
+		return labels
+	}
+	for _, k := range strings.Split(keys, ",") {
+		if v, ok := owner.GetLabels()[k]; ok {
+			labels[k] = v
+		}
+	}
+	return labels
+}
+
+// DataDirName returns the name of the directory under the data volume that stores the local data
+func DataDirName(ps *v1alpha1.PodSet) string {
+	if ps.DataDir != "" {
+		return ps.DataDir
+	}
+	return DataDir
+}
+
+// SyncTopology syncs the topology even spread of PodSet to the underlying pods, the pods of the
+// set are selected by the constraints if the spread policy is set
+func SyncTopology(domains []string, policy *v1alpha1.TopologySpreadPolicy, obj client.Object, podSpec *corev1.PodSpec) {
 	var constraints []corev1.TopologySpreadConstraint
 	for _, domain := range domains {
-		constraints = append(constraints, corev1.TopologySpreadConstraint{
+		c := corev1.TopologySpreadConstraint{
 			MaxSkew:           1,
 			TopologyKey:       domain,
 			WhenUnsatisfiable: corev1.DoNotSchedule,
-		})
+		}
+		if policy != nil {
+			c.LabelSelector = &metav1.LabelSelector{MatchLabels: SubResourceLabels(obj)}
+			c.MinDomains = policy.MinDomains
+			if policy.WhenUnsatisfiable != "" {
+				c.WhenUnsatisfiable = policy.WhenUnsatisfiable
+			}
+		}
+		constraints = append(constraints, c)
 	}
 	podSpec.TopologySpreadConstraints = constraints
 }
 
-// HeadlessServiceTemplate returns a headless service as template
+// TCPProbe returns a probe on the TCP port, the fields that are defaulted by the API server are set
+// explicitly so that the generated probe does not differ from the persisted one
+func TCPProbe(port int, periodSeconds int32, failureThreshold int32) *corev1.Probe {
+	return &corev1.Probe{
+		ProbeHandler: corev1.ProbeHandler{
+			TCPSocket: &corev1.TCPSocketAction{Port: intstr.FromInt(port)},
+		},
+		TimeoutSeconds:   probeTimeoutSeconds,
+		PeriodSeconds:    periodSeconds,
+		SuccessThreshold: probeSuccessThreshold,
+		FailureThreshold: failureThreshold,
+	}
+}
+
+// AntiAffinityPolicyOf returns the anti-affinity policy to sync for the set. The policy defaults to
+// Preferred for a new pod template, while an existing template keeps the anti-affinity it was generated
+// with if the policy is not specified, so that upgrading the operator does not roll the pods of the
+// sets created before the anti-affinity is generated by default
+func AntiAffinityPolicyOf(ps *v1alpha1.PodSet, obj client.Object, podSpec *corev1.PodSpec, existing bool) v1alpha1.AntiAffinityPolicy {
+	if ps.AntiAffinityPolicy != "" || !existing {
+		return ps.GetAntiAffinityPolicy(v1alpha1.AntiAffinityPolicyPreferred)
+	}
+	if podSpec.Affinity == nil || podSpec.Affinity.PodAntiAffinity == nil {
+		return v1alpha1.AntiAffinityPolicyNone
+	}
+	term := antiAffinityTerm(obj)
+	anti := podSpec.Affinity.PodAntiAffinity
+	for _, t := range anti.RequiredDuringSchedulingIgnoredDuringExecution {
+		if equality.Semantic.DeepEqual(t, term) {
+			return v1alpha1.AntiAffinityPolicyRequired
+		}
+	}
+	for _, t := range anti.PreferredDuringSchedulingIgnoredDuringExecution {
+		if equality.Semantic.DeepEqual(t.PodAffinityTerm, term) {
+			return v1alpha1.AntiAffinityPolicyPreferred
+		}
+	}
+	return v1alpha1.AntiAffinityPolicyNone
+}
+
+// SyncAntiAffinity syncs the pod anti-affinity among the pods of the set at node granularity,
+// which will be merged with the affinity of the overlay
+func SyncAntiAffinity(policy v1alpha1.AntiAffinityPolicy, obj client.Object, podSpec *corev1.PodSpec) {
+	term := antiAffinityTerm(obj)
+	switch policy {
+	case v1alpha1.AntiAffinityPolicyRequired:
+		podSpec.Affinity = &corev1.Affinity{PodAntiAffinity: &corev1.PodAntiAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{term},
+		}}
+	case v1alpha1.AntiAffinityPolicyPreferred:
+		podSpec.Affinity = &corev1.Affinity{PodAntiAffinity: &corev1.PodAntiAffinity{
+			PreferredDuringSchedulingIgnoredDuringExecution: []corev1.WeightedPodAffinityTerm{{
+				Weight:          antiAffinityWeight,
+				PodAffinityTerm: term,
+			}},
+		}}
+	default:
+		podSpec.Affinity = nil
+	}
+}
+
+func antiAffinityTerm(obj client.Object) corev1.PodAffinityTerm {
+	return corev1.PodAffinityTerm{
+		LabelSelector: &metav1.LabelSelector{
+			MatchLabels: SubResourceLabels(obj),
+		},
+		TopologyKey: corev1.LabelHostname,
+	}
+}
+
+// SyncSysctls syncs the sysctls of PodSet to the security context of the underlying pods
+func SyncSysctls(sysctls []corev1.Sysctl, podSpec *corev1.PodSpec) {
+	if len(sysctls) == 0 {
+		if podSpec.SecurityContext != nil {
+			podSpec.SecurityContext.Sysctls = nil
+		}
+		return
+	}
+	if podSpec.SecurityContext == nil {
+		podSpec.SecurityContext = &corev1.PodSecurityContext{}
+	}
+	podSpec.SecurityContext.Sysctls = sysctls
+}
+
+// SyncContainerSecurityContext syncs the hardened security context of PodSet to the main container,
+// it must be called before the overlay is applied so that the overlay takes precedence
+func SyncContainerSecurityContext(sc *v1alpha1.ContainerSecurityContext, c *corev1.Container) {
+	c.SecurityContext = sc.SecurityContext()
+}
+
+// SyncTmpVolume mounts the tmp volume of PodSet at /tmp of the main container, it must be called
+// after the containers and the volumes of the pod spec are set
+func SyncTmpVolume(tv *v1alpha1.TmpVolume, podSpec *corev1.PodSpec) {
+	if tv == nil {
+		removeVolume(podSpec, tmpVolume)
+		return
+	}
+	podSpec.Volumes = util.UpsertByKey(podSpec.Volumes, corev1.Volume{
+		Name: tmpVolume,
+		VolumeSource: corev1.VolumeSource{
+			EmptyDir: &corev1.EmptyDirVolumeSource{
+				Medium:    tv.Medium,
+				SizeLimit: tv.SizeLimit,
+			},
+		},
+	}, func(v corev1.Volume) string {
+		return v.Name
+	})
+	for i := range podSpec.Containers {
+		c := &podSpec.Containers[i]
+		if c.Name == v1alpha1.ContainerMain {
+			c.VolumeMounts = util.UpsertByKey(c.VolumeMounts, corev1.VolumeMount{
+				Name:      tmpVolume,
+				MountPath: tmpPath,
+			}, func(m corev1.VolumeMount) string {
+				return m.Name
+			})
+		}
+	}
+}
+
+// SyncNetworkAttachment annotates the pod template to attach the secondary network by Multus, it
+// must be called before the overlay so that the pod annotations of the overlay take precedence
+func SyncNetworkAttachment(na *v1alpha1.NetworkAttachment, meta *metav1.ObjectMeta) {
+	if na == nil {
+		delete(meta.Annotations, NetworksAnnotation)
+		return
+	}
+	if meta.Annotations == nil {
+		meta.Annotations = map[string]string{}
+	}
+	meta.Annotations[NetworksAnnotation] = na.Name + "@" + na.GetInterface()
+}
+
+// NetworkInterface returns the network interface that MO advertises its address on, empty
+// means the pod DNS name is advertised
+func NetworkInterface(na *v1alpha1.NetworkAttachment) string {
+	if na == nil {
+		return ""
+	}
+	return na.GetInterface()
+}
+
+// SyncEphemeralStorage sets the ephemeral storage request and limit of PodSet to the main container
+// unless they are already set, and bounds the tmp volume on the node storage by the limit. It must be
+// called after the overlay and the tmp volume are applied.
+func SyncEphemeralStorage(es *v1alpha1.EphemeralStorage, podSpec *corev1.PodSpec) {
+	if es == nil {
+		return
+	}
+	for i := range podSpec.Containers {
+		c := &podSpec.Containers[i]
+		if c.Name != v1alpha1.ContainerMain {
+			continue
+		}
+		// the resources may share the maps with the spec, copy before modifying
+		c.Resources = *c.Resources.DeepCopy()
+		if es.Request != nil {
+			c.Resources.Requests = withDefaultResource(c.Resources.Requests, corev1.ResourceEphemeralStorage, *es.Request)
+		}
+		if es.Limit != nil {
+			c.Resources.Limits = withDefaultResource(c.Resources.Limits, corev1.ResourceEphemeralStorage, *es.Limit)
+		}
+	}
+	if es.Limit == nil {
+		return
+	}
+	for i := range podSpec.Volumes {
+		v := &podSpec.Volumes[i]
+		if v.Name == tmpVolume && v.EmptyDir != nil && v.EmptyDir.Medium == corev1.StorageMediumDefault && v.EmptyDir.SizeLimit == nil {
+			limit := es.Limit.DeepCopy()
+			v.EmptyDir.SizeLimit = &limit
+		}
+	}
+}
+
+func withDefaultResource(l corev1.ResourceList, name corev1.ResourceName, q resource.Quantity) corev1.ResourceList {
+	if _, ok := l[name]; ok {
+		return l
+	}
+	if l == nil {
+		l = corev1.ResourceList{}
+	}
+	l[name] = q.DeepCopy()
+	return l
+}
+
+// HeadlessServiceTemplate returns a headless service as template, the networking of the service
+// is customized by the headless service policy of the set
 // https://kubernetes.io/docs/concepts/services-networking/service/#headless-services
-func HeadlessServiceTemplate(obj client.Object, name string) *corev1.Service {
-	return &corev1.Service{
+func HeadlessServiceTemplate(obj client.Object, name string, ps *v1alpha1.PodSet) *corev1.Service {
+	svc := &corev1.Service{
 		ObjectMeta: ObjMetaTemplate(obj, name),
 		Spec: corev1.ServiceSpec{
-			ClusterIP: corev1.ClusterIPNone,
+			ClusterIP: ps.GetHeadlessClusterIP(),
 			Selector:  SubResourceLabels(obj),
 			// Need to propagate SRV DNS records for the sts Pods
 			// for the purpose of peer discovery
-			PublishNotReadyAddresses: true,
+			PublishNotReadyAddresses: ps.GetPublishNotReadyAddresses(),
 		},
 	}
+	if ps.HeadlessService != nil {
+		svc.Spec.IPFamilyPolicy = ps.HeadlessService.IPFamilyPolicy
+	}
+	return svc
+}
 
+// SyncHeadlessService syncs the mutable fields of the desired headless service to the existing one,
+// the service will be created by the owner if it does not exist
+func SyncHeadlessService(kubeCli recon.KubeClient, desired *corev1.Service) error {
+	svc := &corev1.Service{}
+	err, found := util.IsFound(kubeCli.Get(client.ObjectKeyFromObject(desired), svc))
+	if err != nil || !found {
+		return err
+	}
+	// the IP family policy is left to the cluster defaulting if not specified
+	ipFamilyPolicySynced := desired.Spec.IPFamilyPolicy == nil ||
+		(svc.Spec.IPFamilyPolicy != nil && *svc.Spec.IPFamilyPolicy == *desired.Spec.IPFamilyPolicy)
+	if svc.Spec.PublishNotReadyAddresses == desired.Spec.PublishNotReadyAddresses && ipFamilyPolicySynced {
+		return nil
+	}
+	return kubeCli.Patch(svc, func() error {
+		svc.Spec.PublishNotReadyAddresses = desired.Spec.PublishNotReadyAddresses
+		if desired.Spec.IPFamilyPolicy != nil {
+			svc.Spec.IPFamilyPolicy = desired.Spec.IPFamilyPolicy
+		}
+		return nil
+	})
 }
 
 // ObjMetaTemplate get object metadata
@@ -96,7 +380,7 @@ func ObjMetaTemplate[T client.Object](obj T, name string) metav1.ObjectMeta {
 		Name:        name,
 		Namespace:   obj.GetNamespace(),
 		Annotations: map[string]string{},
-		Labels:      SubResourceLabels(obj),
+		Labels:      ResourceLabels(obj),
 	}
 }
 
@@ -130,6 +414,54 @@ func StatefulSetTemplate(obj client.Object, name string, svcName string) *kruise
 	}
 }
 
+// SyncUpdateStrategy syncs the update strategy of the statefulset, default to RollingUpdate
+// with the InPlaceIfPossible pod update policy. The rolling update parameters are cleared under
+// the OnDelete strategy since they take no effect.
+func SyncUpdateStrategy(ps *v1alpha1.PodSet, sts *kruise.StatefulSet) {
+	t := ps.UpdateStrategyType
+	if t == "" {
+		t = appsv1.RollingUpdateStatefulSetStrategyType
+	}
+	sts.Spec.UpdateStrategy.Type = t
+	if t != appsv1.RollingUpdateStatefulSetStrategyType {
+		sts.Spec.UpdateStrategy.RollingUpdate = nil
+		return
+	}
+	policy := kruise.InPlaceIfPossiblePodUpdateStrategyType
+	if ps.PodUpdatePolicy != "" {
+		policy = kruise.PodUpdateStrategyType(ps.PodUpdatePolicy)
+	}
+	if sts.Spec.UpdateStrategy.RollingUpdate == nil {
+		sts.Spec.UpdateStrategy.RollingUpdate = &kruise.RollingUpdateStatefulSetStrategy{}
+	}
+	sts.Spec.UpdateStrategy.RollingUpdate.PodUpdatePolicy = policy
+}
+
+// SyncRevisionHistoryLimit syncs the revision history limit of the statefulset
+func SyncRevisionHistoryLimit(ps *v1alpha1.PodSet, sts *kruise.StatefulSet) {
+	sts.Spec.RevisionHistoryLimit = ps.RevisionHistoryLimit
+}
+
+// SyncReadinessGates gates the readiness of the pods by the InPlaceUpdateReady condition, which is
+// maintained by kruise during the in-place updates and by the drain. The InPlaceUpdateReady gate is
+// omitted if the pods are always recreated, so that the pods are not held not ready by kruise for
+// nothing, the pods of the drainable sets are gated by the ServingCondition maintained by the drain
+// instead.
+func SyncReadinessGates(ps *v1alpha1.PodSet, podSpec *corev1.PodSpec, drainable bool) {
+	switch {
+	case ps.PodUpdatePolicy != v1alpha1.PodUpdatePolicyReCreate:
+		podSpec.ReadinessGates = []corev1.PodReadinessGate{{
+			ConditionType: pub.InPlaceUpdateReady,
+		}}
+	case drainable:
+		podSpec.ReadinessGates = []corev1.PodReadinessGate{{
+			ConditionType: ServingCondition,
+		}}
+	default:
+		podSpec.ReadinessGates = nil
+	}
+}
+
 // DeploymentTemplate return a deployment as template
 func DeploymentTemplate(obj client.Object, name string) *appsv1.Deployment {
 	return &appsv1.Deployment{
@@ -148,11 +480,44 @@ func DeploymentTemplate(obj client.Object, name string) *appsv1.Deployment {
 	}
 }
 
+// ResolvedImage returns the image of the main container in the given pod template
+func ResolvedImage(tpl *corev1.PodTemplateSpec) string {
+	c := util.FindFirst(tpl.Spec.Containers, func(c corev1.Container) bool {
+		return c.Name == v1alpha1.ContainerMain
+	})
+	if c == nil {
+		return ""
+	}
+	return c.Image
+}
+
+// SyncVolumeMetadata adds the volume metadata of the set to the persistent volume claim templates
+func SyncVolumeMetadata(m *v1alpha1.VolumeMetadata, tpls []corev1.PersistentVolumeClaim) {
+	if m == nil {
+		return
+	}
+	for i := range tpls {
+		tpl := &tpls[i]
+		if len(m.Labels) > 0 && tpl.Labels == nil {
+			tpl.Labels = map[string]string{}
+		}
+		for k, v := range m.Labels {
+			tpl.Labels[k] = v
+		}
+		if len(m.Annotations) > 0 && tpl.Annotations == nil {
+			tpl.Annotations = map[string]string{}
+		}
+		for k, v := range m.Annotations {
+			tpl.Annotations[k] = v
+		}
+	}
+}
+
 // PersistentVolumeClaimTemplate returns a persistent volume claim object
 func PersistentVolumeClaimTemplate(size resource.Quantity, sc *string, name string) corev1.PersistentVolumeClaim {
 	return corev1.PersistentVolumeClaim{
 		ObjectMeta: metav1.ObjectMeta{
-			Name: DataVolume,
+			Name: name,
 		},
 		Spec: corev1.PersistentVolumeClaimSpec{
 			AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
@@ -0,0 +1,146 @@
+// Copyright 2023 Matrix Origin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"testing"
+
+	"github.com/matrixorigin/matrixone-operator/api/core/v1alpha1"
+	. "github.com/onsi/gomega"
+	"github.com/openkruise/kruise-api/apps/pub"
+	kruise "github.com/openkruise/kruise-api/apps/v1beta1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+)
+
+func TestSyncTopology(t *testing.T) {
+	g := NewGomegaWithT(t)
+	cn := &v1alpha1.CNSet{
+		TypeMeta:   metav1.TypeMeta{Kind: "CNSet"},
+		ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"},
+	}
+	podSpec := &corev1.PodSpec{}
+	SyncTopology([]string{corev1.LabelTopologyZone}, nil, cn, podSpec)
+	g.Expect(podSpec.TopologySpreadConstraints).To(Equal([]corev1.TopologySpreadConstraint{{
+		MaxSkew:           1,
+		TopologyKey:       corev1.LabelTopologyZone,
+		WhenUnsatisfiable: corev1.DoNotSchedule,
+	}}))
+
+	SyncTopology([]string{corev1.LabelTopologyZone}, &v1alpha1.TopologySpreadPolicy{MinDomains: pointer.Int32(3)}, cn, podSpec)
+	g.Expect(podSpec.TopologySpreadConstraints).To(Equal([]corev1.TopologySpreadConstraint{{
+		MaxSkew:           1,
+		TopologyKey:       corev1.LabelTopologyZone,
+		WhenUnsatisfiable: corev1.DoNotSchedule,
+		LabelSelector:     &metav1.LabelSelector{MatchLabels: SubResourceLabels(cn)},
+		MinDomains:        pointer.Int32(3),
+	}}))
+
+	SyncTopology(nil, &v1alpha1.TopologySpreadPolicy{MinDomains: pointer.Int32(3)}, cn, podSpec)
+	g.Expect(podSpec.TopologySpreadConstraints).To(BeEmpty())
+}
+
+func TestSyncEphemeralStorage(t *testing.T) {
+	g := NewGomegaWithT(t)
+	request := resource.MustParse("1Gi")
+	limit := resource.MustParse("4Gi")
+	resources := corev1.ResourceRequirements{
+		Limits: corev1.ResourceList{corev1.ResourceEphemeralStorage: resource.MustParse("8Gi")},
+	}
+	podSpec := &corev1.PodSpec{
+		Containers: []corev1.Container{{Name: v1alpha1.ContainerMain, Resources: resources}},
+	}
+	SyncTmpVolume(&v1alpha1.TmpVolume{}, podSpec)
+	SyncEphemeralStorage(&v1alpha1.EphemeralStorage{Request: &request, Limit: &limit}, podSpec)
+
+	main := podSpec.Containers[0]
+	g.Expect(main.Resources.Requests.StorageEphemeral().String()).To(Equal("1Gi"))
+	g.Expect(main.Resources.Limits.StorageEphemeral().String()).To(Equal("8Gi"), "the limit in resources takes precedence")
+	g.Expect(resources.Requests).To(BeNil(), "the resources of the spec must not be modified")
+	g.Expect(podSpec.Volumes[0].EmptyDir.SizeLimit.String()).To(Equal("4Gi"))
+}
+
+func TestSyncReadinessGates(t *testing.T) {
+	g := NewGomegaWithT(t)
+	gates := []corev1.PodReadinessGate{{ConditionType: pub.InPlaceUpdateReady}}
+	podSpec := &corev1.PodSpec{}
+	for _, policy := range []v1alpha1.PodUpdatePolicy{"", v1alpha1.PodUpdatePolicyInPlaceIfPossible, v1alpha1.PodUpdatePolicyInPlaceOnly} {
+		for _, drainable := range []bool{true, false} {
+			SyncReadinessGates(&v1alpha1.PodSet{PodUpdatePolicy: policy}, podSpec, drainable)
+			g.Expect(podSpec.ReadinessGates).To(Equal(gates), "policy %q", policy)
+		}
+	}
+
+	// the drain still needs a gate to mark the pods not ready when the pods are always recreated
+	SyncReadinessGates(&v1alpha1.PodSet{PodUpdatePolicy: v1alpha1.PodUpdatePolicyReCreate}, podSpec, true)
+	g.Expect(podSpec.ReadinessGates).To(Equal([]corev1.PodReadinessGate{{ConditionType: ServingCondition}}))
+
+	SyncReadinessGates(&v1alpha1.PodSet{PodUpdatePolicy: v1alpha1.PodUpdatePolicyReCreate}, podSpec, false)
+	g.Expect(podSpec.ReadinessGates).To(BeEmpty())
+}
+
+func TestSyncUpdateStrategy(t *testing.T) {
+	g := NewGomegaWithT(t)
+	sts := StatefulSetTemplate(&v1alpha1.CNSet{}, "test", "test-headless")
+
+	SyncUpdateStrategy(&v1alpha1.PodSet{}, sts)
+	g.Expect(sts.Spec.UpdateStrategy.Type).To(Equal(appsv1.RollingUpdateStatefulSetStrategyType))
+	g.Expect(sts.Spec.UpdateStrategy.RollingUpdate.PodUpdatePolicy).To(Equal(kruise.InPlaceIfPossiblePodUpdateStrategyType))
+
+	SyncUpdateStrategy(&v1alpha1.PodSet{PodUpdatePolicy: v1alpha1.PodUpdatePolicyReCreate}, sts)
+	g.Expect(sts.Spec.UpdateStrategy.RollingUpdate.PodUpdatePolicy).To(Equal(kruise.RecreatePodUpdateStrategyType))
+
+	// the rolling update parameters take no effect under OnDelete
+	SyncUpdateStrategy(&v1alpha1.PodSet{UpdateStrategyType: appsv1.OnDeleteStatefulSetStrategyType}, sts)
+	g.Expect(sts.Spec.UpdateStrategy.Type).To(Equal(appsv1.OnDeleteStatefulSetStrategyType))
+	g.Expect(sts.Spec.UpdateStrategy.RollingUpdate).To(BeNil())
+
+	SyncUpdateStrategy(&v1alpha1.PodSet{UpdateStrategyType: appsv1.RollingUpdateStatefulSetStrategyType}, sts)
+	g.Expect(sts.Spec.UpdateStrategy.Type).To(Equal(appsv1.RollingUpdateStatefulSetStrategyType))
+	g.Expect(sts.Spec.UpdateStrategy.RollingUpdate.PodUpdatePolicy).To(Equal(kruise.InPlaceIfPossiblePodUpdateStrategyType))
+}
+
+func TestSyncRevisionHistoryLimit(t *testing.T) {
+	g := NewGomegaWithT(t)
+	sts := StatefulSetTemplate(&v1alpha1.CNSet{}, "test", "test-headless")
+	SyncRevisionHistoryLimit(&v1alpha1.PodSet{RevisionHistoryLimit: pointer.Int32(3)}, sts)
+	g.Expect(sts.Spec.RevisionHistoryLimit).To(Equal(pointer.Int32(3)))
+	SyncRevisionHistoryLimit(&v1alpha1.PodSet{}, sts)
+	g.Expect(sts.Spec.RevisionHistoryLimit).To(BeNil())
+}
+
+func TestAntiAffinityPolicyOf(t *testing.T) {
+	g := NewGomegaWithT(t)
+	dn := &v1alpha1.DNSet{
+		TypeMeta:   metav1.TypeMeta{Kind: "DNSet"},
+		ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"},
+	}
+	ps := &dn.Spec.PodSet
+	podSpec := &corev1.PodSpec{}
+	g.Expect(AntiAffinityPolicyOf(ps, dn, podSpec, false)).To(Equal(v1alpha1.AntiAffinityPolicyPreferred))
+	g.Expect(AntiAffinityPolicyOf(ps, dn, podSpec, true)).To(Equal(v1alpha1.AntiAffinityPolicyNone),
+		"the existing template without anti-affinity must not be changed")
+
+	for _, policy := range []v1alpha1.AntiAffinityPolicy{v1alpha1.AntiAffinityPolicyRequired, v1alpha1.AntiAffinityPolicyPreferred} {
+		SyncAntiAffinity(policy, dn, podSpec)
+		g.Expect(AntiAffinityPolicyOf(ps, dn, podSpec, true)).To(Equal(policy))
+	}
+
+	ps.AntiAffinityPolicy = v1alpha1.AntiAffinityPolicyRequired
+	g.Expect(AntiAffinityPolicyOf(ps, dn, &corev1.PodSpec{}, true)).To(Equal(v1alpha1.AntiAffinityPolicyRequired))
+}
@@ -17,6 +17,17 @@ package common
 const (
 	// ReasoneNoEnughReadyStores means the resource fall into current condition due to there is no enought reayd stores
 	ReasonNoEnoughReadyStores = "NoEnoughReadyStores"
+	// ReasonConfigBuildFailed means the config of the pods cannot be built from the spec and the dependencies
+	ReasonConfigBuildFailed = "ConfigBuildFailed"
+	// ReasonDependencyNotReady means the resource is not reconciled since its external dependency is not reachable
+	ReasonDependencyNotReady = "DependencyNotReady"
+	// ReasonInvalidCABundle means the CA bundle of the S3 endpoint contains no valid certificate
+	ReasonInvalidCABundle = "InvalidCABundle"
+)
+
+const (
+	// ConditionTypeConfigBuilt indicates whether the config of the pods is built successfully
+	ConditionTypeConfigBuilt = "ConfigBuilt"
 )
 
 const (
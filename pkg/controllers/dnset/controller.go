@@ -21,10 +21,12 @@ import (
 	"github.com/matrixorigin/controller-runtime/pkg/util"
 	"github.com/matrixorigin/matrixone-operator/api/core/v1alpha1"
 	"github.com/matrixorigin/matrixone-operator/pkg/controllers/common"
+	"github.com/matrixorigin/matrixone-operator/pkg/metrics"
 	kruise "github.com/openkruise/kruise-api/apps/v1beta1"
 	"github.com/pkg/errors"
 	"github.com/samber/lo"
 	"go.uber.org/multierr"
+	"golang.org/x/exp/slices"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
@@ -56,6 +58,10 @@ func (d *Actor) with(sts *kruise.StatefulSet, svc *corev1.Service) *WithResource
 func (d *Actor) Observe(ctx *recon.Context[*v1alpha1.DNSet]) (recon.Action[*v1alpha1.DNSet], error) {
 	dn := ctx.Obj
 
+	if err := common.CheckExternalLogSet(&dn.Deps.LogSetRef, &dn.Status.ConditionalStatus); err != nil {
+		return nil, err
+	}
+
 	ctx.Log.Info("observe dnset")
 	svc := &corev1.Service{}
 	err, foundSvc := util.IsFound(ctx.Get(client.ObjectKey{Namespace: dn.Namespace, Name: headlessSvcName(dn)}, svc))
@@ -75,13 +81,28 @@ func (d *Actor) Observe(ctx *recon.Context[*v1alpha1.DNSet]) (recon.Action[*v1al
 	if !foundSts || !foundSvc {
 		return d.Create, nil
 	}
+	if err := common.SyncHeadlessService(ctx, buildHeadlessSvc(dn)); err != nil {
+		return nil, errors.Wrap(err, "sync dn headless service")
+	}
 
 	podList := &corev1.PodList{}
 	err = ctx.List(podList, client.InNamespace(dn.Namespace), client.MatchingLabels(common.SubResourceLabels(dn)))
 	if err != nil {
 		return nil, errors.Wrap(err, "list dn pods")
 	}
-	common.CollectStoreStatus(&dn.Status.FailoverStatus, podList.Items)
+	if err := common.SyncDrainedPods(ctx, podList.Items); err != nil {
+		return nil, err
+	}
+	if err := common.SyncStoreUUIDLabels(ctx, podList.Items, common.StoreTypeDN); err != nil {
+		return nil, err
+	}
+	if err := common.ForceDeleteStuckPods(ctx, ctx.Obj.Spec.StuckPodPolicy, podList.Items); err != nil {
+		return nil, err
+	}
+	common.CollectStoreStatus(&dn.Status.FailoverStatus, podList.Items, 0, common.ForceFailover(dn))
+	dn.Status.PodSummary = common.CollectPodSummary(podList.Items)
+	common.CollectImagePullStatus(&dn.Status.ConditionalStatus, podList.Items, dn.Spec.GetImagePullDeadline())
+	dn.Status.ResolvedImage = common.ResolvedImage(&sts.Spec.Template)
 
 	if len(dn.Status.AvailableStores) >= int(dn.Spec.Replicas) {
 		dn.Status.SetCondition(metav1.Condition{
@@ -97,7 +118,7 @@ func (d *Actor) Observe(ctx *recon.Context[*v1alpha1.DNSet]) (recon.Action[*v1al
 	}
 
 	switch {
-	case len(dn.Status.StoresFailedFor(storeDownTimeout)) > 0:
+	case len(storesToRepair(dn, sts)) > 0:
 		return d.with(sts, svc).Repair, nil
 	case dn.Spec.Replicas != *sts.Spec.Replicas:
 		return d.with(sts, svc).Scale, nil
@@ -148,29 +169,14 @@ func (d *Actor) Finalize(ctx *recon.Context[*v1alpha1.DNSet]) (bool, error) {
 
 func (d *Actor) Create(ctx *recon.Context[*v1alpha1.DNSet]) error {
 	ctx.Log.Info("create dn set")
-	dn := ctx.Obj
-
-	hSvc := buildHeadlessSvc(dn)
-	dnSet := buildDNSet(dn)
-	syncReplicas(dn, dnSet)
-	syncPodMeta(dn, dnSet)
-	syncPodSpec(dn, dnSet, ctx.Dep.Deps.LogSet.Spec.SharedStorage)
-	syncPersistentVolumeClaim(dn, dnSet)
-
-	configMap, err := buildDNSetConfigMap(dn, ctx.Dep.Deps.LogSet)
+	objs, err := RenderManifests(ctx.Obj, ctx.Dep.Deps.LogSet)
+	common.SetConfigBuiltCondition(&ctx.Obj.Status.ConditionalStatus, err)
 	if err != nil {
 		return err
 	}
 
-	if err := common.SyncConfigMap(ctx, &dnSet.Spec.Template.Spec, configMap); err != nil {
-		return err
-	}
-
 	// create all resources
-	err = lo.Reduce[client.Object, error]([]client.Object{
-		hSvc,
-		dnSet,
-	}, func(errs error, o client.Object, _ int) error {
+	err = lo.Reduce[client.Object, error](objs, func(errs error, o client.Object, _ int) error {
 		err := ctx.CreateOwned(o)
 		return multierr.Append(errs, util.Ignore(apierrors.IsAlreadyExists, err))
 	}, nil)
@@ -181,19 +187,58 @@ func (d *Actor) Create(ctx *recon.Context[*v1alpha1.DNSet]) error {
 	return nil
 }
 
+// RenderManifests renders the underlying objects of the DNSet without touching the cluster
+func RenderManifests(dn *v1alpha1.DNSet, ls *v1alpha1.LogSet) ([]client.Object, error) {
+	hSvc := buildHeadlessSvc(dn)
+	dnSet := buildDNSet(dn)
+	syncReplicas(dn, dnSet)
+	syncPodMeta(dn, dnSet)
+	syncPodSpec(dn, dnSet, ls.Spec.SharedStorage)
+	syncPersistentVolumeClaim(dn, dnSet)
+	common.SyncUpdateStrategy(&dn.Spec.PodSet, dnSet)
+	common.SyncRevisionHistoryLimit(&dn.Spec.PodSet, dnSet)
+
+	configMap, err := buildDNSetConfigMap(dn, ls)
+	if err != nil {
+		return nil, err
+	}
+	ep, err := common.RenderEntrypointConfigMap(&dn.Spec.PodSet, &dnSet.Spec.Template.Spec, configMap, common.EntrypointFileName(&dn.Spec.PodSet))
+	if err != nil {
+		return nil, err
+	}
+	cm, err := common.RenderConfigMap(&dnSet.Spec.Template.Spec, configMap, common.ConfigFileName(&dn.Spec.PodSet, common.ConfigFile))
+	if err != nil {
+		return nil, err
+	}
+	objs := []client.Object{cm, hSvc, dnSet}
+	if ep != nil {
+		objs = append(objs, ep)
+	}
+	return objs, nil
+}
+
 func (r *WithResources) Scale(ctx *recon.Context[*v1alpha1.DNSet]) error {
-	return ctx.Patch(r.sts, func() error {
+	from := *r.sts.Spec.Replicas
+	if err := ctx.Patch(r.sts, func() error {
 		syncReplicas(ctx.Obj, r.sts)
 		return nil
-	})
+	}); err != nil {
+		return err
+	}
+	common.EmitScaleEvent(ctx.Event, from, ctx.Obj.Spec.Replicas)
+	return nil
 }
 
 func (r *WithResources) Update(ctx *recon.Context[*v1alpha1.DNSet]) error {
-	return ctx.Update(r.sts)
+	if err := ctx.Update(r.sts); err != nil {
+		return err
+	}
+	common.EmitUpgradeEvent(ctx.Event, ctx.Obj.Status.ResolvedImage, common.ResolvedImage(&r.sts.Spec.Template))
+	return nil
 }
 
 func (r *WithResources) Repair(ctx *recon.Context[*v1alpha1.DNSet]) error {
-	toRepair := ctx.Obj.Status.StoresFailedFor(storeDownTimeout)
+	toRepair := storesToRepair(ctx.Obj, r.sts)
 	if len(toRepair) == 0 {
 		return nil
 	}
@@ -204,11 +249,30 @@ func (r *WithResources) Repair(ctx *recon.Context[*v1alpha1.DNSet]) error {
 		return errors.Wrapf(err, "error parse ordinal from pod name %s", toRepair[0].PodName)
 	}
 	r.sts.Spec.ReserveOrdinals = util.Upsert(r.sts.Spec.ReserveOrdinals, ordinal)
+	if err := ctx.Update(r.sts); err != nil {
+		return errors.Wrapf(err, "reserve the ordinal of the failed pod %s", toRepair[0].PodName)
+	}
+	common.EmitFailoverEvent(ctx.Event, toRepair[0].PodName)
 	return nil
 }
 
+// storesToRepair returns the failed stores that are not failed over yet. A store is failed over once
+// the ordinal of its pod is reserved, so that a forced failover is only performed once even if the
+// ForceFailoverAnnotation is kept.
+func storesToRepair(dn *v1alpha1.DNSet, sts *kruise.StatefulSet) []v1alpha1.Store {
+	var stores []v1alpha1.Store
+	for _, store := range dn.Status.StoresFailedFor(storeDownTimeout) {
+		ordinal, err := util.PodOrdinal(store.PodName)
+		if err == nil && slices.Contains(sts.Spec.ReserveOrdinals, ordinal) {
+			continue
+		}
+		stores = append(stores, store)
+	}
+	return stores
+}
+
 func (d *Actor) Reconcile(mgr manager.Manager) error {
-	err := recon.Setup[*v1alpha1.DNSet](&v1alpha1.DNSet{}, "dnset", mgr, d,
+	err := recon.Setup[*v1alpha1.DNSet](&v1alpha1.DNSet{}, "dnset", mgr, metrics.Instrument[*v1alpha1.DNSet]("dnset", d),
 		recon.WithBuildFn(func(b *builder.Builder) {
 			b.Owns(&kruise.StatefulSet{}).
 				Owns(&corev1.Service{})
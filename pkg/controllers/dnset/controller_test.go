@@ -15,6 +15,7 @@
 package dnset
 
 import (
+	"context"
 	"testing"
 
 	"k8s.io/apimachinery/pkg/api/resource"
@@ -30,6 +31,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/utils/pointer"
@@ -153,6 +155,9 @@ func TestDNSetActor_Observe(t *testing.T) {
 							Name:      svc,
 							Namespace: "default",
 						},
+						Spec: corev1.ServiceSpec{
+							PublishNotReadyAddresses: true,
+						},
 					},
 				).Build(),
 			},
@@ -198,6 +203,105 @@ func TestDNSetActor_Observe(t *testing.T) {
 	}
 }
 
+func TestDNSetForceFailover(t *testing.T) {
+	g := NewGomegaWithT(t)
+	dn := &v1alpha1.DNSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   "default",
+			Name:        "test",
+			Annotations: map[string]string{v1alpha1.ForceFailoverAnnotation: "test-dn-1"},
+		},
+		Status: v1alpha1.DNSetStatus{FailoverStatus: v1alpha1.FailoverStatus{
+			FailedStores: []v1alpha1.Store{{PodName: "test-dn-1", Phase: v1alpha1.StorePhaseDown, Forced: true}},
+		}},
+	}
+	sts := &kruisev1.StatefulSet{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: stsName(dn)}}
+	cli := fake.KubeClientBuilder().WithScheme(newScheme()).WithObjects(sts.DeepCopy()).Build()
+	eventEmitter := fake.NewMockEventEmitter(gomock.NewController(t))
+	eventEmitter.EXPECT().EmitEventGeneric(common.ReasonFailoverTriggered, gomock.Any(), nil).Times(1)
+	ctx := fake.NewContext(dn, cli, eventEmitter)
+
+	g.Expect(cli.Get(context.TODO(), client.ObjectKeyFromObject(sts), sts)).To(Succeed())
+	g.Expect(storesToRepair(dn, sts)).To(HaveLen(1))
+	g.Expect((&Actor{}).with(sts, nil).Repair(ctx)).To(Succeed())
+
+	stored := &kruisev1.StatefulSet{}
+	g.Expect(cli.Get(context.TODO(), client.ObjectKeyFromObject(sts), stored)).To(Succeed())
+	g.Expect(stored.Spec.ReserveOrdinals).To(Equal([]int{1}))
+	// the forced store is failed over only once
+	g.Expect(storesToRepair(dn, stored)).To(BeEmpty())
+	g.Expect((&Actor{}).with(stored, nil).Repair(ctx)).To(Succeed())
+}
+
+func TestDNSetStartupProbe(t *testing.T) {
+	g := NewGomegaWithT(t)
+	dn := &v1alpha1.DNSet{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test"},
+		Spec: v1alpha1.DNSetSpec{
+			DNSetBasic: v1alpha1.DNSetBasic{
+				PodSet: v1alpha1.PodSet{
+					MainContainer: v1alpha1.MainContainer{Image: "test:latest"},
+					Replicas:      1,
+				},
+			},
+		},
+	}
+
+	// the default probe must carry the fields defaulted by the API server, otherwise the
+	// statefulset would be updated on every reconciliation
+	sts := &kruisev1.StatefulSet{}
+	syncPodSpec(dn, sts, v1alpha1.SharedStorageProvider{})
+	g.Expect(sts.Spec.Template.Spec.Containers[0].StartupProbe).To(Equal(&corev1.Probe{
+		ProbeHandler:     corev1.ProbeHandler{TCPSocket: &corev1.TCPSocketAction{Port: intstr.FromInt(dnServicePort)}},
+		TimeoutSeconds:   1,
+		PeriodSeconds:    10,
+		SuccessThreshold: 1,
+		FailureThreshold: 180,
+	}))
+
+	dn.Spec.StartupProbe = &v1alpha1.StartupProbe{FailureThreshold: pointer.Int32(30), PeriodSeconds: pointer.Int32(5)}
+	sts = &kruisev1.StatefulSet{}
+	syncPodSpec(dn, sts, v1alpha1.SharedStorageProvider{})
+	probe := sts.Spec.Template.Spec.Containers[0].StartupProbe
+	g.Expect(probe.FailureThreshold).To(Equal(int32(30)))
+	g.Expect(probe.PeriodSeconds).To(Equal(int32(5)))
+
+	// the set created before the default probe is not rolled unless the probe is tuned
+	dn.Spec.StartupProbe = nil
+	sts = &kruisev1.StatefulSet{}
+	sts.Spec.Template.Spec.Containers = []corev1.Container{{Name: v1alpha1.ContainerMain}}
+	syncPodSpec(dn, sts, v1alpha1.SharedStorageProvider{})
+	g.Expect(sts.Spec.Template.Spec.Containers[0].StartupProbe).To(BeNil())
+	g.Expect(sts.Spec.Template.Spec.Affinity).To(BeNil())
+}
+
+func TestDNSetServiceArgs(t *testing.T) {
+	g := NewGomegaWithT(t)
+	dn := &v1alpha1.DNSet{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test"},
+		Spec: v1alpha1.DNSetSpec{
+			DNSetBasic: v1alpha1.DNSetBasic{
+				PodSet: v1alpha1.PodSet{
+					MainContainer:    v1alpha1.MainContainer{Image: "test:latest"},
+					Replicas:         1,
+					ExtraServiceArgs: []string{"-debug-http=:6060"},
+				},
+			},
+			Overlay: &v1alpha1.Overlay{
+				MainContainerOverlay: v1alpha1.MainContainerOverlay{Args: []string{"-max-processor=4"}},
+			},
+		},
+	}
+
+	sts := &kruisev1.StatefulSet{}
+	syncPodSpec(dn, sts, v1alpha1.SharedStorageProvider{})
+	g.Expect(sts.Spec.Template.Spec.Containers[0].Args).To(Equal([]string{"-debug-http=:6060", "-max-processor=4"}))
+
+	// the args are not accumulated across syncs
+	syncPodSpec(dn, sts, v1alpha1.SharedStorageProvider{})
+	g.Expect(sts.Spec.Template.Spec.Containers[0].Args).To(Equal([]string{"-debug-http=:6060", "-max-processor=4"}))
+}
+
 func TestDNSetVolumeMount(t *testing.T) {
 	s := newScheme()
 
@@ -26,13 +26,17 @@ import (
 	"github.com/matrixorigin/controller-runtime/pkg/util"
 	"github.com/matrixorigin/matrixone-operator/api/core/v1alpha1"
 	"github.com/matrixorigin/matrixone-operator/pkg/controllers/common"
-	"github.com/openkruise/kruise-api/apps/pub"
 	kruise "github.com/openkruise/kruise-api/apps/v1beta1"
 	corev1 "k8s.io/api/core/v1"
 )
 
 const (
 	serviceType = "DN"
+
+	// localDataVolume is the volume name of the DN local data PV
+	localDataVolume = "mo-local-data"
+	// localDataPath is the path where the DN local data volume will be mounted to
+	localDataPath = "/var/lib/matrixone-local"
 )
 
 // dn service entrypoint script
@@ -47,11 +51,26 @@ if [ -z "${HOSTNAME_UUID+guard}" ]; then
 else
   UUID=$(echo ${ADDR} | sha256sum | od -x | head -1 | awk '{OFS="-"; print $2$3,$4,$5,$6,$7$8$9}')
 fi
+{{ if .ClusterDomain -}}
+# qualify the address after the UUID is derived so that the UUID is stable
+ADDR="${ADDR}.{{ .ClusterDomain }}"
+{{ end -}}
+SERVICE_ADDR="${ADDR}"
+{{ if .AdvertiseAddress -}}
+SERVICE_ADDR="{{ .AdvertiseAddress }}"
+{{ end -}}
+{{ if .NetworkInterface -}}
+# advertise the address of the attached network so that the data-plane traffic goes through it
+SERVICE_ADDR=$(ip -4 -o addr show dev {{ .NetworkInterface }} | awk '{split($4, a, "/"); print a[1]; exit}')
+if [ -z "${SERVICE_ADDR}" ]; then
+  echo "no IPv4 address found on network interface {{ .NetworkInterface }}" >&2 && exit 1
+fi
+{{ end -}}
 conf=$(mktemp)
 bc=$(mktemp)
 cat <<EOF > ${bc}
 uuid = "${UUID}"
-service-address = "${ADDR}:{{ .DNServicePort }}"
+service-address = "${SERVICE_ADDR}:{{ .DNServicePort }}"
 EOF
 # build instance config
 sed "/\[dn\]/r ${bc}" {{ .ConfigFilePath }} > ${conf}
@@ -59,7 +78,7 @@ sed "/\[dn\]/r ${bc}" {{ .ConfigFilePath }} > ${conf}
 # append lock-service configs
 lsc=$(mktemp)
 cat <<EOF > ${lsc}
-service-address = "${ADDR}:{{ .LockServicePort }}"
+service-address = "${SERVICE_ADDR}:{{ .LockServicePort }}"
 EOF
 sed -i "/\[dn.lockservice\]/r ${lsc}" ${conf}
 
@@ -81,8 +100,8 @@ while true; do
     fi
 done
 
-echo "/mo-service -cfg ${conf}"
-exec /mo-service -cfg ${conf}
+echo "/mo-service -cfg ${conf} $@"
+exec /mo-service -cfg ${conf} "$@"
 `))
 
 type model struct {
@@ -90,6 +109,15 @@ type model struct {
 	ConfigFilePath string
 
 	LockServicePort int
+
+	// NetworkInterface is the interface whose address is advertised instead of the pod DNS name
+	NetworkInterface string
+
+	// ClusterDomain qualifies the pod DNS name if set
+	ClusterDomain string
+	// AdvertiseAddress is the address advertised instead of the pod DNS name, which is rendered
+	// with the shell variables of the entrypoint
+	AdvertiseAddress string
 }
 
 func syncReplicas(dn *v1alpha1.DNSet, cs *kruise.StatefulSet) {
@@ -97,6 +125,7 @@ func syncReplicas(dn *v1alpha1.DNSet, cs *kruise.StatefulSet) {
 }
 
 func syncPodMeta(dn *v1alpha1.DNSet, cs *kruise.StatefulSet) {
+	common.SyncNetworkAttachment(dn.Spec.NetworkAttachment, &cs.Spec.Template.ObjectMeta)
 	dn.Spec.Overlay.OverlayPodMeta(&cs.Spec.Template.ObjectMeta)
 }
 
@@ -117,19 +146,25 @@ func syncPodSpec(dn *v1alpha1.DNSet, sts *kruise.StatefulSet, sp v1alpha1.Shared
 	if dn.Spec.CacheVolume != nil {
 		volumeMountsList = append(volumeMountsList, dataVolume)
 	}
+	if dn.Spec.DataVolume != nil {
+		volumeMountsList = append(volumeMountsList, corev1.VolumeMount{
+			Name:      localDataVolume,
+			MountPath: localDataPath,
+		})
+	}
 	mainRef := util.FindFirst(sts.Spec.Template.Spec.Containers, func(c corev1.Container) bool {
 		return c.Name == v1alpha1.ContainerMain
 	})
+	existing := mainRef != nil
 	if mainRef == nil {
 		mainRef = &corev1.Container{Name: v1alpha1.ContainerMain}
 	}
 
 	mainRef.Image = dn.Spec.Image
 	mainRef.Resources = dn.Spec.Resources
-	mainRef.Command = []string{
-		"/bin/sh", fmt.Sprintf("%s/%s", common.ConfigPath, common.Entrypoint),
-	}
+	mainRef.Args = dn.Spec.ExtraServiceArgs
 	mainRef.VolumeMounts = volumeMountsList
+	common.SyncEntrypoint(&dn.Spec.PodSet, mainRef, common.ConfigPath, common.EntrypointFileName(&dn.Spec.PodSet))
 	mainRef.Env = []corev1.EnvVar{
 		util.FieldRefEnv(common.PodNameEnvKey, "metadata.name"),
 		util.FieldRefEnv(common.NamespaceEnvKey, "metadata.namespace"),
@@ -137,22 +172,63 @@ func syncPodSpec(dn *v1alpha1.DNSet, sts *kruise.StatefulSet, sp v1alpha1.Shared
 	}
 
 	if dn.Spec.DNSBasedIdentity {
-		mainRef.Env = append(mainRef.Env, corev1.EnvVar{Name: "HOSTNAME_UUID", Value: "y"})
+		mainRef.Env = append(mainRef.Env, corev1.EnvVar{Name: common.HostnameUUIDEnvKey, Value: "y"})
+	}
+	// the pods of the sets created before the startup probe is generated by default are not
+	// rolled by the operator upgrade unless the probe is tuned explicitly
+	if !existing || mainRef.StartupProbe != nil || dn.Spec.StartupProbe != nil {
+		mainRef.StartupProbe = common.TCPProbe(dnServicePort, dn.Spec.StartupProbe.GetPeriodSeconds(), dn.Spec.StartupProbe.GetFailureThreshold())
 	}
+	common.SyncContainerSecurityContext(dn.Spec.ContainerSecurityContext, mainRef)
 	dn.Spec.Overlay.OverlayMainContainer(mainRef)
 	specRef := &sts.Spec.Template.Spec
 	specRef.Containers = []corev1.Container{*mainRef}
-	specRef.ReadinessGates = []corev1.PodReadinessGate{{
-		ConditionType: pub.InPlaceUpdateReady,
-	}}
+	common.SyncReadinessGates(&dn.Spec.PodSet, specRef, true)
 	specRef.NodeSelector = dn.Spec.NodeSelector
+	specRef.ServiceAccountName = dn.Spec.ServiceAccountName
 
 	common.SetStorageProviderConfig(sp, specRef)
-	common.SyncTopology(dn.Spec.TopologyEvenSpread, specRef)
+	common.SyncTopology(dn.Spec.TopologyEvenSpread, dn.Spec.TopologySpreadPolicy, dn, specRef)
+	common.SyncSysctls(dn.Spec.Sysctls, specRef)
+	common.SyncTmpVolume(dn.Spec.TmpVolume, specRef)
+	common.SyncEphemeralStorage(dn.Spec.EphemeralStorage, specRef)
+	common.SyncAntiAffinity(common.AntiAffinityPolicyOf(&dn.Spec.PodSet, dn, specRef, existing), dn, specRef)
 
 	dn.Spec.Overlay.OverlayPodSpec(specRef)
 }
 
+// setLockServiceConfig renders the lock service tuning to the DN config
+func setLockServiceConfig(conf *v1alpha1.TomlConfig, l *v1alpha1.LockServiceConfig) {
+	if l == nil {
+		return
+	}
+	if l.KeepBindInterval != nil {
+		conf.Set([]string{"dn", "lockservice", "keep-lock-table-bind-interval"}, l.KeepBindInterval.Duration.String())
+	}
+	if l.KeepRemoteLockInterval != nil {
+		conf.Set([]string{"dn", "lockservice", "keep-remote-lock-interval"}, l.KeepRemoteLockInterval.Duration.String())
+	}
+	if l.RemoteLockTimeout != nil {
+		conf.Set([]string{"dn", "lockservice", "remote-lock-timeout"}, l.RemoteLockTimeout.Duration.String())
+	}
+}
+
+// setCompactionConfig renders the compaction tuning to the checkpoint config of DN
+func setCompactionConfig(conf *v1alpha1.TomlConfig, c *v1alpha1.CompactionConfig) {
+	if c == nil {
+		return
+	}
+	if c.FlushInterval != nil {
+		conf.Set([]string{"dn", "Ckp", "flush-interval"}, c.FlushInterval.Duration.String())
+	}
+	if c.ScanInterval != nil {
+		conf.Set([]string{"dn", "Ckp", "scan-interval"}, c.ScanInterval.Duration.String())
+	}
+	if c.MinCount != nil {
+		conf.Set([]string{"dn", "Ckp", "min-count"}, int64(*c.MinCount))
+	}
+}
+
 // buildDNSetConfigMap return dn set configmap
 func buildDNSetConfigMap(dn *v1alpha1.DNSet, ls *v1alpha1.LogSet) (*corev1.ConfigMap, error) {
 	if ls.Status.Discovery == nil {
@@ -164,20 +240,38 @@ func buildDNSetConfigMap(dn *v1alpha1.DNSet, ls *v1alpha1.LogSet) (*corev1.Confi
 	}
 	conf.Set([]string{"hakeeper-client", "service-addresses"}, logset.HaKeeperAdds(ls))
 	// conf.Set([]string{"hakeeper-client", "discovery-address"}, ls.Status.Discovery.String())
-	conf.Merge(common.FileServiceConfig(fmt.Sprintf("%s/%s", common.DataPath, common.DataDir), ls.Spec.SharedStorage, dn.Spec.CacheVolume, &dn.Spec.SharedStorageCache))
+	conf.Merge(common.FileServiceConfig(localDataDir(dn), ls.Spec.SharedStorage, dn.Spec.CacheVolume, &dn.Spec.SharedStorageCache))
 	conf.Set([]string{"service-type"}, serviceType)
 	conf.Set([]string{"dn", "listen-address"}, getListenAddress())
 	conf.Set([]string{"dn", "lockservice", "listen-address"}, fmt.Sprintf("0.0.0.0:%d", common.LockServicePort))
+	setLockServiceConfig(conf, dn.Spec.LockService)
+	setCompactionConfig(conf, dn.Spec.Compaction)
 	s, err := conf.ToString()
 	if err != nil {
 		return nil, err
 	}
 
+	var advertiseAddress string
+	if tpl := dn.Spec.AdvertiseAddressTemplate; tpl != "" {
+		advertiseAddress, err = v1alpha1.RenderAdvertiseAddress(tpl, v1alpha1.AdvertiseAddressVars{
+			PodName:   "${POD_NAME}",
+			Ordinal:   "${ORDINAL}",
+			Namespace: "${NAMESPACE}",
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "render advertise address")
+		}
+	}
+
+	configFile := common.ConfigFileName(&dn.Spec.PodSet, common.ConfigFile)
 	buff := new(bytes.Buffer)
 	err = startScriptTpl.Execute(buff, &model{
-		DNServicePort:   dnServicePort,
-		LockServicePort: common.LockServicePort,
-		ConfigFilePath:  fmt.Sprintf("%s/%s", common.ConfigPath, common.ConfigFile),
+		DNServicePort:    dnServicePort,
+		LockServicePort:  common.LockServicePort,
+		ConfigFilePath:   fmt.Sprintf("%s/%s", common.ConfigPath, configFile),
+		NetworkInterface: common.NetworkInterface(dn.Spec.NetworkAttachment),
+		ClusterDomain:    dn.Spec.ClusterDomain,
+		AdvertiseAddress: advertiseAddress,
 	})
 	if err != nil {
 		return nil, err
@@ -186,14 +280,14 @@ func buildDNSetConfigMap(dn *v1alpha1.DNSet, ls *v1alpha1.LogSet) (*corev1.Confi
 	return &corev1.ConfigMap{
 		ObjectMeta: common.ObjMetaTemplate(dn, configMapName(dn)),
 		Data: map[string]string{
-			common.ConfigFile: s,
-			common.Entrypoint: buff.String(),
+			configFile: s,
+			common.EntrypointFileName(&dn.Spec.PodSet): buff.String(),
 		},
 	}, nil
 }
 
 func buildHeadlessSvc(dn *v1alpha1.DNSet) *corev1.Service {
-	return common.HeadlessServiceTemplate(dn, headlessSvcName(dn))
+	return common.HeadlessServiceTemplate(dn, headlessSvcName(dn), &dn.Spec.PodSet)
 }
 
 func buildDNSet(dn *v1alpha1.DNSet) *kruise.StatefulSet {
@@ -201,25 +295,46 @@ func buildDNSet(dn *v1alpha1.DNSet) *kruise.StatefulSet {
 }
 
 func syncPersistentVolumeClaim(dn *v1alpha1.DNSet, sts *kruise.StatefulSet) {
+	var tpls []corev1.PersistentVolumeClaim
 	if dn.Spec.CacheVolume != nil {
-		dataPVC := common.PersistentVolumeClaimTemplate(dn.Spec.CacheVolume.Size, dn.Spec.CacheVolume.StorageClassName, common.DataVolume)
-		tpls := []corev1.PersistentVolumeClaim{dataPVC}
+		tpls = append(tpls, common.PersistentVolumeClaimTemplate(dn.Spec.CacheVolume.Size, dn.Spec.CacheVolume.StorageClassName, common.DataVolume))
+	}
+	if dn.Spec.DataVolume != nil {
+		tpls = append(tpls, common.PersistentVolumeClaimTemplate(dn.Spec.DataVolume.Size, dn.Spec.DataVolume.StorageClassName, localDataVolume))
+	}
+	if len(tpls) > 0 {
+		common.SyncVolumeMetadata(dn.Spec.VolumeMetadata, tpls)
 		dn.Spec.Overlay.AppendVolumeClaims(&tpls)
 		sts.Spec.VolumeClaimTemplates = tpls
 	}
 }
 
+// localDataDir returns the directory of the DN local data, which is placed in the dedicated
+// data volume if specified, otherwise shares the cache volume
+func localDataDir(dn *v1alpha1.DNSet) string {
+	if dn.Spec.DataVolume != nil {
+		return fmt.Sprintf("%s/%s", localDataPath, common.DataDirName(&dn.Spec.PodSet))
+	}
+	return fmt.Sprintf("%s/%s", common.DataPath, common.DataDirName(&dn.Spec.PodSet))
+}
+
 func syncPods(ctx *recon.Context[*v1alpha1.DNSet], sts *kruise.StatefulSet) error {
 	cm, err := buildDNSetConfigMap(ctx.Obj, ctx.Dep.Deps.LogSet)
+	common.SetConfigBuiltCondition(&ctx.Obj.Status.ConditionalStatus, err)
 	if err != nil {
 		return err
 	}
 
 	syncPodMeta(ctx.Obj, sts)
+	common.SyncUpdateStrategy(&ctx.Obj.Spec.PodSet, sts)
+	common.SyncRevisionHistoryLimit(&ctx.Obj.Spec.PodSet, sts)
 	if ctx.Dep != nil {
 		syncPodSpec(ctx.Obj, sts, ctx.Dep.Deps.LogSet.Spec.SharedStorage)
 
 	}
 
-	return common.SyncConfigMap(ctx, &sts.Spec.Template.Spec, cm)
+	if err := common.SyncEntrypointConfigMap(ctx, &ctx.Obj.Spec.PodSet, &sts.Spec.Template.Spec, cm, common.EntrypointFileName(&ctx.Obj.Spec.PodSet)); err != nil {
+		return err
+	}
+	return common.SyncConfigMap(ctx, &sts.Spec.Template.Spec, cm, common.ConfigFileName(&ctx.Obj.Spec.PodSet, common.ConfigFile))
 }
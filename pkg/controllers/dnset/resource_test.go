@@ -16,9 +16,14 @@ package dnset
 
 import (
 	"github.com/matrixorigin/matrixone-operator/api/core/v1alpha1"
+	"github.com/matrixorigin/matrixone-operator/pkg/controllers/common"
 	. "github.com/onsi/gomega"
+	kruisev1 "github.com/openkruise/kruise-api/apps/v1beta1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
 	"testing"
+	"time"
 )
 
 func Test_buildDNSetConfigMap(t *testing.T) {
@@ -152,6 +157,66 @@ backend = "DISK-ETL"
 data-dir = "/test"
 name = "ETL"
 
+[hakeeper-client]
+service-addresses = []
+`,
+		},
+		{
+			name: "dataVolume",
+			args: args{
+				dn: &v1alpha1.DNSet{
+					ObjectMeta: metav1.ObjectMeta{
+						Namespace: "test",
+						Name:      "test",
+					},
+					Spec: v1alpha1.DNSetSpec{DNSetBasic: v1alpha1.DNSetBasic{
+						DataVolume: &v1alpha1.Volume{
+							Size: resource.MustParse("10Gi"),
+						},
+					}},
+				},
+				ls: &v1alpha1.LogSet{
+					ObjectMeta: metav1.ObjectMeta{
+						Namespace: "test",
+						Name:      "test",
+					},
+					Spec: v1alpha1.LogSetSpec{LogSetBasic: v1alpha1.LogSetBasic{SharedStorage: v1alpha1.SharedStorageProvider{
+						FileSystem: &v1alpha1.FileSystemProvider{
+							Path: "/test",
+						},
+					}}},
+					Status: v1alpha1.LogSetStatus{
+						Discovery: &v1alpha1.LogSetDiscovery{
+							Port:    6001,
+							Address: "test",
+						},
+					},
+				},
+			},
+			wantConfig: `data-dir = "/var/lib/matrixone-local/data"
+service-type = "DN"
+
+[dn]
+listen-address = "0.0.0.0:41010"
+
+[dn.lockservice]
+listen-address = "0.0.0.0:6003"
+
+[[fileservice]]
+backend = "DISK"
+data-dir = "/var/lib/matrixone-local/data"
+name = "LOCAL"
+
+[[fileservice]]
+backend = "DISK"
+data-dir = "/test"
+name = "S3"
+
+[[fileservice]]
+backend = "DISK-ETL"
+data-dir = "/test"
+name = "ETL"
+
 [hakeeper-client]
 service-addresses = []
 `,
@@ -170,3 +235,128 @@ service-addresses = []
 		})
 	}
 }
+
+func TestSetLockServiceConfig(t *testing.T) {
+	g := NewGomegaWithT(t)
+	conf := v1alpha1.NewTomlConfig(map[string]interface{}{})
+	setLockServiceConfig(conf, nil)
+	g.Expect(conf.Get("dn", "lockservice")).To(BeNil())
+
+	setLockServiceConfig(conf, &v1alpha1.LockServiceConfig{
+		KeepRemoteLockInterval: &metav1.Duration{Duration: time.Second},
+		RemoteLockTimeout:      &metav1.Duration{Duration: 10 * time.Second},
+	})
+	g.Expect(conf.Get("dn", "lockservice", "keep-remote-lock-interval").MustString()).To(Equal("1s"))
+	g.Expect(conf.Get("dn", "lockservice", "remote-lock-timeout").MustString()).To(Equal("10s"))
+	g.Expect(conf.Get("dn", "lockservice", "keep-lock-table-bind-interval")).To(BeNil())
+}
+
+func TestSetCompactionConfig(t *testing.T) {
+	g := NewGomegaWithT(t)
+	conf := v1alpha1.NewTomlConfig(map[string]interface{}{})
+	setCompactionConfig(conf, nil)
+	g.Expect(conf.Get("dn", "Ckp")).To(BeNil())
+
+	setCompactionConfig(conf, &v1alpha1.CompactionConfig{
+		FlushInterval: &metav1.Duration{Duration: time.Minute},
+		MinCount:      pointer.Int32(100),
+	})
+	g.Expect(conf.Get("dn", "Ckp", "flush-interval").MustString()).To(Equal("1m0s"))
+	g.Expect(conf.Get("dn", "Ckp", "min-count").MustInt()).To(Equal(int64(100)))
+	g.Expect(conf.Get("dn", "Ckp", "scan-interval")).To(BeNil())
+}
+
+func TestNetworkAttachment(t *testing.T) {
+	g := NewGomegaWithT(t)
+	dn := &v1alpha1.DNSet{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "test", Name: "test"},
+		Spec: v1alpha1.DNSetSpec{DNSetBasic: v1alpha1.DNSetBasic{
+			NetworkAttachment: &v1alpha1.NetworkAttachment{Name: "mo/data-plane"},
+		}},
+	}
+	ls := &v1alpha1.LogSet{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "test", Name: "test"},
+		Spec: v1alpha1.LogSetSpec{LogSetBasic: v1alpha1.LogSetBasic{SharedStorage: v1alpha1.SharedStorageProvider{
+			FileSystem: &v1alpha1.FileSystemProvider{Path: "/test"},
+		}}},
+		Status: v1alpha1.LogSetStatus{
+			Discovery: &v1alpha1.LogSetDiscovery{Port: 6001, Address: "test"},
+		},
+	}
+	cm, err := buildDNSetConfigMap(dn, ls)
+	g.Expect(err).To(Succeed())
+	g.Expect(cm.Data[common.Entrypoint]).To(ContainSubstring("ip -4 -o addr show dev net1"))
+	g.Expect(cm.Data[common.Entrypoint]).To(ContainSubstring(`service-address = "${SERVICE_ADDR}:`))
+
+	sts := buildDNSet(dn)
+	syncPodMeta(dn, sts)
+	g.Expect(sts.Spec.Template.Annotations).To(HaveKeyWithValue(common.NetworksAnnotation, "mo/data-plane@net1"))
+
+	dn.Spec.NetworkAttachment = nil
+	cm, err = buildDNSetConfigMap(dn, ls)
+	g.Expect(err).To(Succeed())
+	g.Expect(cm.Data[common.Entrypoint]).NotTo(ContainSubstring("ip -4"))
+	syncPodMeta(dn, sts)
+	g.Expect(sts.Spec.Template.Annotations).NotTo(HaveKey(common.NetworksAnnotation))
+}
+
+func TestAdvertiseAddress(t *testing.T) {
+	g := NewGomegaWithT(t)
+	dn := &v1alpha1.DNSet{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "test", Name: "test"},
+		Spec: v1alpha1.DNSetSpec{DNSetBasic: v1alpha1.DNSetBasic{
+			PodSet:                   v1alpha1.PodSet{ClusterDomain: "cluster.example"},
+			AdvertiseAddressTemplate: "dn-{{ .Ordinal }}.{{ .Namespace }}.mo.example.com",
+		}},
+	}
+	ls := &v1alpha1.LogSet{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "test", Name: "test"},
+		Spec: v1alpha1.LogSetSpec{LogSetBasic: v1alpha1.LogSetBasic{SharedStorage: v1alpha1.SharedStorageProvider{
+			FileSystem: &v1alpha1.FileSystemProvider{Path: "/test"},
+		}}},
+		Status: v1alpha1.LogSetStatus{
+			Discovery: &v1alpha1.LogSetDiscovery{Port: 6001, Address: "test"},
+		},
+	}
+	cm, err := buildDNSetConfigMap(dn, ls)
+	g.Expect(err).To(Succeed())
+	g.Expect(cm.Data[common.Entrypoint]).To(ContainSubstring(`ADDR="${ADDR}.cluster.example"`))
+	g.Expect(cm.Data[common.Entrypoint]).To(ContainSubstring(`SERVICE_ADDR="dn-${ORDINAL}.${NAMESPACE}.mo.example.com"`))
+
+	dn.Spec.ClusterDomain = ""
+	dn.Spec.AdvertiseAddressTemplate = ""
+	cm, err = buildDNSetConfigMap(dn, ls)
+	g.Expect(err).To(Succeed())
+	g.Expect(cm.Data[common.Entrypoint]).NotTo(ContainSubstring(`ADDR="${ADDR}.`))
+	g.Expect(cm.Data[common.Entrypoint]).To(ContainSubstring(`SERVICE_ADDR="${ADDR}"`))
+}
+
+func TestConfigFileNameOverride(t *testing.T) {
+	g := NewGomegaWithT(t)
+	dn := &v1alpha1.DNSet{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "test", Name: "test"},
+		Spec: v1alpha1.DNSetSpec{DNSetBasic: v1alpha1.DNSetBasic{
+			PodSet: v1alpha1.PodSet{ConfigFileName: "dn.toml", EntrypointFileName: "run.sh"},
+		}},
+	}
+	ls := &v1alpha1.LogSet{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "test", Name: "test"},
+		Spec: v1alpha1.LogSetSpec{LogSetBasic: v1alpha1.LogSetBasic{SharedStorage: v1alpha1.SharedStorageProvider{
+			FileSystem: &v1alpha1.FileSystemProvider{Path: "/test"},
+		}}},
+		Status: v1alpha1.LogSetStatus{
+			Discovery: &v1alpha1.LogSetDiscovery{Port: 6001, Address: "test"},
+		},
+	}
+	cm, err := buildDNSetConfigMap(dn, ls)
+	g.Expect(err).To(Succeed())
+	g.Expect(cm.Data).To(HaveKey("dn.toml"))
+	g.Expect(cm.Data).NotTo(HaveKey(common.ConfigFile))
+	g.Expect(cm.Data["run.sh"]).To(ContainSubstring(common.ConfigPath + "/dn.toml"))
+
+	sts := &kruisev1.StatefulSet{}
+	syncPodSpec(dn, sts, ls.Spec.SharedStorage)
+	g.Expect(sts.Spec.Template.Spec.Containers[0].Command).To(Equal([]string{"/bin/sh", common.ConfigPath + "/run.sh"}))
+	_, err = common.RenderConfigMap(&sts.Spec.Template.Spec, cm, common.ConfigFile)
+	g.Expect(err).To(HaveOccurred())
+}
@@ -25,22 +25,30 @@ const (
 	dnServicePort = 41010
 )
 
+// Ports returns the ports that the DNSet pods listen on
+func Ports() []int32 {
+	return []int32{dnServicePort, common.LockServicePort}
+}
+
 func getListenAddress() string {
 	return fmt.Sprintf("%s:%d", common.AnyIP, dnServicePort)
 }
 
 func configMapName(dn *v1alpha1.DNSet) string {
-	return resourceName(dn) + "-config"
+	return common.ResourceName(resourceName(dn), "-config", common.MaxConfigMapNameLength)
 }
 
 func stsName(dn *v1alpha1.DNSet) string {
-	return resourceName(dn)
+	return common.ResourceName(resourceName(dn), "", common.MaxStatefulSetNameLength)
 }
 
 func headlessSvcName(dn *v1alpha1.DNSet) string {
-	return resourceName(dn) + "-headless"
+	return common.ResourceName(resourceName(dn), "-headless", common.MaxServiceNameLength)
 }
 
 func resourceName(dn *v1alpha1.DNSet) string {
+	if dn.Spec.NameOverride != "" {
+		return dn.Spec.NameOverride
+	}
 	return dn.Name + nameSuffix
 }
@@ -17,6 +17,7 @@ import (
 	"fmt"
 	recon "github.com/matrixorigin/controller-runtime/pkg/reconciler"
 	"github.com/matrixorigin/matrixone-operator/api/core/v1alpha1"
+	"github.com/matrixorigin/matrixone-operator/pkg/controllers/common"
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -60,6 +61,7 @@ func buildBootstrapConfig(ctx *recon.Context[*v1alpha1.LogSet]) (*corev1.ConfigM
 		ObjectMeta: metav1.ObjectMeta{
 			Namespace: ls.Namespace,
 			Name:      bootstrapConfigMapName(ls),
+			Labels:    common.ResourceLabels(ls),
 		},
 		Data: map[string]string{
 			bootstrapFile: c,
@@ -117,5 +119,5 @@ func encodeOrdinal(ordinal int) string {
 }
 
 func bootstrapConfigMapName(ls *v1alpha1.LogSet) string {
-	return resourceName(ls) + "-bootstrap"
+	return common.ResourceName(resourceName(ls), "-bootstrap", common.MaxConfigMapNameLength)
 }
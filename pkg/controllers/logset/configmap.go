@@ -31,7 +31,6 @@ import (
 const (
 	configFile = "logservice.toml"
 	gossipFile = "gossip.toml"
-	entrypoint = "start.sh"
 
 	raftPort       = 32000
 	logServicePort = 32001
@@ -40,6 +39,11 @@ const (
 	serviceTypeLog = "LOG"
 )
 
+// Ports returns the ports that the LogSet pods listen on
+func Ports() []int32 {
+	return []int32{raftPort, logServicePort, gossipPort}
+}
+
 // Since HA requires instance-based heterogeneous configuration (e.g. instance UUID and advertised addresses), we need a start script to build these configurations based on
 // the instance meta injected by k8s downward API
 // TODO(aylei): add logservice topology labels
@@ -94,8 +98,8 @@ while true; do
     fi
 done
 
-echo "/mo-service -cfg ${conf}"
-exec /mo-service -cfg ${conf}
+echo "/mo-service -cfg ${conf} $@"
+exec /mo-service -cfg ${conf} "$@"
 `))
 
 type model struct {
@@ -119,6 +123,7 @@ func buildGossipSeedsConfigMap(ls *v1alpha1.LogSet, sts *kruisev1.StatefulSet) (
 		ObjectMeta: metav1.ObjectMeta{
 			Namespace: ls.Namespace,
 			Name:      gossipConfigMapName(ls),
+			Labels:    common.ResourceLabels(ls),
 		},
 		Data: map[string]string{
 			gossipFile: c,
@@ -133,7 +138,7 @@ func buildConfigMap(ls *v1alpha1.LogSet) (*corev1.ConfigMap, error) {
 		conf = v1alpha1.NewTomlConfig(map[string]interface{}{})
 	}
 	// 1. build base config file
-	conf.Merge(common.FileServiceConfig(fmt.Sprintf("%s/%s", common.DataPath, common.DataDir), ls.Spec.SharedStorage, &ls.Spec.Volume, nil))
+	conf.Merge(common.FileServiceConfig(fmt.Sprintf("%s/%s", common.DataPath, common.DataDirName(&ls.Spec.PodSet)), ls.Spec.SharedStorage, &ls.Spec.Volume, nil))
 	conf.Set([]string{"service-type"}, serviceTypeLog)
 	conf.Set([]string{"logservice", "deployment-id"}, deploymentID(ls))
 	conf.Set([]string{"logservice", "logservice-listen-address"}, fmt.Sprintf("0.0.0.0:%d", logServicePort))
@@ -144,12 +149,13 @@ func buildConfigMap(ls *v1alpha1.LogSet) (*corev1.ConfigMap, error) {
 	}
 
 	// 2. build the start script
+	configFileName := common.ConfigFileName(&ls.Spec.PodSet, configFile)
 	buff := new(bytes.Buffer)
 	err = startScriptTpl.Execute(buff, &model{
 		RaftPort:          raftPort,
 		LogServicePort:    logServicePort,
 		GossipPort:        gossipPort,
-		ConfigFilePath:    fmt.Sprintf("%s/%s", configPath, configFile),
+		ConfigFilePath:    fmt.Sprintf("%s/%s", configPath, configFileName),
 		BootstrapFilePath: fmt.Sprintf("%s/%s", bootstrapPath, bootstrapFile),
 		GossipFilePath:    fmt.Sprintf("%s/%s", gossipPath, gossipFile),
 	})
@@ -161,11 +167,11 @@ func buildConfigMap(ls *v1alpha1.LogSet) (*corev1.ConfigMap, error) {
 		ObjectMeta: metav1.ObjectMeta{
 			Namespace: ls.Namespace,
 			Name:      configMapName(ls),
-			Labels:    common.SubResourceLabels(ls),
+			Labels:    common.ResourceLabels(ls),
 		},
 		Data: map[string]string{
-			configFile: s,
-			entrypoint: buff.String(),
+			configFileName: s,
+			common.EntrypointFileName(&ls.Spec.PodSet): buff.String(),
 		},
 	}, nil
 }
@@ -202,9 +208,9 @@ func deploymentID(ls *v1alpha1.LogSet) uint64 {
 }
 
 func configMapName(ls *v1alpha1.LogSet) string {
-	return resourceName(ls) + "-config"
+	return common.ResourceName(resourceName(ls), "-config", common.MaxConfigMapNameLength)
 }
 
 func gossipConfigMapName(ls *v1alpha1.LogSet) string {
-	return resourceName(ls) + "-gossip"
+	return common.ResourceName(resourceName(ls), "-gossip", common.MaxConfigMapNameLength)
 }
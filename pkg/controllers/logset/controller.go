@@ -26,6 +26,7 @@ import (
 	"github.com/matrixorigin/controller-runtime/pkg/util"
 	"github.com/matrixorigin/matrixone-operator/api/core/v1alpha1"
 	"github.com/matrixorigin/matrixone-operator/pkg/controllers/common"
+	"github.com/matrixorigin/matrixone-operator/pkg/metrics"
 	kruisev1 "github.com/openkruise/kruise-api/apps/v1beta1"
 	"github.com/pkg/errors"
 	"github.com/samber/lo"
@@ -33,11 +34,14 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 const (
-	reSyncAfter = 15 * time.Second
+	// statusPollJitter is the max factor of the jitter added to the status poll interval, so that
+	// the logsets created together do not poll the stores at the same time
+	statusPollJitter = 0.1
 
 	// failoverDeletionFinalizer hold the pod that chosen to be deleted until human confirmation
 	failoverDeletionFinalizer = "matrixorigin.io/confirm-deletion"
@@ -62,6 +66,9 @@ func (r *Actor) Observe(ctx *recon.Context[*v1alpha1.LogSet]) (recon.Action[*v1a
 	ls := ctx.Obj
 
 	ctx.Log.Info("observe logset")
+	if err := common.CheckS3CABundle(ctx, ls.Spec.SharedStorage.S3, &ls.Status.ConditionalStatus); err != nil {
+		return nil, err
+	}
 	// get subresources
 	discoverySvc := &corev1.Service{}
 	err, foundDiscovery := util.IsFound(ctx.Get(client.ObjectKey{Namespace: ls.Namespace, Name: discoverySvcName(ls)}, discoverySvc))
@@ -76,6 +83,16 @@ func (r *Actor) Observe(ctx *recon.Context[*v1alpha1.LogSet]) (recon.Action[*v1a
 	if !foundDiscovery || !foundSts {
 		return r.Create, nil
 	}
+	if err := common.SyncHeadlessService(ctx, buildHeadlessSvc(ls)); err != nil {
+		return nil, errors.Wrap(err, "sync logservice headless service")
+	}
+	originSvc := discoverySvc.DeepCopy()
+	syncDiscoveryServiceMeta(ls, discoverySvc)
+	if !equality.Semantic.DeepEqual(originSvc, discoverySvc) {
+		if err := ctx.Update(discoverySvc); err != nil {
+			return nil, errors.Wrap(err, "sync HAKeeper discovery service")
+		}
+	}
 
 	// calculate status
 	podList := &corev1.PodList{}
@@ -84,9 +101,16 @@ func (r *Actor) Observe(ctx *recon.Context[*v1alpha1.LogSet]) (recon.Action[*v1a
 	if err != nil {
 		return nil, errors.Wrap(err, "list logservice pods")
 	}
+	if err := common.SyncStoreUUIDLabels(ctx, podList.Items, common.StoreTypeLogService); err != nil {
+		return nil, err
+	}
 
-	common.CollectStoreStatus(&ls.Status.FailoverStatus, podList.Items)
+	common.CollectStoreStatus(&ls.Status.FailoverStatus, podList.Items, ls.Spec.GetStoreFailureDetectionDelay().Duration, common.ForceFailover(ls))
+	ls.Status.PodSummary = common.CollectPodSummary(podList.Items)
+	common.CollectImagePullStatus(&ls.Status.ConditionalStatus, podList.Items, ls.Spec.GetImagePullDeadline())
+	ls.Status.ResolvedImage = common.ResolvedImage(&sts.Spec.Template)
 	if len(ls.Status.AvailableStores) >= int(ls.Spec.Replicas) {
+		ls.Status.Bootstrapped = true
 		ls.Status.SetCondition(metav1.Condition{
 			Type:   recon.ConditionTypeReady,
 			Status: metav1.ConditionTrue,
@@ -98,12 +122,9 @@ func (r *Actor) Observe(ctx *recon.Context[*v1alpha1.LogSet]) (recon.Action[*v1a
 			Reason: common.ReasonNoEnoughReadyStores,
 		})
 	}
-	ls.Status.Discovery = &v1alpha1.LogSetDiscovery{
-		Port:    logServicePort,
-		Address: discoverySvcAddress(ls),
-	}
+	ls.Status.Discovery = Discovery(ls)
 	switch {
-	case len(ls.Status.StoresFailedFor(ls.Spec.GetStoreFailureTimeout().Duration)) > 0:
+	case len(ls.Status.StoresFailedFor(storeFailoverTimeout(ls))) > 0:
 		return r.with(sts).Repair, nil
 	case ls.Spec.Replicas != *sts.Spec.Replicas:
 		return r.with(sts).Scale, nil
@@ -116,11 +137,11 @@ func (r *Actor) Observe(ctx *recon.Context[*v1alpha1.LogSet]) (recon.Action[*v1a
 	if !equality.Semantic.DeepEqual(origin, sts) {
 		return r.with(sts).Update, nil
 	}
-	if recon.IsReady(&ls.Status.ConditionalStatus) && len(ls.Status.FailedStores) == 0 {
+	if recon.IsReady(&ls.Status.ConditionalStatus) && len(ls.Status.FailedStores) == 0 && len(ls.Status.SuspectedStores) == 0 {
 		ctx.Log.Info("logset synced")
 		return nil, nil
 	}
-	return nil, recon.ErrReSync("logset is not ready or has unready members", reSyncAfter)
+	return nil, recon.ErrReSync("logset is not ready or has unready members", wait.Jitter(ls.Spec.GetStatusPollInterval().Duration, statusPollJitter))
 }
 
 func (r *Actor) Create(ctx *recon.Context[*v1alpha1.LogSet]) error {
@@ -132,6 +153,28 @@ func (r *Actor) Create(ctx *recon.Context[*v1alpha1.LogSet]) error {
 	if err != nil {
 		return err
 	}
+	objs, err := RenderManifests(ls)
+	common.SetConfigBuiltCondition(&ls.Status.ConditionalStatus, err)
+	if err != nil {
+		return err
+	}
+
+	// create all resources
+	err = lo.Reduce[client.Object, error](append([]client.Object{bc}, objs...), func(errs error, o client.Object, _ int) error {
+		err := ctx.CreateOwned(o)
+		// ignore already exist during creation, updating of the underlying resources should be
+		// done carefully in other Actions since updating might be destructive
+		return multierr.Append(errs, util.Ignore(apierrors.IsAlreadyExists, err))
+	}, nil)
+	if err != nil {
+		return errors.Wrap(err, "create")
+	}
+	return nil
+}
+
+// RenderManifests renders the underlying objects of the LogSet without touching the cluster,
+// the bootstrap config is not included since it is decided at runtime
+func RenderManifests(ls *v1alpha1.LogSet) ([]client.Object, error) {
 	svc := buildHeadlessSvc(ls)
 	sts := buildStatefulSet(ls, svc)
 	syncReplicas(ls, sts)
@@ -141,40 +184,33 @@ func (r *Actor) Create(ctx *recon.Context[*v1alpha1.LogSet]) error {
 	discovery := buildDiscoveryService(ls)
 	gconfig, err := buildGossipSeedsConfigMap(ls, sts)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	// sync the config
-	cm, err := buildConfigMap(ls)
+	configMap, err := buildConfigMap(ls)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	if err := common.SyncConfigMap(ctx, &sts.Spec.Template.Spec, cm); err != nil {
-		return err
+	ep, err := common.RenderEntrypointConfigMap(&ls.Spec.PodSet, &sts.Spec.Template.Spec, configMap, common.EntrypointFileName(&ls.Spec.PodSet))
+	if err != nil {
+		return nil, err
 	}
-
-	// create all resources
-	err = lo.Reduce[client.Object, error]([]client.Object{
-		bc,
-		gconfig,
-		svc,
-		sts,
-		discovery,
-	}, func(errs error, o client.Object, _ int) error {
-		err := ctx.CreateOwned(o)
-		// ignore already exist during creation, updating of the underlying resources should be
-		// done carefully in other Actions since updating might be destructive
-		return multierr.Append(errs, util.Ignore(apierrors.IsAlreadyExists, err))
-	}, nil)
+	cm, err := common.RenderConfigMap(&sts.Spec.Template.Spec, configMap, common.ConfigFileName(&ls.Spec.PodSet, configFile))
 	if err != nil {
-		return errors.Wrap(err, "create")
+		return nil, err
 	}
-	return nil
+	objs := []client.Object{gconfig, cm, svc, sts, discovery}
+	if ep != nil {
+		objs = append(objs, ep)
+	}
+	return objs, nil
 }
 
 // Scale scale-out/in the log set pods to match the desired state
 // TODO(aylei): special treatment for scale-in
 func (r *WithResources) Scale(ctx *recon.Context[*v1alpha1.LogSet]) error {
 	ctx.Log.Info("scale logset")
+	from := *r.sts.Spec.Replicas
 	err := ctx.Patch(r.sts, func() error {
 		syncReplicas(ctx.Obj, r.sts)
 		return nil
@@ -182,6 +218,7 @@ func (r *WithResources) Scale(ctx *recon.Context[*v1alpha1.LogSet]) error {
 	if err != nil {
 		return err
 	}
+	common.EmitScaleEvent(ctx.Event, from, ctx.Obj.Spec.Replicas)
 	// also update gossip config after scale
 	return updateGossipConfig(ctx, r.sts)
 }
@@ -196,7 +233,7 @@ func (r *WithResources) Repair(ctx *recon.Context[*v1alpha1.LogSet]) error {
 		ctx.Log.Info("majority failure might happen, wait for human intervention")
 		return nil
 	}
-	toRepair := ctx.Obj.Status.StoresFailedFor(ctx.Obj.Spec.GetStoreFailureTimeout().Duration)
+	toRepair := ctx.Obj.Status.StoresFailedFor(storeFailoverTimeout(ctx.Obj))
 	if len(toRepair) == 0 {
 		return nil
 	}
@@ -230,6 +267,7 @@ func (r *WithResources) Repair(ctx *recon.Context[*v1alpha1.LogSet]) error {
 	if err := ctx.Update(r.sts); err != nil {
 		return err
 	}
+	common.EmitFailoverEvent(ctx.Event, candidate.PodName)
 	// also update gossip config after failover
 	return updateGossipConfig(ctx, r.sts)
 }
@@ -237,7 +275,11 @@ func (r *WithResources) Repair(ctx *recon.Context[*v1alpha1.LogSet]) error {
 // Update rolling-update the log set pods to match the desired state
 // TODO(aylei): should logset controller take care of graceful rolling?
 func (r *WithResources) Update(ctx *recon.Context[*v1alpha1.LogSet]) error {
-	return ctx.Update(r.sts)
+	if err := ctx.Update(r.sts); err != nil {
+		return err
+	}
+	common.EmitUpgradeEvent(ctx.Event, ctx.Obj.Status.ResolvedImage, common.ResolvedImage(&r.sts.Spec.Template))
+	return nil
 }
 
 func (r *Actor) Finalize(ctx *recon.Context[*v1alpha1.LogSet]) (bool, error) {
@@ -305,18 +347,28 @@ func updateGossipConfig(ctx *recon.Context[*v1alpha1.LogSet], sts *kruisev1.Stat
 	})
 }
 
+// storeFailoverTimeout returns how long a store must have been down before it is failed over,
+// the failure timeout counts from the store being confirmed failed after the detection delay
+func storeFailoverTimeout(ls *v1alpha1.LogSet) time.Duration {
+	return ls.Spec.GetStoreFailureDetectionDelay().Duration + ls.Spec.GetStoreFailureTimeout().Duration
+}
+
 func syncPods(ctx *recon.Context[*v1alpha1.LogSet], sts *kruisev1.StatefulSet) error {
 	cm, err := buildConfigMap(ctx.Obj)
+	common.SetConfigBuiltCondition(&ctx.Obj.Status.ConditionalStatus, err)
 	if err != nil {
 		return err
 	}
 	syncPodMeta(ctx.Obj, sts)
 	syncPodSpec(ctx.Obj, &sts.Spec.Template.Spec)
-	return common.SyncConfigMap(ctx, &sts.Spec.Template.Spec, cm)
+	if err := common.SyncEntrypointConfigMap(ctx, &ctx.Obj.Spec.PodSet, &sts.Spec.Template.Spec, cm, common.EntrypointFileName(&ctx.Obj.Spec.PodSet)); err != nil {
+		return err
+	}
+	return common.SyncConfigMap(ctx, &sts.Spec.Template.Spec, cm, common.ConfigFileName(&ctx.Obj.Spec.PodSet, configFile))
 }
 
 func (r *Actor) Reconcile(mgr manager.Manager) error {
-	return recon.Setup[*v1alpha1.LogSet](&v1alpha1.LogSet{}, "logset", mgr, r,
+	return recon.Setup[*v1alpha1.LogSet](&v1alpha1.LogSet{}, "logset", mgr, metrics.Instrument[*v1alpha1.LogSet]("logset", r),
 		recon.WithBuildFn(func(b *builder.Builder) {
 			// watch all changes on the owned statefulset since we need perform failover if there is a pod failure
 			b.Owns(&kruisev1.StatefulSet{}).
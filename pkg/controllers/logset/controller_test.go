@@ -206,6 +206,23 @@ func TestLogSetActor_Observe(t *testing.T) {
 	}
 }
 
+func TestStoreFailoverTimeout(t *testing.T) {
+	g := NewGomegaWithT(t)
+	ls := &v1alpha1.LogSet{}
+	ls.Spec.StoreFailureTimeout = &metav1.Duration{Duration: 10 * time.Minute}
+	g.Expect(storeFailoverTimeout(ls)).To(Equal(10 * time.Minute))
+
+	// the failure timeout counts from the store being confirmed failed
+	ls.Spec.StoreFailureDetectionDelay = &metav1.Duration{Duration: 5 * time.Minute}
+	g.Expect(storeFailoverTimeout(ls)).To(Equal(15 * time.Minute))
+	status := &v1alpha1.FailoverStatus{FailedStores: []v1alpha1.Store{{
+		PodName:            "test-log-0",
+		Phase:              v1alpha1.StorePhaseDown,
+		LastTransitionTime: metav1.Time{Time: time.Now().Add(-12 * time.Minute)},
+	}}}
+	g.Expect(status.StoresFailedFor(storeFailoverTimeout(ls))).To(BeEmpty())
+}
+
 func TestLogSetActor_Create(t *testing.T) {
 	type args struct {
 		ctx *recon.Context[*v1alpha1.LogSet]
@@ -23,11 +23,10 @@ import (
 )
 
 func buildDiscoveryService(ls *v1alpha1.LogSet) *corev1.Service {
-	return &corev1.Service{
+	svc := &corev1.Service{
 		ObjectMeta: metav1.ObjectMeta{
 			Namespace: ls.Namespace,
 			Name:      discoverySvcName(ls),
-			Labels:    common.SubResourceLabels(ls),
 		},
 		Spec: corev1.ServiceSpec{
 			Ports: []corev1.ServicePort{{
@@ -39,13 +38,43 @@ func buildDiscoveryService(ls *v1alpha1.LogSet) *corev1.Service {
 			Selector: common.SubResourceLabels(ls),
 		},
 	}
+	syncDiscoveryServiceMeta(ls, svc)
+	return svc
+}
+
+// syncDiscoveryServiceMeta syncs the labels and annotations of the discovery service
+func syncDiscoveryServiceMeta(ls *v1alpha1.LogSet, svc *corev1.Service) {
+	labels := common.InheritedLabels(ls)
+	var annotations map[string]string
+	if ds := ls.Spec.DiscoveryService; ds != nil {
+		for k, v := range ds.Labels {
+			labels[k] = v
+		}
+		annotations = ds.Annotations
+	}
+	for k, v := range common.SubResourceLabels(ls) {
+		labels[k] = v
+	}
+	svc.Labels = labels
+	svc.Annotations = annotations
 }
 
 func discoverySvcName(ls *v1alpha1.LogSet) string {
-	return resourceName(ls) + "-discovery"
+	return common.ResourceName(resourceName(ls), "-discovery", common.MaxServiceNameLength)
+}
+
+// Discovery returns the HAKeeper discovery info of the logset
+func Discovery(ls *v1alpha1.LogSet) *v1alpha1.LogSetDiscovery {
+	return &v1alpha1.LogSetDiscovery{
+		Port:    logServicePort,
+		Address: discoverySvcAddress(ls),
+	}
 }
 
 func discoverySvcAddress(ls *v1alpha1.LogSet) string {
+	if ls.Spec.DiscoveryService != nil && ls.Spec.DiscoveryService.Address != "" {
+		return ls.Spec.DiscoveryService.Address
+	}
 	// TODO(aylei): we need FQDN (name.ns.svc.cluster.${clusterName}) for cross-cluster dns resolution
 	return fmt.Sprintf("%s.%s.svc", discoverySvcName(ls), ls.Namespace)
 }
@@ -0,0 +1,51 @@
+// Copyright 2023 Matrix Origin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logset
+
+import (
+	"testing"
+
+	"github.com/matrixorigin/matrixone-operator/api/core/v1alpha1"
+	"github.com/matrixorigin/matrixone-operator/pkg/controllers/common"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_buildDiscoveryService(t *testing.T) {
+	g := NewGomegaWithT(t)
+	ls := &v1alpha1.LogSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      "test",
+		},
+	}
+	svc := buildDiscoveryService(ls)
+	g.Expect(svc.Labels).To(Equal(common.SubResourceLabels(ls)))
+	g.Expect(svc.Annotations).To(BeEmpty())
+	g.Expect(Discovery(ls).Address).To(Equal("test-log-discovery.default.svc"))
+
+	ls.Spec.DiscoveryService = &v1alpha1.DiscoveryService{
+		Labels:      map[string]string{"mesh": "enabled"},
+		Annotations: map[string]string{"external-dns.alpha.kubernetes.io/hostname": "hakeeper.example.com"},
+		Address:     "hakeeper.example.com",
+	}
+	syncDiscoveryServiceMeta(ls, svc)
+	g.Expect(svc.Labels).To(HaveKeyWithValue("mesh", "enabled"))
+	for k, v := range common.SubResourceLabels(ls) {
+		g.Expect(svc.Labels).To(HaveKeyWithValue(k, v))
+	}
+	g.Expect(svc.Annotations).To(HaveKeyWithValue("external-dns.alpha.kubernetes.io/hostname", "hakeeper.example.com"))
+	g.Expect(Discovery(ls).Address).To(Equal("hakeeper.example.com"))
+}
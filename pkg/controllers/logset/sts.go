@@ -14,16 +14,15 @@
 package logset
 
 import (
-	"fmt"
 	"github.com/matrixorigin/controller-runtime/pkg/util"
 	"github.com/matrixorigin/matrixone-operator/api/core/v1alpha1"
 	"github.com/matrixorigin/matrixone-operator/pkg/controllers/common"
-	"github.com/openkruise/kruise-api/apps/pub"
 	kruisev1 "github.com/openkruise/kruise-api/apps/v1beta1"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 const (
@@ -60,18 +59,20 @@ func syncPodSpec(ls *v1alpha1.LogSet, specRef *corev1.PodSpec) {
 	mainRef := util.FindFirst(specRef.Containers, func(c corev1.Container) bool {
 		return c.Name == v1alpha1.ContainerMain
 	})
+	existing := mainRef != nil
 	if mainRef == nil {
 		mainRef = &corev1.Container{Name: v1alpha1.ContainerMain}
 	}
 	mainRef.Image = ls.Spec.Image
 	mainRef.Resources = ls.Spec.Resources
-	mainRef.Command = []string{"/bin/sh", fmt.Sprintf("%s/%s", configPath, entrypoint)}
+	mainRef.Args = ls.Spec.ExtraServiceArgs
 	mainRef.VolumeMounts = []corev1.VolumeMount{
 		{Name: common.DataVolume, MountPath: common.DataPath},
 		{Name: bootstrapVolume, ReadOnly: true, MountPath: bootstrapPath},
 		{Name: configVolume, ReadOnly: true, MountPath: configPath},
 		{Name: gossipVolume, ReadOnly: true, MountPath: gossipPath},
 	}
+	common.SyncEntrypoint(&ls.Spec.PodSet, mainRef, configPath, common.EntrypointFileName(&ls.Spec.PodSet))
 	mainRef.Env = []corev1.EnvVar{
 		util.FieldRefEnv(PodNameEnvKey, "metadata.name"),
 		util.FieldRefEnv(NamespaceEnvKey, "metadata.namespace"),
@@ -81,6 +82,7 @@ func syncPodSpec(ls *v1alpha1.LogSet, specRef *corev1.PodSpec) {
 	//if ls.Spec.DNSBasedIdentity {
 	//	mainRef.Env = append(mainRef.Env, corev1.EnvVar{Name: "HOSTNAME_UUID", Value: "y"})
 	//}
+	common.SyncContainerSecurityContext(ls.Spec.ContainerSecurityContext, mainRef)
 	ls.Spec.Overlay.OverlayMainContainer(mainRef)
 
 	specRef.Containers = []corev1.Container{*mainRef}
@@ -95,12 +97,15 @@ func syncPodSpec(ls *v1alpha1.LogSet, specRef *corev1.PodSpec) {
 		Name:         gossipVolume,
 		VolumeSource: util.ConfigMapVolume(gossipConfigMapName(ls)),
 	}}
-	specRef.ReadinessGates = []corev1.PodReadinessGate{{
-		ConditionType: pub.InPlaceUpdateReady,
-	}}
+	common.SyncReadinessGates(&ls.Spec.PodSet, specRef, false)
 	specRef.NodeSelector = ls.Spec.NodeSelector
+	specRef.ServiceAccountName = ls.Spec.ServiceAccountName
 	common.SetStorageProviderConfig(ls.Spec.SharedStorage, specRef)
-	common.SyncTopology(ls.Spec.TopologyEvenSpread, specRef)
+	common.SyncTopology(ls.Spec.TopologyEvenSpread, ls.Spec.TopologySpreadPolicy, ls, specRef)
+	common.SyncSysctls(ls.Spec.Sysctls, specRef)
+	common.SyncTmpVolume(ls.Spec.TmpVolume, specRef)
+	common.SyncEphemeralStorage(ls.Spec.EphemeralStorage, specRef)
+	common.SyncAntiAffinity(common.AntiAffinityPolicyOf(&ls.Spec.PodSet, ls, specRef, existing), ls, specRef)
 	ls.Spec.Overlay.OverlayPodSpec(specRef)
 }
 
@@ -121,12 +126,23 @@ func syncPersistentVolumeClaim(ls *v1alpha1.LogSet, sts *kruisev1.StatefulSet) {
 		},
 	}
 	tpls := []corev1.PersistentVolumeClaim{dataPVC}
+	common.SyncVolumeMetadata(ls.Spec.VolumeMetadata, tpls)
 	ls.Spec.Overlay.AppendVolumeClaims(&tpls)
 	sts.Spec.VolumeClaimTemplates = tpls
 }
 
 // syncStatefulSetSpec syncs the statefulset to the current desired state
 func syncStatefulSetSpec(ls *v1alpha1.LogSet, sts *kruisev1.StatefulSet) {
+	common.SyncUpdateStrategy(&ls.Spec.PodSet, sts)
+	common.SyncRevisionHistoryLimit(&ls.Spec.PodSet, sts)
+	if ru := sts.Spec.UpdateStrategy.RollingUpdate; ru != nil {
+		ru.MaxUnavailable = nil
+		// the pods are updated one by one until the quorum of each log shard is formed
+		if p := ls.Spec.RollingUpdatePolicy; p != nil && ls.Status.Bootstrapped {
+			maxUnavailable := intstr.FromInt(int(p.MaxUnavailable))
+			ru.MaxUnavailable = &maxUnavailable
+		}
+	}
 	switch ls.Spec.GetPVCRetentionPolicy() {
 	case v1alpha1.PVCRetentionPolicyDelete:
 		sts.Spec.PersistentVolumeClaimRetentionPolicy = &kruisev1.StatefulSetPersistentVolumeClaimRetentionPolicy{
@@ -147,7 +163,7 @@ func buildStatefulSet(ls *v1alpha1.LogSet, headlessSvc *corev1.Service) *kruisev
 		ObjectMeta: metav1.ObjectMeta{
 			Namespace: ls.Namespace,
 			Name:      stsName(ls),
-			Labels:    common.SubResourceLabels(ls),
+			Labels:    common.ResourceLabels(ls),
 		},
 		Spec: kruisev1.StatefulSetSpec{
 			ServiceName: headlessSvc.Name,
@@ -175,17 +191,20 @@ func buildStatefulSet(ls *v1alpha1.LogSet, headlessSvc *corev1.Service) *kruisev
 
 // buildHeadlessSvc build the initial headless service object for the given logset
 func buildHeadlessSvc(ls *v1alpha1.LogSet) *corev1.Service {
-	return common.HeadlessServiceTemplate(ls, headlessSvcName(ls))
+	return common.HeadlessServiceTemplate(ls, headlessSvcName(ls), &ls.Spec.PodSet)
 }
 
 func stsName(ls *v1alpha1.LogSet) string {
-	return resourceName(ls)
+	return common.ResourceName(resourceName(ls), "", common.MaxStatefulSetNameLength)
 }
 
 func headlessSvcName(ls *v1alpha1.LogSet) string {
-	return resourceName(ls) + "-headless"
+	return common.ResourceName(resourceName(ls), "-headless", common.MaxServiceNameLength)
 }
 
 func resourceName(ls *v1alpha1.LogSet) string {
+	if ls.Spec.NameOverride != "" {
+		return ls.Spec.NameOverride
+	}
 	return ls.Name + logSuffix
 }
@@ -159,6 +159,19 @@ func Test_syncPodSpec(t *testing.T) {
 				TopologyKey:       "zone",
 				WhenUnsatisfiable: corev1.DoNotSchedule,
 			}},
+			Affinity: &corev1.Affinity{
+				PodAntiAffinity: &corev1.PodAntiAffinity{
+					PreferredDuringSchedulingIgnoredDuringExecution: []corev1.WeightedPodAffinityTerm{{
+						Weight: 100,
+						PodAffinityTerm: corev1.PodAffinityTerm{
+							LabelSelector: &metav1.LabelSelector{
+								MatchLabels: common.SubResourceLabels(&v1alpha1.LogSet{ObjectMeta: lsMeta}),
+							},
+							TopologyKey: corev1.LabelHostname,
+						},
+					}},
+				},
+			},
 		},
 	}}
 	for _, tt := range tests {
@@ -263,6 +276,54 @@ func Test_buildStatefulSet(t *testing.T) {
 				},
 			},
 		},
+	}, {
+		name: "revision history limit",
+		args: args{
+			ls: &v1alpha1.LogSet{
+				ObjectMeta: lsMeta,
+				Spec: v1alpha1.LogSetSpec{
+					LogSetBasic: v1alpha1.LogSetBasic{
+						PodSet: v1alpha1.PodSet{RevisionHistoryLimit: pointer.Int32(3)},
+					},
+				},
+			},
+			headlessSvc: &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "test-svc",
+				},
+			},
+		},
+		want: &kruisev1.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-log",
+				Namespace: "default",
+				Labels:    labels,
+			},
+			Spec: kruisev1.StatefulSetSpec{
+				ServiceName: "test-svc",
+				UpdateStrategy: kruisev1.StatefulSetUpdateStrategy{
+					Type: appsv1.RollingUpdateStatefulSetStrategyType,
+					RollingUpdate: &kruisev1.RollingUpdateStatefulSetStrategy{
+						PodUpdatePolicy: kruisev1.InPlaceIfPossiblePodUpdateStrategyType,
+					},
+				},
+				RevisionHistoryLimit: pointer.Int32(3),
+				PodManagementPolicy:  appsv1.ParallelPodManagement,
+				Selector: &metav1.LabelSelector{
+					MatchLabels: labels,
+				},
+				PersistentVolumeClaimRetentionPolicy: &kruisev1.StatefulSetPersistentVolumeClaimRetentionPolicy{
+					WhenDeleted: kruisev1.DeletePersistentVolumeClaimRetentionPolicyType,
+					WhenScaled:  kruisev1.DeletePersistentVolumeClaimRetentionPolicyType,
+				},
+				Template: corev1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{
+						Labels:      labels,
+						Annotations: map[string]string{},
+					},
+				},
+			},
+		},
 	}}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -279,14 +340,61 @@ func Test_syncPersistentVolumeClaim(t *testing.T) {
 		sts *kruisev1.StatefulSet
 	}
 	tests := []struct {
-		name string
-		args args
-	}{
-		// TODO: Add test cases.
-	}
+		name            string
+		args            args
+		wantLabels      map[string]string
+		wantAnnotations map[string]string
+	}{{
+		name: "volume metadata",
+		args: args{
+			ls: &v1alpha1.LogSet{
+				ObjectMeta: lsMeta,
+				Spec: v1alpha1.LogSetSpec{
+					LogSetBasic: v1alpha1.LogSetBasic{
+						PodSet: v1alpha1.PodSet{
+							VolumeMetadata: &v1alpha1.VolumeMetadata{
+								Labels:      map[string]string{"backup": "true"},
+								Annotations: map[string]string{"backup.velero.io/backup-volumes": "mo-data"},
+							},
+						},
+						Volume: v1alpha1.Volume{Size: resource.MustParse("10Gi")},
+					},
+				},
+			},
+			sts: &kruisev1.StatefulSet{},
+		},
+		wantLabels:      map[string]string{"backup": "true"},
+		wantAnnotations: map[string]string{"backup.velero.io/backup-volumes": "mo-data"},
+	}}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			syncPersistentVolumeClaim(tt.args.ls, tt.args.sts)
+			tpl := tt.args.sts.Spec.VolumeClaimTemplates[0]
+			if diff := cmp.Diff(tpl.Labels, tt.wantLabels); diff != "" {
+				t.Errorf("syncPersistentVolumeClaim(...): -want labels, +got:\n%s", diff)
+			}
+			if diff := cmp.Diff(tpl.Annotations, tt.wantAnnotations); diff != "" {
+				t.Errorf("syncPersistentVolumeClaim(...): -want annotations, +got:\n%s", diff)
+			}
 		})
 	}
 }
+
+func Test_syncStatefulSetSpec_rollingUpdatePolicy(t *testing.T) {
+	ls := &v1alpha1.LogSet{
+		ObjectMeta: lsMeta,
+		Spec: v1alpha1.LogSetSpec{LogSetBasic: v1alpha1.LogSetBasic{
+			RollingUpdatePolicy: &v1alpha1.LogSetRollingUpdatePolicy{MaxUnavailable: 2},
+		}},
+	}
+	sts := &kruisev1.StatefulSet{}
+	syncStatefulSetSpec(ls, sts)
+	if sts.Spec.UpdateStrategy.RollingUpdate.MaxUnavailable != nil {
+		t.Errorf("pods must be updated one by one before the logset is bootstrapped, got %v", sts.Spec.UpdateStrategy.RollingUpdate.MaxUnavailable)
+	}
+	ls.Status.Bootstrapped = true
+	syncStatefulSetSpec(ls, sts)
+	if mu := sts.Spec.UpdateStrategy.RollingUpdate.MaxUnavailable; mu == nil || mu.IntValue() != 2 {
+		t.Errorf("expected maxUnavailable 2 after the logset is bootstrapped, got %v", mu)
+	}
+}
@@ -0,0 +1,141 @@
+// Copyright 2023 Matrix Origin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mocluster
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	recon "github.com/matrixorigin/controller-runtime/pkg/reconciler"
+	"github.com/matrixorigin/matrixone-operator/api/core/v1alpha1"
+	"github.com/matrixorigin/matrixone-operator/pkg/controllers/cnset"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// bootstrapSQLTimeout bounds the time to connect to the cluster and execute the bootstrap SQL
+	bootstrapSQLTimeout = 5 * time.Minute
+)
+
+// execSQL executes the SQL script in a single session of the database
+var execSQL = func(ctx context.Context, dsn string, script string) error {
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	_, err = conn.ExecContext(ctx, script)
+	return err
+}
+
+// ExecuteBootstrapSQL executes the bootstrap SQL script against the TP CN set of the cluster and
+// records the completion in status. The action is retried with the whole script on failure.
+func (r *MatrixOneClusterActor) ExecuteBootstrapSQL(ctx *recon.Context[*v1alpha1.MatrixOneCluster]) error {
+	mo := ctx.Obj
+	script, err := loadBootstrapSQL(ctx)
+	if err != nil {
+		return err
+	}
+	if script != "" {
+		dsn, err := clusterDSN(ctx)
+		if err != nil {
+			return err
+		}
+		timeoutCtx, cancel := context.WithTimeout(ctx, bootstrapSQLTimeout)
+		defer cancel()
+		if err := execSQL(timeoutCtx, dsn, script); err != nil {
+			return errors.Wrap(err, "execute bootstrap SQL")
+		}
+		ctx.Log.Info("bootstrap SQL executed")
+	}
+	mo.Status.BootstrapSQLExecuted = true
+	return ctx.UpdateStatus(mo)
+}
+
+// clusterDSN returns the DSN to connect to the TP CN set of the cluster with the initial credential
+func clusterDSN(ctx *recon.Context[*v1alpha1.MatrixOneCluster]) (string, error) {
+	mo := ctx.Obj
+	sec := &corev1.Secret{}
+	if err := ctx.Get(types.NamespacedName{Namespace: mo.Namespace, Name: mo.Status.CredentialRef.Name}, sec); err != nil {
+		return "", errors.Wrap(err, "get cluster credential")
+	}
+	tp := &v1alpha1.CNSet{ObjectMeta: tpSetKey(mo)}
+	cfg := mysql.NewConfig()
+	cfg.User = string(sec.Data[usernameKey])
+	cfg.Passwd = string(sec.Data[passwordKey])
+	cfg.Net = "tcp"
+	cfg.Addr = fmt.Sprintf("%s.%s:%d", cnset.ServiceName(tp), mo.Namespace, cnset.CNSQLPort)
+	cfg.MultiStatements = true
+	return cfg.FormatDSN(), nil
+}
+
+// loadBootstrapSQL reads the bootstrap SQL script from the referenced ConfigMap or Secret, an empty
+// script is returned if an optional reference is missing
+func loadBootstrapSQL(ctx *recon.Context[*v1alpha1.MatrixOneCluster]) (string, error) {
+	mo := ctx.Obj
+	b := mo.Spec.BootstrapSQL
+	if ref := b.ConfigMapKeyRef; ref != nil {
+		cm := &corev1.ConfigMap{}
+		if err := ctx.Get(types.NamespacedName{Namespace: mo.Namespace, Name: ref.Name}, cm); err != nil {
+			if apierrors.IsNotFound(err) {
+				return "", optionalOrErr(ref.Optional, errors.Errorf("bootstrap SQL ConfigMap %s not found", ref.Name))
+			}
+			return "", err
+		}
+		script, ok := cm.Data[ref.Key]
+		if !ok {
+			if ref.Optional != nil && *ref.Optional {
+				return "", nil
+			}
+			return "", errors.Errorf("key %s not found in bootstrap SQL ConfigMap %s", ref.Key, ref.Name)
+		}
+		return script, nil
+	}
+	ref := b.SecretKeyRef
+	sec := &corev1.Secret{}
+	if err := ctx.Get(types.NamespacedName{Namespace: mo.Namespace, Name: ref.Name}, sec); err != nil {
+		if apierrors.IsNotFound(err) {
+			return "", optionalOrErr(ref.Optional, errors.Errorf("bootstrap SQL Secret %s not found", ref.Name))
+		}
+		return "", err
+	}
+	script, ok := sec.Data[ref.Key]
+	if !ok {
+		if ref.Optional != nil && *ref.Optional {
+			return "", nil
+		}
+		return "", errors.Errorf("key %s not found in bootstrap SQL Secret %s", ref.Key, ref.Name)
+	}
+	return string(script), nil
+}
+
+// optionalOrErr ignores the error of a missing script if the reference is optional
+func optionalOrErr(optional *bool, err error) error {
+	if optional != nil && *optional {
+		return nil
+	}
+	return err
+}
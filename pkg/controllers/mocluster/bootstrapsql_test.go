@@ -0,0 +1,81 @@
+// Copyright 2023 Matrix Origin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mocluster
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/matrixorigin/controller-runtime/pkg/fake"
+	"github.com/matrixorigin/matrixone-operator/api/core/v1alpha1"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestExecuteBootstrapSQL(t *testing.T) {
+	g := NewGomegaWithT(t)
+	mo := &v1alpha1.MatrixOneCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"},
+		Spec: v1alpha1.MatrixOneClusterSpec{
+			BootstrapSQL: &v1alpha1.BootstrapSQL{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "bootstrap"},
+					Key:                  "init.sql",
+				},
+			},
+		},
+		Status: v1alpha1.MatrixOneClusterStatus{
+			CredentialRef: &corev1.LocalObjectReference{Name: "test-credential"},
+		},
+	}
+	credential := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-credential", Namespace: "default"},
+		Data:       map[string][]byte{usernameKey: []byte("dump"), passwordKey: []byte("111")},
+	}
+	script := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "bootstrap", Namespace: "default"},
+		Data:       map[string][]byte{"init.sql": []byte("CREATE DATABASE IF NOT EXISTS app;")},
+	}
+	var executed []string
+	defer func(f func(context.Context, string, string) error) { execSQL = f }(execSQL)
+	execSQL = func(_ context.Context, dsn string, script string) error {
+		g.Expect(dsn).To(ContainSubstring("dump:111@tcp(test-tp-cn.default:6001)"))
+		executed = append(executed, script)
+		return nil
+	}
+
+	r := &MatrixOneClusterActor{}
+	newContext := func(objs ...client.Object) (client.Client, error) {
+		cli := fake.KubeClientBuilder().WithScheme(newScheme()).WithObjects(append(objs, mo)...).Build()
+		ctx := fake.NewContext(mo, cli, fake.NewMockEventEmitter(gomock.NewController(t)))
+		return cli, r.ExecuteBootstrapSQL(ctx)
+	}
+
+	// the referenced script does not exist
+	_, err := newContext(credential)
+	g.Expect(err).To(MatchError(ContainSubstring("bootstrap SQL Secret bootstrap not found")))
+	g.Expect(executed).To(BeEmpty())
+
+	cli, err := newContext(credential, script)
+	g.Expect(err).To(Succeed())
+	g.Expect(executed).To(Equal([]string{"CREATE DATABASE IF NOT EXISTS app;"}))
+	updated := &v1alpha1.MatrixOneCluster{}
+	g.Expect(cli.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: "test"}, updated)).To(Succeed())
+	g.Expect(updated.Status.BootstrapSQLExecuted).To(BeTrue())
+}
@@ -0,0 +1,116 @@
+// Copyright 2023 Matrix Origin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mocluster
+
+import (
+	"fmt"
+	"time"
+
+	recon "github.com/matrixorigin/controller-runtime/pkg/reconciler"
+	"github.com/matrixorigin/controller-runtime/pkg/util"
+	"github.com/matrixorigin/matrixone-operator/api/core/v1alpha1"
+	"github.com/matrixorigin/matrixone-operator/pkg/controllers/common"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// reasonCNSetRemoved is the reason of the event emitted when a removed CN set is deleted after drained
+const reasonCNSetRemoved = "CNSetRemoved"
+
+// removeCNSet drains the pods of a CN set that is removed from the spec and deletes the set once the
+// grace period of the cluster elapses, the progress is recorded in the status of the cluster.
+// It returns whether the CN set is gone.
+func removeCNSet(ctx *recon.Context[*v1alpha1.MatrixOneCluster], key metav1.ObjectMeta) (bool, error) {
+	mo := ctx.Obj
+	cn := &v1alpha1.CNSet{}
+	err, found := util.IsFound(ctx.Get(client.ObjectKey{Namespace: key.Namespace, Name: key.Name}, cn))
+	if err != nil {
+		return false, errors.Wrap(err, "get removed CNSet")
+	}
+	if !found || !metav1.IsControlledBy(cn, mo) {
+		mo.Status.CNSetRemoval = nil
+		return true, nil
+	}
+	if cn.DeletionTimestamp != nil {
+		// wait the garbage collector to remove the CN set
+		return false, nil
+	}
+	removal := mo.Status.CNSetRemoval
+	if removal == nil || removal.Name != cn.Name {
+		removal = &v1alpha1.CNSetRemovalStatus{Name: cn.Name, DrainStartTime: metav1.Now()}
+		mo.Status.CNSetRemoval = removal
+	}
+	drained, err := setCNSetDrained(ctx, key, true)
+	if err != nil {
+		return false, err
+	}
+	removal.DrainedPods = drained
+	if time.Since(removal.DrainStartTime.Time) < mo.GetCNRemovalGracePeriod() {
+		return false, nil
+	}
+	if err := util.Ignore(apierrors.IsNotFound, ctx.Delete(cn)); err != nil {
+		return false, errors.Wrap(err, "delete removed CNSet")
+	}
+	ctx.Event.EmitEventGeneric(reasonCNSetRemoved, fmt.Sprintf("deleted CNSet %s after draining its pods", cn.Name), nil)
+	return false, nil
+}
+
+// cancelCNSetRemoval undrains the pods of the CN set if it is added back to the spec before deleted
+func cancelCNSetRemoval(ctx *recon.Context[*v1alpha1.MatrixOneCluster], key metav1.ObjectMeta) error {
+	removal := ctx.Obj.Status.CNSetRemoval
+	if removal == nil || removal.Name != key.Name {
+		return nil
+	}
+	if _, err := setCNSetDrained(ctx, key, false); err != nil {
+		return err
+	}
+	ctx.Obj.Status.CNSetRemoval = nil
+	return nil
+}
+
+// setCNSetDrained adds or removes the DrainAnnotation on the pods of the CN set and returns the
+// number of the pods that are drained afterwards
+func setCNSetDrained(ctx *recon.Context[*v1alpha1.MatrixOneCluster], key metav1.ObjectMeta, drain bool) (int32, error) {
+	// TypeMeta of the typed objects read from the client is empty, build the selector from the key
+	owner := &v1alpha1.CNSet{TypeMeta: typeMeta("CNSet"), ObjectMeta: key}
+	podList := &corev1.PodList{}
+	if err := ctx.List(podList, client.InNamespace(key.Namespace), client.MatchingLabels(common.SubResourceLabels(owner))); err != nil {
+		return 0, errors.Wrap(err, "list pods of CNSet")
+	}
+	var drained int32
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+		if common.IsDrained(pod) != drain {
+			err := ctx.Patch(pod, func() error {
+				if drain {
+					metav1.SetMetaDataAnnotation(&pod.ObjectMeta, v1alpha1.DrainAnnotation, "true")
+				} else {
+					delete(pod.Annotations, v1alpha1.DrainAnnotation)
+				}
+				return nil
+			})
+			if err != nil && !apierrors.IsNotFound(err) {
+				return 0, errors.Wrapf(err, "set drain annotation of pod %s", pod.Name)
+			}
+		}
+		if drain {
+			drained++
+		}
+	}
+	return drained, nil
+}
@@ -0,0 +1,87 @@
+// Copyright 2023 Matrix Origin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mocluster
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/matrixorigin/controller-runtime/pkg/fake"
+	"github.com/matrixorigin/matrixone-operator/api/core/v1alpha1"
+	"github.com/matrixorigin/matrixone-operator/pkg/controllers/common"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+func TestRemoveCNSet(t *testing.T) {
+	g := NewGomegaWithT(t)
+	s := newScheme()
+	mo := &v1alpha1.MatrixOneCluster{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test", UID: "mo-uid"},
+		Spec: v1alpha1.MatrixOneClusterSpec{
+			CNRemovalGracePeriod: &metav1.Duration{Duration: time.Minute},
+		},
+	}
+	ap := &v1alpha1.CNSet{TypeMeta: typeMeta("CNSet"), ObjectMeta: apSetKey(mo)}
+	g.Expect(controllerutil.SetControllerReference(mo, ap, s)).To(Succeed())
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Namespace: "default",
+		Name:      "test-ap-0",
+		Labels:    common.SubResourceLabels(ap),
+	}}
+	cli := fake.KubeClientBuilder().WithScheme(s).WithObjects(mo, ap, pod).Build()
+	eventEmitter := fake.NewMockEventEmitter(gomock.NewController(t))
+	ctx := fake.NewContext(mo, cli, eventEmitter)
+	get := func(obj client.Object) error {
+		return cli.Get(context.TODO(), client.ObjectKeyFromObject(obj), obj)
+	}
+
+	// the pods are drained first
+	removed, err := removeCNSet(ctx, apSetKey(mo))
+	g.Expect(err).To(Succeed())
+	g.Expect(removed).To(BeFalse())
+	g.Expect(get(pod)).To(Succeed())
+	g.Expect(common.IsDrained(pod)).To(BeTrue())
+	g.Expect(get(ap)).To(Succeed())
+	g.Expect(mo.Status.CNSetRemoval.Name).To(Equal("test-ap"))
+	g.Expect(mo.Status.CNSetRemoval.DrainedPods).To(Equal(int32(1)))
+
+	// the removal is canceled if the set is added back
+	g.Expect(cancelCNSetRemoval(ctx, apSetKey(mo))).To(Succeed())
+	g.Expect(get(pod)).To(Succeed())
+	g.Expect(common.IsDrained(pod)).To(BeFalse())
+	g.Expect(mo.Status.CNSetRemoval).To(BeNil())
+
+	// the set is deleted after the grace period
+	mo.Status.CNSetRemoval = &v1alpha1.CNSetRemovalStatus{
+		Name:           "test-ap",
+		DrainStartTime: metav1.NewTime(time.Now().Add(-2 * time.Minute)),
+	}
+	eventEmitter.EXPECT().EmitEventGeneric(reasonCNSetRemoved, gomock.Any(), nil)
+	removed, err = removeCNSet(ctx, apSetKey(mo))
+	g.Expect(err).To(Succeed())
+	g.Expect(removed).To(BeFalse())
+	g.Expect(get(ap)).NotTo(Succeed())
+
+	removed, err = removeCNSet(ctx, apSetKey(mo))
+	g.Expect(err).To(Succeed())
+	g.Expect(removed).To(BeTrue())
+	g.Expect(mo.Status.CNSetRemoval).To(BeNil())
+}
@@ -18,29 +18,55 @@ import (
 	recon "github.com/matrixorigin/controller-runtime/pkg/reconciler"
 	"github.com/matrixorigin/controller-runtime/pkg/util"
 	"github.com/matrixorigin/matrixone-operator/api/core/v1alpha1"
+	"github.com/matrixorigin/matrixone-operator/pkg/controllers/common"
+	"github.com/matrixorigin/matrixone-operator/pkg/metrics"
 	"github.com/matrixorigin/matrixone-operator/pkg/utils"
 	kruisepolicy "github.com/openkruise/kruise-api/policy/v1alpha1"
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sort"
+	"strings"
 	"time"
 )
 
 const (
 	resyncAfter = 15 * time.Second
 
+	// devRequestDivisor is the divisor of the limits to default the requests under the Dev resource profile
+	devRequestDivisor = 4
+
 	usernameKey = "username"
 	passwordKey = "password"
 
 	maxUnavailablePod = 1
 
 	matrixoneClusterLabelKey = "matrixorigin.io/cluster"
+
+	// component label values of the pods of each set
+	logSetComponent = "LogSet"
+	dnSetComponent  = "DNSet"
+	cnSetComponent  = "CNSet"
+	webUIComponent  = "WebUI"
+
+	// colocationWeight is the weight of the preferred colocation affinity terms
+	colocationWeight = 100
+	// isolationWeight is the weight of the preferred CN isolation anti-affinity term
+	isolationWeight = 100
+
+	// timezoneEnvKey is the env of the main container to set the timezone
+	timezoneEnvKey = "TZ"
+	// timezoneAnnotation records the TZ env injected into the overlay of the set by the cluster,
+	// so that the TZ env set by the user is told apart and kept
+	timezoneAnnotation = "matrixorigin.io/timezone"
 )
 
 var _ recon.Actor[*v1alpha1.MatrixOneCluster] = &MatrixOneClusterActor{}
@@ -68,6 +94,12 @@ func (r *MatrixOneClusterActor) Observe(ctx *recon.Context[*v1alpha1.MatrixOneCl
 	}); err != nil {
 		return nil, errors.Wrap(err, "sync cluster unavailable budget")
 	}
+	if err := syncNetworkPolicy(ctx); err != nil {
+		return nil, errors.Wrap(err, "sync cluster network policy")
+	}
+	if err := syncServiceAccounts(ctx); err != nil {
+		return nil, errors.Wrap(err, "sync cluster service accounts")
+	}
 
 	// sync specs
 	ls := &v1alpha1.LogSet{
@@ -87,10 +119,7 @@ func (r *MatrixOneClusterActor) Observe(ctx *recon.Context[*v1alpha1.MatrixOneCl
 		Deps:       v1alpha1.CNSetDeps{LogSetRef: ls.AsDependency()},
 	}
 	result, err := utils.CreateOwnedOrUpdate(ctx, ls, func() error {
-		ls.Spec.LogSetBasic = mo.Spec.LogService
-		setPodSetDefault(&ls.Spec.LogSetBasic.PodSet, mo)
-		setOverlay(&ls.Spec.Overlay, mo)
-		ls.Spec.Image = mo.LogSetImage()
+		syncLogSet(mo, ls)
 		return nil
 	})
 	if err != nil {
@@ -104,23 +133,14 @@ func (r *MatrixOneClusterActor) Observe(ctx *recon.Context[*v1alpha1.MatrixOneCl
 		}
 	}
 	result, err = utils.CreateOwnedOrUpdate(ctx, dn, func() error {
-		dn.Spec.DNSetBasic = mo.Spec.DN
-		setPodSetDefault(&dn.Spec.DNSetBasic.PodSet, mo)
-		setOverlay(&dn.Spec.Overlay, mo)
-		dn.Spec.Image = mo.DnSetImage()
-		dn.Deps.LogSet = &v1alpha1.LogSet{ObjectMeta: logSetKey(mo)}
+		syncDNSet(mo, dn)
 		return nil
 	})
 	if err != nil {
 		return nil, errors.Wrap(err, "sync DNSet")
 	}
 	result, err = utils.CreateOwnedOrUpdate(ctx, tp, func() error {
-		tp.Spec.CNSetBasic = mo.Spec.TP
-		setPodSetDefault(&tp.Spec.CNSetBasic.PodSet, mo)
-		setOverlay(&tp.Spec.Overlay, mo)
-		tp.Spec.Image = mo.TpSetImage()
-		tp.Deps.LogSet = &v1alpha1.LogSet{ObjectMeta: logSetKey(mo)}
-		tp.Deps.DNSet = &v1alpha1.DNSet{ObjectMeta: dnSetKey(mo)}
+		syncTPSet(mo, tp)
 		return nil
 	})
 	if err != nil {
@@ -132,17 +152,23 @@ func (r *MatrixOneClusterActor) Observe(ctx *recon.Context[*v1alpha1.MatrixOneCl
 			Deps:       v1alpha1.CNSetDeps{LogSetRef: ls.AsDependency()},
 		}
 		if err := recon.CreateOwnedOrUpdate(ctx, ap, func() error {
-			ap.Spec.CNSetBasic = *mo.Spec.AP
-			setPodSetDefault(&ap.Spec.CNSetBasic.PodSet, mo)
-			setOverlay(&ap.Spec.Overlay, mo)
-			ap.Spec.Image = mo.ApSetImage()
-			ap.Deps.LogSet = &v1alpha1.LogSet{ObjectMeta: logSetKey(mo)}
-			ap.Deps.DNSet = &v1alpha1.DNSet{ObjectMeta: dnSetKey(mo)}
+			syncAPSet(mo, ap)
 			return nil
 		}); err != nil {
 			return nil, errors.Wrap(err, "sync AP CNSet")
 		}
+		if err := cancelCNSetRemoval(ctx, apSetKey(mo)); err != nil {
+			return nil, errors.Wrap(err, "cancel AP CNSet removal")
+		}
 		mo.Status.AP = &ap.Status
+	} else {
+		removed, err := removeCNSet(ctx, apSetKey(mo))
+		if err != nil {
+			return nil, errors.Wrap(err, "remove AP CNSet")
+		}
+		if removed {
+			mo.Status.AP = nil
+		}
 	}
 
 	if mo.Spec.WebUI != nil {
@@ -153,7 +179,7 @@ func (r *MatrixOneClusterActor) Observe(ctx *recon.Context[*v1alpha1.MatrixOneCl
 			},
 		}
 		if err := recon.CreateOwnedOrUpdate(ctx, webui, func() error {
-			webui.Spec.WebUIBasic = *mo.Spec.WebUI
+			syncWebUI(mo, webui)
 			return nil
 		}); err != nil {
 			return nil, errors.Wrap(err, "sync webUI")
@@ -165,9 +191,19 @@ func (r *MatrixOneClusterActor) Observe(ctx *recon.Context[*v1alpha1.MatrixOneCl
 	mo.Status.LogService = &ls.Status
 	mo.Status.DN = &dn.Status
 	mo.Status.TP = &tp.Status
+	mo.Status.PodSummary = podSummary(mo)
 	mo.Status.Phase = "NotReady"
 	mo.Status.ConditionalStatus.SetCondition(syncedCondition(mo))
 
+	if mo.Spec.Hibernate {
+		if cnStopped(mo) && !hasStores(&mo.Status.DN.FailoverStatus) {
+			mo.Status.Phase = "Hibernated"
+			return nil, nil
+		}
+		mo.Status.Phase = "Hibernating"
+		return nil, recon.ErrReSync("wait cluster hibernated", resyncAfter)
+	}
+
 	subResourcesReady := readyCondition(mo)
 
 	if mo.Status.CredentialRef == nil {
@@ -189,14 +225,151 @@ func (r *MatrixOneClusterActor) Observe(ctx *recon.Context[*v1alpha1.MatrixOneCl
 	mo.Status.ConditionalStatus.SetCondition(subResourcesReady)
 	if subResourcesReady.Status == metav1.ConditionTrue {
 		mo.Status.Phase = "Ready"
+		if mo.Spec.BootstrapSQL != nil && !mo.Status.BootstrapSQLExecuted {
+			return r.ExecuteBootstrapSQL, nil
+		}
 	}
 
+	if mo.Status.CNSetRemoval != nil {
+		return nil, recon.ErrReSync("wait the removed CN set drained", resyncAfter)
+	}
 	if recon.IsReady(&mo.Status) {
 		return nil, nil
 	}
 	return nil, recon.ErrReSync("matrixone cluster is not ready", resyncAfter)
 }
 
+// syncLogSet syncs the desired spec of the LogSet from the cluster spec
+func syncLogSet(mo *v1alpha1.MatrixOneCluster, ls *v1alpha1.LogSet) {
+	setCommonLabels(ls, mo)
+	ls.Spec.LogSetBasic = mo.Spec.LogService
+	setPodSetDefault(&ls.Spec.LogSetBasic.PodSet, mo)
+	setManagedServiceAccount(&ls.Spec.LogSetBasic.PodSet, mo, logServiceAccount)
+	setOverlay(&ls.Spec.Overlay, mo)
+	setTimezone(ls, ls.Spec.Overlay, mo)
+	setColocation(ls.Spec.Overlay, mo, logSetComponent)
+	ls.Spec.Image = mo.LogSetImage()
+}
+
+// syncDNSet syncs the desired spec of the DNSet from the cluster spec
+func syncDNSet(mo *v1alpha1.MatrixOneCluster, dn *v1alpha1.DNSet) {
+	setCommonLabels(dn, mo)
+	setValidationAnnotations(dn, mo)
+	dn.Spec.DNSetBasic = mo.Spec.DN
+	if mo.Spec.Hibernate && cnStopped(mo) {
+		dn.Spec.Replicas = 0
+	}
+	setPodSetDefault(&dn.Spec.DNSetBasic.PodSet, mo)
+	setManagedServiceAccount(&dn.Spec.DNSetBasic.PodSet, mo, dnServiceAccount)
+	setOverlay(&dn.Spec.Overlay, mo)
+	setTimezone(dn, dn.Spec.Overlay, mo)
+	setColocation(dn.Spec.Overlay, mo, dnSetComponent)
+	dn.Spec.Image = mo.DnSetImage()
+	dn.Deps.LogSet = &v1alpha1.LogSet{ObjectMeta: logSetKey(mo)}
+}
+
+// syncTPSet syncs the desired spec of the TP CNSet from the cluster spec
+func syncTPSet(mo *v1alpha1.MatrixOneCluster, tp *v1alpha1.CNSet) {
+	setCommonLabels(tp, mo)
+	setValidationAnnotations(tp, mo)
+	stopped := isStopped(tp, tp.Spec.Replicas)
+	tp.Spec.CNSetBasic = mo.Spec.TP
+	if shouldStop(mo, stopped) {
+		tp.Spec.Replicas = 0
+	}
+	setPodSetDefault(&tp.Spec.CNSetBasic.PodSet, mo)
+	setManagedServiceAccount(&tp.Spec.CNSetBasic.PodSet, mo, tpServiceAccount)
+	setOverlay(&tp.Spec.Overlay, mo)
+	setTimezone(tp, tp.Spec.Overlay, mo)
+	setColocation(tp.Spec.Overlay, mo, cnSetComponent)
+	setCNIsolation(tp.Spec.Overlay, mo, tp.Name)
+	tp.Spec.Image = mo.TpSetImage()
+	tp.Deps.LogSet = &v1alpha1.LogSet{ObjectMeta: logSetKey(mo)}
+	tp.Deps.DNSet = &v1alpha1.DNSet{ObjectMeta: dnSetKey(mo)}
+}
+
+// syncAPSet syncs the desired spec of the AP CNSet from the cluster spec
+func syncAPSet(mo *v1alpha1.MatrixOneCluster, ap *v1alpha1.CNSet) {
+	setCommonLabels(ap, mo)
+	setValidationAnnotations(ap, mo)
+	stopped := isStopped(ap, ap.Spec.Replicas)
+	ap.Spec.CNSetBasic = *mo.Spec.AP
+	if shouldStop(mo, stopped) {
+		ap.Spec.Replicas = 0
+	}
+	setPodSetDefault(&ap.Spec.CNSetBasic.PodSet, mo)
+	setManagedServiceAccount(&ap.Spec.CNSetBasic.PodSet, mo, apServiceAccount)
+	setOverlay(&ap.Spec.Overlay, mo)
+	setTimezone(ap, ap.Spec.Overlay, mo)
+	setColocation(ap.Spec.Overlay, mo, cnSetComponent)
+	setCNIsolation(ap.Spec.Overlay, mo, ap.Name)
+	ap.Spec.Image = mo.ApSetImage()
+	ap.Deps.LogSet = &v1alpha1.LogSet{ObjectMeta: logSetKey(mo)}
+	ap.Deps.DNSet = &v1alpha1.DNSet{ObjectMeta: dnSetKey(mo)}
+}
+
+// syncWebUI syncs the desired spec of the WebUI from the cluster spec, the cluster-level pod meta,
+// image pull policy and timezone apply to the WebUI like the other sets
+func syncWebUI(mo *v1alpha1.MatrixOneCluster, webui *v1alpha1.WebUI) {
+	setCommonLabels(webui, mo)
+	stopped := isStopped(webui, webui.Spec.Replicas)
+	webui.Spec.WebUIBasic = *mo.Spec.WebUI
+	if shouldStop(mo, stopped) {
+		webui.Spec.Replicas = 0
+	}
+	setOverlay(&webui.Spec.Overlay, mo)
+	setTimezone(webui, webui.Spec.Overlay, mo)
+}
+
+// isStopped returns whether the existing set is scaled to zero
+func isStopped(obj client.Object, replicas int32) bool {
+	created := obj.GetCreationTimestamp()
+	return !created.IsZero() && replicas == 0
+}
+
+// shouldStop returns whether a set that depends on the DN set should be scaled to zero, the set
+// is stopped during hibernation and is resumed after all the DN stores are available on wakeup
+func shouldStop(mo *v1alpha1.MatrixOneCluster, stopped bool) bool {
+	if mo.Spec.Hibernate {
+		return true
+	}
+	return stopped && (mo.Status.DN == nil || len(mo.Status.DN.AvailableStores) < int(mo.Spec.DN.Replicas))
+}
+
+// cnStopped returns whether all the CN pods of the cluster are gone
+func cnStopped(mo *v1alpha1.MatrixOneCluster) bool {
+	if mo.Status.TP != nil && hasStores(&mo.Status.TP.FailoverStatus) {
+		return false
+	}
+	return mo.Status.AP == nil || !hasStores(&mo.Status.AP.FailoverStatus)
+}
+
+// hasStores returns whether there is any pod observed in the failover status
+func hasStores(s *v1alpha1.FailoverStatus) bool {
+	return len(s.AvailableStores)+len(s.SuspectedStores)+len(s.FailedStores) > 0
+}
+
+// podSummary rolls up the pod summaries of all the components of the cluster
+func podSummary(mo *v1alpha1.MatrixOneCluster) *v1alpha1.PodSummary {
+	s := &v1alpha1.PodSummary{}
+	if mo.Status.LogService != nil {
+		s.Add(mo.Status.LogService.PodSummary)
+	}
+	if mo.Status.DN != nil {
+		s.Add(mo.Status.DN.PodSummary)
+	}
+	if mo.Status.TP != nil {
+		s.Add(mo.Status.TP.PodSummary)
+	}
+	if mo.Status.AP != nil {
+		s.Add(mo.Status.AP.PodSummary)
+	}
+	if mo.Status.Webui != nil {
+		s.Add(mo.Status.Webui.PodSummary)
+	}
+	return s
+}
+
 func setPodSetDefault(ps *v1alpha1.PodSet, mo *v1alpha1.MatrixOneCluster) {
 	if ps.NodeSelector == nil {
 		ps.NodeSelector = mo.Spec.NodeSelector
@@ -204,6 +377,140 @@ func setPodSetDefault(ps *v1alpha1.PodSet, mo *v1alpha1.MatrixOneCluster) {
 	if ps.TopologyEvenSpread == nil {
 		ps.TopologyEvenSpread = mo.Spec.TopologyEvenSpread
 	}
+	if ps.ServiceAccountName == "" {
+		ps.ServiceAccountName = mo.Spec.ServiceAccountName
+	}
+	if len(mo.Spec.CommonLabels) > 0 {
+		// the volume metadata of the set takes precedence over the common labels
+		m := &v1alpha1.VolumeMetadata{Labels: map[string]string{}}
+		for k, v := range mo.Spec.CommonLabels {
+			m.Labels[k] = v
+		}
+		if ps.VolumeMetadata != nil {
+			for k, v := range ps.VolumeMetadata.Labels {
+				m.Labels[k] = v
+			}
+			m.Annotations = ps.VolumeMetadata.Annotations
+		}
+		ps.VolumeMetadata = m
+	}
+	setResourceProfile(ps, mo)
+}
+
+// setResourceProfile defaults the resources of the set according to the resource profile of
+// the cluster, the resources of the set are copied since they are shared with the cluster spec
+func setResourceProfile(ps *v1alpha1.PodSet, mo *v1alpha1.MatrixOneCluster) {
+	switch mo.Spec.ResourceProfile {
+	case v1alpha1.ResourceProfileDev:
+		ps.Resources = *ps.Resources.DeepCopy()
+		ps.Resources.Requests = defaultResources(ps.Resources.Requests, ps.Resources.Limits, func(name corev1.ResourceName, q resource.Quantity) resource.Quantity {
+			if name == corev1.ResourceCPU {
+				return *resource.NewMilliQuantity(q.MilliValue()/devRequestDivisor, q.Format)
+			}
+			return *resource.NewQuantity(q.Value()/devRequestDivisor, q.Format)
+		})
+	case v1alpha1.ResourceProfileProduction:
+		ps.Resources = *ps.Resources.DeepCopy()
+		ps.Resources.Limits = defaultResources(ps.Resources.Limits, ps.Resources.Requests, func(_ corev1.ResourceName, q resource.Quantity) resource.Quantity {
+			return q
+		})
+	}
+}
+
+// defaultResources defaults the cpu and memory that are absent in the list from the other list
+func defaultResources(list, from corev1.ResourceList, fn func(corev1.ResourceName, resource.Quantity) resource.Quantity) corev1.ResourceList {
+	for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+		q, ok := from[name]
+		if !ok {
+			continue
+		}
+		if _, ok := list[name]; ok {
+			continue
+		}
+		if list == nil {
+			list = corev1.ResourceList{}
+		}
+		list[name] = fn(name, q)
+	}
+	return list
+}
+
+// setCommonLabels labels the set with the common labels of the cluster, which are then inherited
+// by the resources of the set. The keys are recorded in annotation so that the labels removed
+// from the cluster can be cleaned up.
+func setCommonLabels(obj client.Object, mo *v1alpha1.MatrixOneCluster) {
+	labels := obj.GetLabels()
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	if last := annotations[common.CommonLabelsAnnotation]; last != "" {
+		for _, k := range strings.Split(last, ",") {
+			delete(labels, k)
+		}
+	}
+	var keys []string
+	for k, v := range mo.Spec.CommonLabels {
+		labels[k] = v
+		keys = append(keys, k)
+	}
+	if len(keys) > 0 {
+		sort.Strings(keys)
+		annotations[common.CommonLabelsAnnotation] = strings.Join(keys, ",")
+	} else {
+		delete(annotations, common.CommonLabelsAnnotation)
+	}
+	obj.SetLabels(labels)
+	obj.SetAnnotations(annotations)
+}
+
+// setValidationAnnotations passes the annotations of the cluster that relax the validation of the
+// webhooks to the set, so that the set is not rejected for the spec accepted as part of the cluster
+func setValidationAnnotations(obj client.Object, mo *v1alpha1.MatrixOneCluster) {
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	if v, ok := mo.Annotations[v1alpha1.SkipCacheSizeValidationAnnotation]; ok {
+		annotations[v1alpha1.SkipCacheSizeValidationAnnotation] = v
+	} else {
+		delete(annotations, v1alpha1.SkipCacheSizeValidationAnnotation)
+	}
+	obj.SetAnnotations(annotations)
+}
+
+// setTimezone injects the timezone of the cluster into the overlay of the set as the TZ env, a TZ env
+// that is not injected by the cluster (i.e. set by the user) takes precedence and is left untouched.
+// It must be called after setOverlay.
+func setTimezone(obj client.Object, o *v1alpha1.Overlay, mo *v1alpha1.MatrixOneCluster) {
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	injected, ok := annotations[timezoneAnnotation]
+	var env []corev1.EnvVar
+	for _, e := range o.Env {
+		if e.Name != timezoneEnvKey {
+			env = append(env, e)
+			continue
+		}
+		if !ok || e.ValueFrom != nil || e.Value != injected {
+			// set by the user
+			delete(annotations, timezoneAnnotation)
+			obj.SetAnnotations(annotations)
+			return
+		}
+	}
+	delete(annotations, timezoneAnnotation)
+	if mo.Spec.Timezone != "" {
+		env = append(env, corev1.EnvVar{Name: timezoneEnvKey, Value: mo.Spec.Timezone})
+		annotations[timezoneAnnotation] = mo.Spec.Timezone
+	}
+	o.Env = env
+	obj.SetAnnotations(annotations)
 }
 
 func setOverlay(o **v1alpha1.Overlay, mo *v1alpha1.MatrixOneCluster) {
@@ -213,10 +520,176 @@ func setOverlay(o **v1alpha1.Overlay, mo *v1alpha1.MatrixOneCluster) {
 	if mo.Spec.ImagePullPolicy != nil {
 		(*o).ImagePullPolicy = mo.Spec.ImagePullPolicy
 	}
-	if (*o).PodLabels == nil {
-		(*o).PodLabels = map[string]string{}
+	// cluster-level pod meta are defaults, the overlay of the set takes precedence
+	meta := &metav1.ObjectMeta{
+		Labels:      map[string]string{},
+		Annotations: map[string]string{},
+	}
+	(&v1alpha1.Overlay{
+		PodLabels: mo.Spec.CommonLabels,
+	}).OverlayPodMeta(meta)
+	(&v1alpha1.Overlay{
+		PodLabels:      mo.Spec.PodLabels,
+		PodAnnotations: mo.Spec.PodAnnotations,
+	}).OverlayPodMeta(meta)
+	(*o).OverlayPodMeta(meta)
+	meta.Labels[matrixoneClusterLabelKey] = mo.Name
+	(*o).PodLabels = meta.Labels
+	if len(meta.Annotations) > 0 {
+		(*o).PodAnnotations = meta.Annotations
+	}
+}
+
+// setCNIsolation generates the pod anti-affinity that keeps the pods of the CN set away from the
+// pods of the other CN sets of the cluster, the previously generated term is replaced on every sync
+func setCNIsolation(o *v1alpha1.Overlay, mo *v1alpha1.MatrixOneCluster, name string) {
+	if anti := podAntiAffinity(o); anti != nil {
+		anti.RequiredDuringSchedulingIgnoredDuringExecution = withoutTerms(anti.RequiredDuringSchedulingIgnoredDuringExecution, isIsolationTerm)
+		anti.PreferredDuringSchedulingIgnoredDuringExecution = withoutWeightedTerms(anti.PreferredDuringSchedulingIgnoredDuringExecution, isIsolationTerm)
+	}
+	c := mo.Spec.CNIsolation
+	if c == nil {
+		return
+	}
+	term := corev1.PodAffinityTerm{
+		LabelSelector: &metav1.LabelSelector{
+			MatchLabels: map[string]string{
+				matrixoneClusterLabelKey: mo.Name,
+				common.ComponentLabelKey: cnSetComponent,
+			},
+			MatchExpressions: []metav1.LabelSelectorRequirement{{
+				Key:      common.InstanceLabelKey,
+				Operator: metav1.LabelSelectorOpNotIn,
+				Values:   []string{name},
+			}},
+		},
+		TopologyKey: c.GetTopologyKey(),
+	}
+	if o.Affinity == nil {
+		o.Affinity = &corev1.Affinity{}
+	}
+	if o.Affinity.PodAntiAffinity == nil {
+		o.Affinity.PodAntiAffinity = &corev1.PodAntiAffinity{}
+	}
+	anti := o.Affinity.PodAntiAffinity
+	if c.GetPolicy() == v1alpha1.AntiAffinityPolicyRequired {
+		anti.RequiredDuringSchedulingIgnoredDuringExecution = append(anti.RequiredDuringSchedulingIgnoredDuringExecution, term)
+		return
+	}
+	anti.PreferredDuringSchedulingIgnoredDuringExecution = append(anti.PreferredDuringSchedulingIgnoredDuringExecution, corev1.WeightedPodAffinityTerm{
+		Weight:          isolationWeight,
+		PodAffinityTerm: term,
+	})
+}
+
+func podAntiAffinity(o *v1alpha1.Overlay) *corev1.PodAntiAffinity {
+	if o.Affinity == nil {
+		return nil
+	}
+	return o.Affinity.PodAntiAffinity
+}
+
+func withoutTerms(terms []corev1.PodAffinityTerm, generated func(corev1.PodAffinityTerm) bool) []corev1.PodAffinityTerm {
+	var res []corev1.PodAffinityTerm
+	for _, t := range terms {
+		if !generated(t) {
+			res = append(res, t)
+		}
+	}
+	return res
+}
+
+func withoutWeightedTerms(terms []corev1.WeightedPodAffinityTerm, generated func(corev1.PodAffinityTerm) bool) []corev1.WeightedPodAffinityTerm {
+	var res []corev1.WeightedPodAffinityTerm
+	for _, t := range terms {
+		if !generated(t.PodAffinityTerm) {
+			res = append(res, t)
+		}
+	}
+	return res
+}
+
+// isColocationTerm returns whether the term is generated by setColocation
+func isColocationTerm(t corev1.PodAffinityTerm) bool {
+	if t.LabelSelector == nil || len(t.LabelSelector.MatchExpressions) != 1 {
+		return false
+	}
+	if len(t.LabelSelector.MatchLabels) != 1 || t.LabelSelector.MatchLabels[matrixoneClusterLabelKey] == "" {
+		return false
+	}
+	r := t.LabelSelector.MatchExpressions[0]
+	return r.Key == common.ComponentLabelKey && r.Operator == metav1.LabelSelectorOpIn
+}
+
+// isIsolationTerm returns whether the term is generated by setCNIsolation
+func isIsolationTerm(t corev1.PodAffinityTerm) bool {
+	if t.LabelSelector == nil || len(t.LabelSelector.MatchExpressions) != 1 {
+		return false
+	}
+	r := t.LabelSelector.MatchExpressions[0]
+	return r.Key == common.InstanceLabelKey && r.Operator == metav1.LabelSelectorOpNotIn
+}
+
+// setColocation generates the affinity of the set according to the colocation policy of the cluster:
+// DN pods are required to be colocated with LogService pods, LogService pods prefer to be colocated
+// with DN pods and CN pods prefer to stay away from both of them. The generated terms are merged
+// into the affinity of the overlay so that the affinity set by user is kept.
+func setColocation(o *v1alpha1.Overlay, mo *v1alpha1.MatrixOneCluster, component string) {
+	if o.Affinity != nil {
+		if pa := o.Affinity.PodAffinity; pa != nil {
+			pa.RequiredDuringSchedulingIgnoredDuringExecution = withoutTerms(pa.RequiredDuringSchedulingIgnoredDuringExecution, isColocationTerm)
+			pa.PreferredDuringSchedulingIgnoredDuringExecution = withoutWeightedTerms(pa.PreferredDuringSchedulingIgnoredDuringExecution, isColocationTerm)
+		}
+		if anti := o.Affinity.PodAntiAffinity; anti != nil {
+			anti.PreferredDuringSchedulingIgnoredDuringExecution = withoutWeightedTerms(anti.PreferredDuringSchedulingIgnoredDuringExecution, isColocationTerm)
+		}
+	}
+	c := mo.Spec.Colocation
+	if c == nil {
+		return
+	}
+	term := func(components ...string) corev1.PodAffinityTerm {
+		return corev1.PodAffinityTerm{
+			LabelSelector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{matrixoneClusterLabelKey: mo.Name},
+				MatchExpressions: []metav1.LabelSelectorRequirement{{
+					Key:      common.ComponentLabelKey,
+					Operator: metav1.LabelSelectorOpIn,
+					Values:   components,
+				}},
+			},
+			TopologyKey: c.GetTopologyKey(),
+		}
+	}
+	if o.Affinity == nil {
+		o.Affinity = &corev1.Affinity{}
+	}
+	switch component {
+	case dnSetComponent:
+		if o.Affinity.PodAffinity == nil {
+			o.Affinity.PodAffinity = &corev1.PodAffinity{}
+		}
+		pa := o.Affinity.PodAffinity
+		pa.RequiredDuringSchedulingIgnoredDuringExecution = append(pa.RequiredDuringSchedulingIgnoredDuringExecution, term(logSetComponent))
+	case logSetComponent:
+		if o.Affinity.PodAffinity == nil {
+			o.Affinity.PodAffinity = &corev1.PodAffinity{}
+		}
+		pa := o.Affinity.PodAffinity
+		pa.PreferredDuringSchedulingIgnoredDuringExecution = append(pa.PreferredDuringSchedulingIgnoredDuringExecution, corev1.WeightedPodAffinityTerm{
+			Weight:          colocationWeight,
+			PodAffinityTerm: term(dnSetComponent),
+		})
+	case cnSetComponent:
+		if o.Affinity.PodAntiAffinity == nil {
+			o.Affinity.PodAntiAffinity = &corev1.PodAntiAffinity{}
+		}
+		anti := o.Affinity.PodAntiAffinity
+		anti.PreferredDuringSchedulingIgnoredDuringExecution = append(anti.PreferredDuringSchedulingIgnoredDuringExecution, corev1.WeightedPodAffinityTerm{
+			Weight:          colocationWeight,
+			PodAffinityTerm: term(dnSetComponent, logSetComponent),
+		})
 	}
-	(*o).PodLabels[matrixoneClusterLabelKey] = mo.Name
 }
 
 // Initialize the MO cluster
@@ -241,7 +714,11 @@ func (r *MatrixOneClusterActor) Initialize(ctx *recon.Context[*v1alpha1.MatrixOn
 
 	// 3. update the status
 	ctx.Obj.Status.CredentialRef = &corev1.LocalObjectReference{Name: sec.Name}
-	return ctx.UpdateStatus(ctx.Obj)
+	if err := ctx.UpdateStatus(ctx.Obj); err != nil {
+		return err
+	}
+	ctx.Event.EmitEventGeneric(common.ReasonBootstrapped, "cluster initialized", nil)
+	return nil
 }
 
 func readyCondition(mo *v1alpha1.MatrixOneCluster) metav1.Condition {
@@ -282,17 +759,61 @@ func syncedCondition(mo *v1alpha1.MatrixOneCluster) metav1.Condition {
 	return c
 }
 
+// teardownTier is a group of sets that can be deleted in parallel during cluster teardown
+type teardownTier struct {
+	name string
+	// components are the component labels of the pods that belong to this tier
+	components []string
+	objs       []client.Object
+}
+
+// teardownTiers returns the sets of the cluster in reverse dependency order, a tier will
+// be deleted only after all the pods of its preceding tiers are gone
+func teardownTiers(mo *v1alpha1.MatrixOneCluster) []teardownTier {
+	return []teardownTier{{
+		name:       "CN",
+		components: []string{webUIComponent, cnSetComponent},
+		objs: []client.Object{
+			&v1alpha1.WebUI{ObjectMeta: webUIKey(mo)},
+			&v1alpha1.CNSet{ObjectMeta: tpSetKey(mo)},
+			&v1alpha1.CNSet{ObjectMeta: apSetKey(mo)},
+		},
+	}, {
+		name:       "DN",
+		components: []string{dnSetComponent},
+		objs:       []client.Object{&v1alpha1.DNSet{ObjectMeta: dnSetKey(mo)}},
+	}, {
+		name:       "LogService",
+		components: []string{logSetComponent},
+		objs:       []client.Object{&v1alpha1.LogSet{ObjectMeta: logSetKey(mo)}},
+	}}
+}
+
 func (r *MatrixOneClusterActor) Finalize(ctx *recon.Context[*v1alpha1.MatrixOneCluster]) (bool, error) {
 	mo := ctx.Obj
-	objs := []client.Object{
-		&v1alpha1.LogSet{ObjectMeta: logSetKey(mo)},
-		&v1alpha1.DNSet{ObjectMeta: dnSetKey(mo)},
-		&v1alpha1.CNSet{ObjectMeta: tpSetKey(mo)},
-		&v1alpha1.CNSet{ObjectMeta: apSetKey(mo)},
-		&v1alpha1.WebUI{ObjectMeta: webUIKey(mo)},
+	for _, tier := range teardownTiers(mo) {
+		done, err := teardown(ctx, tier)
+		if err != nil {
+			return false, errors.Wrapf(err, "teardown %s", tier.name)
+		}
+		if !done {
+			mo.Status.Phase = "Terminating"
+			mo.Status.SetCondition(metav1.Condition{
+				Type:    recon.ConditionTypeReady,
+				Status:  metav1.ConditionFalse,
+				Reason:  "Terminating",
+				Message: fmt.Sprintf("waiting for %s to be terminated", tier.name),
+			})
+			return false, ctx.UpdateStatus(mo)
+		}
 	}
+	return true, nil
+}
+
+// teardown deletes the sets in the tier and reports whether the sets and their pods are all gone
+func teardown(ctx *recon.Context[*v1alpha1.MatrixOneCluster], tier teardownTier) (bool, error) {
 	existAny := false
-	for _, obj := range objs {
+	for _, obj := range tier.objs {
 		exist, err := ctx.Exist(client.ObjectKeyFromObject(obj), obj)
 		if err != nil {
 			return false, err
@@ -304,7 +825,23 @@ func (r *MatrixOneClusterActor) Finalize(ctx *recon.Context[*v1alpha1.MatrixOneC
 		}
 		existAny = existAny || exist
 	}
-	return !existAny, nil
+	if existAny {
+		return false, nil
+	}
+	// the sets are gone, wait the pods to be terminated
+	for _, component := range tier.components {
+		podList := &corev1.PodList{}
+		if err := ctx.List(podList, client.InNamespace(ctx.Obj.Namespace), client.MatchingLabels{
+			matrixoneClusterLabelKey: ctx.Obj.Name,
+			common.ComponentLabelKey: component,
+		}); err != nil {
+			return false, err
+		}
+		if len(podList.Items) > 0 {
+			return false, nil
+		}
+	}
+	return true, nil
 }
 
 func logSetKey(mo *v1alpha1.MatrixOneCluster) metav1.ObjectMeta {
@@ -343,12 +880,14 @@ func webUIKey(mo *v1alpha1.MatrixOneCluster) metav1.ObjectMeta {
 }
 
 func (r *MatrixOneClusterActor) Reconcile(mgr manager.Manager) error {
-	return recon.Setup[*v1alpha1.MatrixOneCluster](&v1alpha1.MatrixOneCluster{}, "matrixonecluster", mgr, r,
+	return recon.Setup[*v1alpha1.MatrixOneCluster](&v1alpha1.MatrixOneCluster{}, "matrixonecluster", mgr, metrics.Instrument[*v1alpha1.MatrixOneCluster]("matrixonecluster", r),
 		recon.WithBuildFn(func(b *builder.Builder) {
 			b.Owns(&v1alpha1.LogSet{}).
 				Owns(&v1alpha1.DNSet{}).
 				Owns(&v1alpha1.CNSet{}).
-				Owns(&v1alpha1.WebUI{})
+				Owns(&v1alpha1.WebUI{}).
+				Owns(&networkingv1.NetworkPolicy{}).
+				Owns(&corev1.ServiceAccount{})
 		}))
 }
 
@@ -19,6 +19,7 @@ import (
 	"github.com/matrixorigin/controller-runtime/pkg/fake"
 	recon "github.com/matrixorigin/controller-runtime/pkg/reconciler"
 	"github.com/matrixorigin/matrixone-operator/api/core/v1alpha1"
+	"github.com/matrixorigin/matrixone-operator/pkg/controllers/common"
 	. "github.com/onsi/gomega"
 	kruisev1 "github.com/openkruise/kruise-api/apps/v1beta1"
 	kruisepolicy "github.com/openkruise/kruise-api/policy/v1alpha1"
@@ -124,6 +125,54 @@ func TestMatrixOneClusterActor_Observe(t *testing.T) {
 			g.Expect(recon.IsReady(&mo.Status)).To(BeTrue())
 			g.Expect(err).To(Succeed())
 		},
+	}, {
+		name: "bootstrapSQL",
+		mo: func() *v1alpha1.MatrixOneCluster {
+			mo := tpl.DeepCopy()
+			mo.Spec.BootstrapSQL = &v1alpha1.BootstrapSQL{
+				ConfigMapKeyRef: &corev1.ConfigMapKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "bootstrap"},
+					Key:                  "init.sql",
+				},
+			}
+			mo.Status.CredentialRef = &corev1.LocalObjectReference{Name: "test"}
+			return mo
+		}(),
+		objects: []client.Object{
+			&v1alpha1.LogSet{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test"},
+				Status: v1alpha1.LogSetStatus{
+					ConditionalStatus: v1alpha1.ConditionalStatus{Conditions: []metav1.Condition{{
+						Type:   recon.ConditionTypeReady,
+						Status: metav1.ConditionTrue,
+					}}},
+				},
+			},
+			&v1alpha1.DNSet{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test"},
+				Status: v1alpha1.DNSetStatus{
+					ConditionalStatus: v1alpha1.ConditionalStatus{Conditions: []metav1.Condition{{
+						Type:   recon.ConditionTypeReady,
+						Status: metav1.ConditionTrue,
+					}}},
+				},
+			},
+			&v1alpha1.CNSet{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test-tp"},
+				Status: v1alpha1.CNSetStatus{
+					ConditionalStatus: v1alpha1.ConditionalStatus{Conditions: []metav1.Condition{{
+						Type:   recon.ConditionTypeReady,
+						Status: metav1.ConditionTrue,
+					}}},
+				},
+			},
+		},
+		expect: func(g *WithT, mo *v1alpha1.MatrixOneCluster, err error, c client.Client) {
+			g.Expect(err).To(Succeed())
+		},
+		expectAction: func(g *WithT, action recon.Action[*v1alpha1.MatrixOneCluster]) {
+			g.Expect(action.String()).To(ContainSubstring("ExecuteBootstrapSQL"))
+		},
 	}, {
 		name: "DNNotReady",
 		mo:   tpl.DeepCopy(),
@@ -262,6 +311,26 @@ func TestMatrixOneClusterActor_Observe(t *testing.T) {
 			g.Expect(c.Get(ctx, types.NamespacedName{Namespace: "default", Name: "test-tp"}, cn)).To(Succeed())
 			g.Expect(cn.Spec.NodeSelector).To(Equal(map[string]string{"local-label": "local-value"}))
 		},
+	}, {
+		name: "inheritOrOverrideGlobalServiceAccountName",
+		mo: func() *v1alpha1.MatrixOneCluster {
+			m := tpl.DeepCopy()
+			m.Spec.ServiceAccountName = "mo"
+			m.Spec.TP.ServiceAccountName = "mo-tp"
+			return m
+		}(),
+		objects: nil,
+		expect: func(g *WithT, _ *v1alpha1.MatrixOneCluster, err error, c client.Client) {
+			dn := &v1alpha1.DNSet{}
+			g.Expect(c.Get(ctx, types.NamespacedName{Namespace: "default", Name: "test"}, dn)).To(Succeed())
+			g.Expect(dn.Spec.ServiceAccountName).To(Equal("mo"))
+			ls := &v1alpha1.LogSet{}
+			g.Expect(c.Get(ctx, types.NamespacedName{Namespace: "default", Name: "test"}, ls)).To(Succeed())
+			g.Expect(ls.Spec.ServiceAccountName).To(Equal("mo"))
+			cn := &v1alpha1.CNSet{}
+			g.Expect(c.Get(ctx, types.NamespacedName{Namespace: "default", Name: "test-tp"}, cn)).To(Succeed())
+			g.Expect(cn.Spec.ServiceAccountName).To(Equal("mo-tp"))
+		},
 	}, {
 		name: "setImagePullPolicy",
 		mo: func() *v1alpha1.MatrixOneCluster {
@@ -348,6 +417,7 @@ func TestMatrixOneClusterActor_Initialize(t *testing.T) {
 			cli := fake.KubeClientBuilder().WithScheme(s).WithObjects(tt.mo).Build()
 			mockCtrl := gomock.NewController(t)
 			eventEmitter := fake.NewMockEventEmitter(mockCtrl)
+			eventEmitter.EXPECT().EmitEventGeneric(common.ReasonBootstrapped, gomock.Any(), nil)
 			ctx := fake.NewContext(tt.mo, cli, eventEmitter)
 			err := r.Initialize(ctx)
 			g.Expect(err).To(Succeed())
@@ -356,6 +426,404 @@ func TestMatrixOneClusterActor_Initialize(t *testing.T) {
 	}
 }
 
+func TestMatrixOneClusterActor_Finalize(t *testing.T) {
+	s := newScheme()
+	g := NewGomegaWithT(t)
+	mo := &v1alpha1.MatrixOneCluster{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test"},
+	}
+	ls := &v1alpha1.LogSet{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test"}}
+	dn := &v1alpha1.DNSet{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test"}}
+	tp := &v1alpha1.CNSet{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test-tp"}}
+	cli := fake.KubeClientBuilder().WithScheme(s).WithObjects(mo, ls, dn, tp).Build()
+	mockCtrl := gomock.NewController(t)
+	eventEmitter := fake.NewMockEventEmitter(mockCtrl)
+	ctx := fake.NewContext(mo, cli, eventEmitter)
+	r := &MatrixOneClusterActor{}
+	exist := func(obj client.Object) bool {
+		err := cli.Get(context.TODO(), client.ObjectKeyFromObject(obj), obj)
+		return err == nil
+	}
+
+	// CN sets should be deleted first
+	done, err := r.Finalize(ctx)
+	g.Expect(err).To(Succeed())
+	g.Expect(done).To(BeFalse())
+	g.Expect(exist(tp)).To(BeFalse())
+	g.Expect(exist(dn)).To(BeTrue())
+	g.Expect(exist(ls)).To(BeTrue())
+	g.Expect(mo.Status.Phase).To(Equal("Terminating"))
+
+	// then the DN set
+	done, err = r.Finalize(ctx)
+	g.Expect(err).To(Succeed())
+	g.Expect(done).To(BeFalse())
+	g.Expect(exist(dn)).To(BeFalse())
+	g.Expect(exist(ls)).To(BeTrue())
+
+	// finally the log set
+	done, err = r.Finalize(ctx)
+	g.Expect(err).To(Succeed())
+	g.Expect(done).To(BeFalse())
+	g.Expect(exist(ls)).To(BeFalse())
+
+	done, err = r.Finalize(ctx)
+	g.Expect(err).To(Succeed())
+	g.Expect(done).To(BeTrue())
+}
+
+func TestSetColocation(t *testing.T) {
+	g := NewGomegaWithT(t)
+	mo := &v1alpha1.MatrixOneCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: "default",
+		},
+	}
+
+	o := &v1alpha1.Overlay{}
+	setColocation(o, mo, dnSetComponent)
+	g.Expect(o.Affinity).To(BeNil())
+
+	mo.Spec.Colocation = &v1alpha1.Colocation{}
+	setColocation(o, mo, dnSetComponent)
+	g.Expect(o.Affinity.PodAffinity.RequiredDuringSchedulingIgnoredDuringExecution).To(HaveLen(1))
+	dnTerm := o.Affinity.PodAffinity.RequiredDuringSchedulingIgnoredDuringExecution[0]
+	g.Expect(dnTerm.TopologyKey).To(Equal(corev1.LabelHostname))
+	g.Expect(dnTerm.LabelSelector.MatchExpressions[0].Values).To(ConsistOf(logSetComponent))
+
+	// the term is replaced rather than appended on resync
+	setColocation(o, mo, dnSetComponent)
+	g.Expect(o.Affinity.PodAffinity.RequiredDuringSchedulingIgnoredDuringExecution).To(HaveLen(1))
+
+	o = &v1alpha1.Overlay{}
+	setColocation(o, mo, logSetComponent)
+	g.Expect(o.Affinity.PodAffinity.PreferredDuringSchedulingIgnoredDuringExecution).To(HaveLen(1))
+
+	o = &v1alpha1.Overlay{}
+	setColocation(o, mo, cnSetComponent)
+	g.Expect(o.Affinity.PodAffinity).To(BeNil())
+	cnTerm := o.Affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution[0].PodAffinityTerm
+	g.Expect(cnTerm.LabelSelector.MatchExpressions[0].Values).To(ConsistOf(dnSetComponent, logSetComponent))
+}
+
+func TestSetColocationKeepUserAffinity(t *testing.T) {
+	g := NewGomegaWithT(t)
+	mo := &v1alpha1.MatrixOneCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: "default",
+		},
+		Spec: v1alpha1.MatrixOneClusterSpec{
+			Colocation: &v1alpha1.Colocation{},
+		},
+	}
+	nodeAffinity := &corev1.NodeAffinity{
+		RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+			NodeSelectorTerms: []corev1.NodeSelectorTerm{{
+				MatchExpressions: []corev1.NodeSelectorRequirement{{
+					Key:      "pool",
+					Operator: corev1.NodeSelectorOpIn,
+					Values:   []string{"mo"},
+				}},
+			}},
+		},
+	}
+	userTerm := corev1.PodAffinityTerm{
+		LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "proxy"}},
+		TopologyKey:   corev1.LabelHostname,
+	}
+	userAffinity := &corev1.Affinity{
+		NodeAffinity: nodeAffinity,
+		PodAffinity: &corev1.PodAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{userTerm},
+		},
+		PodAntiAffinity: &corev1.PodAntiAffinity{
+			PreferredDuringSchedulingIgnoredDuringExecution: []corev1.WeightedPodAffinityTerm{{Weight: 10, PodAffinityTerm: userTerm}},
+		},
+	}
+
+	o := &v1alpha1.Overlay{}
+	o.Affinity = userAffinity.DeepCopy()
+	setColocation(o, mo, dnSetComponent)
+	setColocation(o, mo, dnSetComponent)
+	g.Expect(o.Affinity.NodeAffinity).To(Equal(nodeAffinity))
+	required := o.Affinity.PodAffinity.RequiredDuringSchedulingIgnoredDuringExecution
+	g.Expect(required).To(HaveLen(2))
+	g.Expect(required[0]).To(Equal(userTerm))
+	g.Expect(required[1].LabelSelector.MatchExpressions[0].Values).To(ConsistOf(logSetComponent))
+
+	o = &v1alpha1.Overlay{}
+	o.Affinity = userAffinity.DeepCopy()
+	setColocation(o, mo, cnSetComponent)
+	g.Expect(o.Affinity.NodeAffinity).To(Equal(nodeAffinity))
+	g.Expect(o.Affinity.PodAffinity.RequiredDuringSchedulingIgnoredDuringExecution).To(Equal([]corev1.PodAffinityTerm{userTerm}))
+	preferred := o.Affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution
+	g.Expect(preferred).To(HaveLen(2))
+	g.Expect(preferred[0].PodAffinityTerm).To(Equal(userTerm))
+
+	// the generated terms are removed once colocation is disabled
+	mo.Spec.Colocation = nil
+	setColocation(o, mo, cnSetComponent)
+	g.Expect(o.Affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution).To(HaveLen(1))
+}
+
+func TestSetCNIsolation(t *testing.T) {
+	g := NewGomegaWithT(t)
+	mo := &v1alpha1.MatrixOneCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: "default",
+		},
+		Spec: v1alpha1.MatrixOneClusterSpec{
+			Colocation:  &v1alpha1.Colocation{},
+			CNIsolation: &v1alpha1.CNIsolation{},
+		},
+	}
+	o := &v1alpha1.Overlay{}
+	setColocation(o, mo, cnSetComponent)
+	setCNIsolation(o, mo, "test-tp")
+	preferred := o.Affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution
+	g.Expect(preferred).To(HaveLen(2))
+	term := preferred[1].PodAffinityTerm
+	g.Expect(term.TopologyKey).To(Equal(corev1.LabelHostname))
+	g.Expect(term.LabelSelector.MatchLabels).To(HaveKeyWithValue(common.ComponentLabelKey, cnSetComponent))
+	g.Expect(term.LabelSelector.MatchExpressions[0].Values).To(Equal([]string{"test-tp"}))
+
+	// the term is replaced rather than appended on resync
+	setCNIsolation(o, mo, "test-tp")
+	g.Expect(o.Affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution).To(HaveLen(2))
+
+	mo.Spec.CNIsolation.Policy = v1alpha1.AntiAffinityPolicyRequired
+	setCNIsolation(o, mo, "test-tp")
+	g.Expect(o.Affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution).To(HaveLen(1))
+	g.Expect(o.Affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution).To(HaveLen(1))
+
+	mo.Spec.CNIsolation = nil
+	setCNIsolation(o, mo, "test-tp")
+	g.Expect(o.Affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution).To(HaveLen(1))
+	g.Expect(o.Affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution).To(BeEmpty())
+}
+
+func TestSetTimezone(t *testing.T) {
+	g := NewGomegaWithT(t)
+	mo := &v1alpha1.MatrixOneCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: "default",
+		},
+	}
+	dn := &v1alpha1.DNSet{}
+
+	setOverlay(&dn.Spec.Overlay, mo)
+	setTimezone(dn, dn.Spec.Overlay, mo)
+	g.Expect(dn.Spec.Overlay.Env).To(BeEmpty())
+
+	mo.Spec.Timezone = "Asia/Shanghai"
+	dn.Spec.Overlay.Env = []corev1.EnvVar{{Name: "FOO", Value: "bar"}}
+	setTimezone(dn, dn.Spec.Overlay, mo)
+	g.Expect(dn.Spec.Overlay.Env).To(Equal([]corev1.EnvVar{{Name: "FOO", Value: "bar"}, {Name: timezoneEnvKey, Value: "Asia/Shanghai"}}))
+
+	// the injected TZ follows the timezone of the cluster
+	mo.Spec.Timezone = "Europe/Berlin"
+	setTimezone(dn, dn.Spec.Overlay, mo)
+	g.Expect(dn.Spec.Overlay.Env).To(Equal([]corev1.EnvVar{{Name: "FOO", Value: "bar"}, {Name: timezoneEnvKey, Value: "Europe/Berlin"}}))
+
+	mo.Spec.Timezone = ""
+	setTimezone(dn, dn.Spec.Overlay, mo)
+	g.Expect(dn.Spec.Overlay.Env).To(Equal([]corev1.EnvVar{{Name: "FOO", Value: "bar"}}))
+	g.Expect(dn.Annotations).ToNot(HaveKey(timezoneAnnotation))
+
+	// the TZ set by the user takes precedence
+	mo.Spec.Timezone = "Asia/Shanghai"
+	dn.Spec.Overlay.Env = []corev1.EnvVar{{Name: "FOO", Value: "bar"}, {Name: timezoneEnvKey, Value: "UTC"}}
+	setTimezone(dn, dn.Spec.Overlay, mo)
+	g.Expect(dn.Spec.Overlay.Env).To(Equal([]corev1.EnvVar{{Name: "FOO", Value: "bar"}, {Name: timezoneEnvKey, Value: "UTC"}}))
+	setTimezone(dn, dn.Spec.Overlay, mo)
+	g.Expect(dn.Spec.Overlay.Env).To(Equal([]corev1.EnvVar{{Name: "FOO", Value: "bar"}, {Name: timezoneEnvKey, Value: "UTC"}}))
+}
+
+func TestSyncWebUI(t *testing.T) {
+	g := NewGomegaWithT(t)
+	pullAlways := corev1.PullAlways
+	mo := &v1alpha1.MatrixOneCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: "default",
+		},
+		Spec: v1alpha1.MatrixOneClusterSpec{
+			WebUI: &v1alpha1.WebUIBasic{
+				PodSet: v1alpha1.PodSet{Replicas: 1},
+			},
+			ImagePullPolicy: &pullAlways,
+			PodLabels:       map[string]string{"team": "db", "cost-center": "mo"},
+			PodAnnotations:  map[string]string{"owner": "dba"},
+			Timezone:        "Asia/Shanghai",
+		},
+	}
+	webui := &v1alpha1.WebUI{
+		Spec: v1alpha1.WebUISpec{
+			Overlay: &v1alpha1.Overlay{PodLabels: map[string]string{"team": "web"}},
+		},
+	}
+	syncWebUI(mo, webui)
+	o := webui.Spec.Overlay
+	g.Expect(o.PodLabels).To(Equal(map[string]string{
+		"team":                   "web",
+		"cost-center":            "mo",
+		matrixoneClusterLabelKey: "test",
+	}))
+	g.Expect(o.PodAnnotations).To(Equal(map[string]string{"owner": "dba"}))
+	g.Expect(o.ImagePullPolicy).To(Equal(&pullAlways))
+	g.Expect(o.Env).To(Equal([]corev1.EnvVar{{Name: timezoneEnvKey, Value: "Asia/Shanghai"}}))
+}
+
+func TestSetCommonLabels(t *testing.T) {
+	g := NewGomegaWithT(t)
+	mo := &v1alpha1.MatrixOneCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: "default",
+		},
+		Spec: v1alpha1.MatrixOneClusterSpec{
+			CommonLabels: map[string]string{"team": "db", "env": "prod"},
+			DN: v1alpha1.DNSetBasic{PodSet: v1alpha1.PodSet{
+				VolumeMetadata: &v1alpha1.VolumeMetadata{Labels: map[string]string{"env": "staging"}},
+			}},
+		},
+	}
+	dn := &v1alpha1.DNSet{ObjectMeta: dnSetKey(mo)}
+	dn.Labels = map[string]string{"owner": "someone"}
+	syncDNSet(mo, dn)
+	g.Expect(dn.Labels).To(Equal(map[string]string{"owner": "someone", "team": "db", "env": "prod"}))
+	g.Expect(dn.Annotations[common.CommonLabelsAnnotation]).To(Equal("env,team"))
+	g.Expect(common.InheritedLabels(dn)).To(Equal(map[string]string{"team": "db", "env": "prod"}))
+	// the volume metadata of the set takes precedence and the cluster spec is not mutated
+	g.Expect(dn.Spec.VolumeMetadata.Labels).To(Equal(map[string]string{"team": "db", "env": "staging"}))
+	g.Expect(mo.Spec.DN.VolumeMetadata.Labels).To(Equal(map[string]string{"env": "staging"}))
+	g.Expect(dn.Spec.Overlay.PodLabels).To(HaveKeyWithValue("team", "db"))
+	g.Expect(dn.Spec.Overlay.PodLabels).To(HaveKeyWithValue(matrixoneClusterLabelKey, "test"))
+
+	// the labels removed from the cluster are cleaned up
+	mo.Spec.CommonLabels = map[string]string{"team": "db"}
+	syncDNSet(mo, dn)
+	g.Expect(dn.Labels).To(Equal(map[string]string{"owner": "someone", "team": "db"}))
+	g.Expect(dn.Annotations[common.CommonLabelsAnnotation]).To(Equal("team"))
+
+	mo.Spec.CommonLabels = nil
+	syncDNSet(mo, dn)
+	g.Expect(dn.Labels).To(Equal(map[string]string{"owner": "someone"}))
+	g.Expect(dn.Annotations).NotTo(HaveKey(common.CommonLabelsAnnotation))
+}
+
+func TestSetValidationAnnotations(t *testing.T) {
+	g := NewGomegaWithT(t)
+	mo := &v1alpha1.MatrixOneCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "test",
+			Namespace:   "default",
+			Annotations: map[string]string{v1alpha1.SkipCacheSizeValidationAnnotation: "true"},
+		},
+		Spec: v1alpha1.MatrixOneClusterSpec{
+			AP: &v1alpha1.CNSetBasic{},
+		},
+	}
+	dn := &v1alpha1.DNSet{ObjectMeta: dnSetKey(mo)}
+	tp := &v1alpha1.CNSet{ObjectMeta: tpSetKey(mo)}
+	ap := &v1alpha1.CNSet{ObjectMeta: apSetKey(mo)}
+	syncDNSet(mo, dn)
+	syncTPSet(mo, tp)
+	syncAPSet(mo, ap)
+	for _, obj := range []client.Object{dn, tp, ap} {
+		g.Expect(obj.GetAnnotations()).To(HaveKeyWithValue(v1alpha1.SkipCacheSizeValidationAnnotation, "true"), obj.GetName())
+	}
+
+	// the annotation removed from the cluster is removed from the sets
+	mo.Annotations = nil
+	syncTPSet(mo, tp)
+	g.Expect(tp.Annotations).NotTo(HaveKey(v1alpha1.SkipCacheSizeValidationAnnotation))
+}
+
+func TestSetResourceProfile(t *testing.T) {
+	g := NewGomegaWithT(t)
+	mo := &v1alpha1.MatrixOneCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: "default",
+		},
+		Spec: v1alpha1.MatrixOneClusterSpec{
+			ResourceProfile: v1alpha1.ResourceProfileDev,
+			DN: v1alpha1.DNSetBasic{PodSet: v1alpha1.PodSet{MainContainer: v1alpha1.MainContainer{
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")},
+					Limits: corev1.ResourceList{
+						corev1.ResourceCPU:    resource.MustParse("2"),
+						corev1.ResourceMemory: resource.MustParse("4Gi"),
+					},
+				},
+			}}},
+		},
+	}
+	dn := &v1alpha1.DNSet{ObjectMeta: dnSetKey(mo)}
+	syncDNSet(mo, dn)
+	requests := dn.Spec.Resources.Requests
+	g.Expect(requests.Cpu().String()).To(Equal("1"))
+	g.Expect(requests.Memory().String()).To(Equal("1Gi"))
+	// the cluster spec is not mutated
+	g.Expect(mo.Spec.DN.Resources.Requests).To(HaveLen(1))
+
+	mo.Spec.ResourceProfile = v1alpha1.ResourceProfileProduction
+	mo.Spec.DN.Resources.Limits = nil
+	syncDNSet(mo, dn)
+	g.Expect(dn.Spec.Resources.Limits.Cpu().String()).To(Equal("1"))
+	g.Expect(mo.Spec.DN.Resources.Limits).To(BeNil())
+}
+
+func TestHibernation(t *testing.T) {
+	g := NewGomegaWithT(t)
+	mo := &v1alpha1.MatrixOneCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: "default",
+		},
+		Spec: v1alpha1.MatrixOneClusterSpec{
+			DN:        v1alpha1.DNSetBasic{PodSet: v1alpha1.PodSet{Replicas: 2}},
+			TP:        v1alpha1.CNSetBasic{PodSet: v1alpha1.PodSet{Replicas: 2}},
+			Hibernate: true,
+		},
+		Status: v1alpha1.MatrixOneClusterStatus{
+			TP: &v1alpha1.CNSetStatus{FailoverStatus: v1alpha1.FailoverStatus{
+				AvailableStores: []v1alpha1.Store{{PodName: "tp-0"}},
+			}},
+			DN: &v1alpha1.DNSetStatus{},
+		},
+	}
+	tp := &v1alpha1.CNSet{ObjectMeta: metav1.ObjectMeta{CreationTimestamp: metav1.Now()}}
+	dn := &v1alpha1.DNSet{}
+
+	// CN sets are stopped first
+	syncTPSet(mo, tp)
+	syncDNSet(mo, dn)
+	g.Expect(tp.Spec.Replicas).To(BeZero())
+	g.Expect(dn.Spec.Replicas).To(Equal(int32(2)))
+
+	// DN set is stopped after all CN pods are gone
+	mo.Status.TP.AvailableStores = nil
+	syncDNSet(mo, dn)
+	g.Expect(dn.Spec.Replicas).To(BeZero())
+
+	// CN sets wait for DN set on wakeup
+	mo.Spec.Hibernate = false
+	syncDNSet(mo, dn)
+	syncTPSet(mo, tp)
+	g.Expect(dn.Spec.Replicas).To(Equal(int32(2)))
+	g.Expect(tp.Spec.Replicas).To(BeZero())
+
+	mo.Status.DN.AvailableStores = []v1alpha1.Store{{PodName: "dn-0"}, {PodName: "dn-1"}}
+	syncTPSet(mo, tp)
+	g.Expect(tp.Spec.Replicas).To(Equal(int32(2)))
+}
+
 func newScheme() *runtime.Scheme {
 	scheme := runtime.NewScheme()
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
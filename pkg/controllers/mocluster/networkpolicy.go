@@ -0,0 +1,108 @@
+// Copyright 2023 Matrix Origin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mocluster
+
+import (
+	"sort"
+
+	recon "github.com/matrixorigin/controller-runtime/pkg/reconciler"
+	"github.com/matrixorigin/controller-runtime/pkg/util"
+	"github.com/matrixorigin/matrixone-operator/api/core/v1alpha1"
+	"github.com/matrixorigin/matrixone-operator/pkg/controllers/cnset"
+	"github.com/matrixorigin/matrixone-operator/pkg/controllers/dnset"
+	"github.com/matrixorigin/matrixone-operator/pkg/controllers/logset"
+	"github.com/matrixorigin/matrixone-operator/pkg/controllers/webui"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// syncNetworkPolicy creates or updates the NetworkPolicy of the cluster if enabled, otherwise
+// the NetworkPolicy is deleted
+func syncNetworkPolicy(ctx *recon.Context[*v1alpha1.MatrixOneCluster]) error {
+	mo := ctx.Obj
+	np := &networkingv1.NetworkPolicy{ObjectMeta: networkPolicyKey(mo)}
+	if mo.Spec.NetworkPolicy == nil || !mo.Spec.NetworkPolicy.Enabled {
+		exist, err := ctx.Exist(client.ObjectKeyFromObject(np), &networkingv1.NetworkPolicy{})
+		if err != nil || !exist {
+			return err
+		}
+		return util.Ignore(apierrors.IsNotFound, ctx.Delete(np))
+	}
+	return recon.CreateOwnedOrUpdate(ctx, np, func() error {
+		syncNetworkPolicySpec(mo, np)
+		return nil
+	})
+}
+
+// syncNetworkPolicySpec allows the traffic between the pods of the cluster on the ports of the
+// components, the traffic from anywhere to the ports that serve the clients and the scraping
+// from the configured peers
+func syncNetworkPolicySpec(mo *v1alpha1.MatrixOneCluster, np *networkingv1.NetworkPolicy) {
+	clusterPods := metav1.LabelSelector{
+		MatchLabels: map[string]string{matrixoneClusterLabelKey: mo.Name},
+	}
+	var internal []int32
+	for _, ports := range [][]int32{logset.Ports(), dnset.Ports(), cnset.Ports(), webui.Ports()} {
+		for _, p := range ports {
+			internal = util.Upsert(internal, p)
+		}
+	}
+	sort.Slice(internal, func(i, j int) bool {
+		return internal[i] < internal[j]
+	})
+	rules := []networkingv1.NetworkPolicyIngressRule{{
+		From: []networkingv1.NetworkPolicyPeer{{PodSelector: &clusterPods}},
+		// the LogService ports are allowed on UDP as well since the gossip runs on both protocols
+		Ports: append(policyPorts(corev1.ProtocolTCP, internal), policyPorts(corev1.ProtocolUDP, logset.Ports())...),
+	}, {
+		// the clients may come from anywhere
+		Ports: policyPorts(corev1.ProtocolTCP, append([]int32{cnset.CNSQLPort}, webui.Ports()...)),
+	}}
+	if p := mo.Spec.NetworkPolicy; len(p.MetricsFrom) > 0 {
+		rules = append(rules, networkingv1.NetworkPolicyIngressRule{
+			From:  p.MetricsFrom,
+			Ports: policyPorts(corev1.ProtocolTCP, []int32{p.GetMetricsPort()}),
+		})
+	}
+	np.Spec = networkingv1.NetworkPolicySpec{
+		PodSelector: clusterPods,
+		Ingress:     rules,
+		PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+	}
+}
+
+func policyPorts(protocol corev1.Protocol, ports []int32) []networkingv1.NetworkPolicyPort {
+	var res []networkingv1.NetworkPolicyPort
+	for _, p := range ports {
+		protocol := protocol
+		port := intstr.FromInt(int(p))
+		res = append(res, networkingv1.NetworkPolicyPort{
+			Protocol: &protocol,
+			Port:     &port,
+		})
+	}
+	return res
+}
+
+func networkPolicyKey(mo *v1alpha1.MatrixOneCluster) metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		Name:      mo.Name,
+		Namespace: mo.Namespace,
+	}
+}
@@ -0,0 +1,69 @@
+// Copyright 2023 Matrix Origin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mocluster
+
+import (
+	"testing"
+
+	"github.com/matrixorigin/matrixone-operator/api/core/v1alpha1"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+)
+
+func TestSyncNetworkPolicySpec(t *testing.T) {
+	g := NewGomegaWithT(t)
+	mo := &v1alpha1.MatrixOneCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: "default",
+		},
+		Spec: v1alpha1.MatrixOneClusterSpec{
+			NetworkPolicy: &v1alpha1.NetworkPolicy{Enabled: true},
+		},
+	}
+	ports := func(rule networkingv1.NetworkPolicyIngressRule, protocol corev1.Protocol) []int {
+		var res []int
+		for _, p := range rule.Ports {
+			if *p.Protocol == protocol {
+				res = append(res, p.Port.IntValue())
+			}
+		}
+		return res
+	}
+
+	np := &networkingv1.NetworkPolicy{}
+	syncNetworkPolicySpec(mo, np)
+	g.Expect(np.Spec.PodSelector.MatchLabels).To(Equal(map[string]string{matrixoneClusterLabelKey: "test"}))
+	g.Expect(np.Spec.Ingress).To(HaveLen(2))
+	internal := np.Spec.Ingress[0]
+	g.Expect(internal.From).To(Equal([]networkingv1.NetworkPolicyPeer{{PodSelector: &np.Spec.PodSelector}}))
+	g.Expect(ports(internal, corev1.ProtocolTCP)).To(Equal([]int{6001, 6002, 6003, 8001, 8007, 32000, 32001, 32002, 41010}))
+	g.Expect(ports(internal, corev1.ProtocolUDP)).To(Equal([]int{32000, 32001, 32002}))
+	public := np.Spec.Ingress[1]
+	g.Expect(public.From).To(BeEmpty())
+	g.Expect(ports(public, corev1.ProtocolTCP)).To(Equal([]int{6001, 8007, 8001}))
+
+	mo.Spec.NetworkPolicy.MetricsFrom = []networkingv1.NetworkPolicyPeer{{
+		NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"name": "monitoring"}},
+	}}
+	mo.Spec.NetworkPolicy.MetricsPort = pointer.Int32(9090)
+	syncNetworkPolicySpec(mo, np)
+	g.Expect(np.Spec.Ingress).To(HaveLen(3))
+	g.Expect(np.Spec.Ingress[2].From).To(Equal(mo.Spec.NetworkPolicy.MetricsFrom))
+	g.Expect(ports(np.Spec.Ingress[2], corev1.ProtocolTCP)).To(Equal([]int{9090}))
+}
@@ -0,0 +1,120 @@
+// Copyright 2023 Matrix Origin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mocluster
+
+import (
+	"github.com/matrixorigin/matrixone-operator/api/core/v1alpha1"
+	"github.com/matrixorigin/matrixone-operator/pkg/controllers/cnset"
+	"github.com/matrixorigin/matrixone-operator/pkg/controllers/dnset"
+	"github.com/matrixorigin/matrixone-operator/pkg/controllers/logset"
+	"github.com/matrixorigin/matrixone-operator/pkg/controllers/webui"
+	"github.com/pkg/errors"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// RenderManifests renders the sets of the MatrixOneCluster and the underlying objects of these sets
+// without touching the cluster, which is useful for auditing and offline validation.
+// Objects that are decided at runtime (e.g. the bootstrap config of the LogSet) are not included.
+// The sets are defaulted with the given webhook policy as the webhooks of the operator do.
+func RenderManifests(cluster *v1alpha1.MatrixOneCluster, policy *v1alpha1.WebhookPolicy) ([]client.Object, error) {
+	mo := cluster.DeepCopy()
+	mo.Default(policy)
+
+	var objs []client.Object
+	ls := &v1alpha1.LogSet{
+		TypeMeta:   typeMeta("LogSet"),
+		ObjectMeta: logSetKey(mo),
+	}
+	syncLogSet(mo, ls)
+	ls.Default()
+	lsObjs, err := logset.RenderManifests(ls)
+	if err != nil {
+		return nil, errors.Wrap(err, "render LogSet")
+	}
+	objs = append(objs, ls)
+	objs = append(objs, lsObjs...)
+	// the discovery info will be exposed by the LogSet controller at runtime
+	lsDep := ls.DeepCopy()
+	lsDep.Status.Discovery = logset.Discovery(lsDep)
+
+	dn := &v1alpha1.DNSet{
+		TypeMeta:   typeMeta("DNSet"),
+		ObjectMeta: dnSetKey(mo),
+	}
+	syncDNSet(mo, dn)
+	dn.Default(policy)
+	dnObjs, err := dnset.RenderManifests(dn, lsDep)
+	if err != nil {
+		return nil, errors.Wrap(err, "render DNSet")
+	}
+	objs = append(objs, dn)
+	objs = append(objs, dnObjs...)
+
+	cnSets := []*v1alpha1.CNSet{{
+		TypeMeta:   typeMeta("CNSet"),
+		ObjectMeta: tpSetKey(mo),
+	}}
+	syncTPSet(mo, cnSets[0])
+	if mo.Spec.AP != nil {
+		ap := &v1alpha1.CNSet{
+			TypeMeta:   typeMeta("CNSet"),
+			ObjectMeta: apSetKey(mo),
+		}
+		syncAPSet(mo, ap)
+		cnSets = append(cnSets, ap)
+	}
+	for _, cn := range cnSets {
+		cn.Default(policy)
+		cnObjs, err := cnset.RenderManifests(cn, lsDep)
+		if err != nil {
+			return nil, errors.Wrapf(err, "render CNSet %s", cn.Name)
+		}
+		objs = append(objs, cn)
+		objs = append(objs, cnObjs...)
+	}
+
+	if mo.Spec.WebUI != nil {
+		wi := &v1alpha1.WebUI{
+			TypeMeta:   typeMeta("WebUI"),
+			ObjectMeta: webUIKey(mo),
+		}
+		syncWebUI(mo, wi)
+		wi.Default()
+		wiObjs, err := webui.RenderManifests(wi)
+		if err != nil {
+			return nil, errors.Wrap(err, "render WebUI")
+		}
+		objs = append(objs, wi)
+		objs = append(objs, wiObjs...)
+	}
+	if mo.Spec.NetworkPolicy != nil && mo.Spec.NetworkPolicy.Enabled {
+		np := &networkingv1.NetworkPolicy{
+			TypeMeta:   metav1.TypeMeta{APIVersion: networkingv1.SchemeGroupVersion.String(), Kind: "NetworkPolicy"},
+			ObjectMeta: networkPolicyKey(mo),
+		}
+		syncNetworkPolicySpec(mo, np)
+		objs = append(objs, np)
+	}
+	return objs, nil
+}
+
+func typeMeta(kind string) metav1.TypeMeta {
+	return metav1.TypeMeta{
+		APIVersion: v1alpha1.GroupVersion.String(),
+		Kind:       kind,
+	}
+}
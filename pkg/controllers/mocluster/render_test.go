@@ -0,0 +1,87 @@
+// Copyright 2023 Matrix Origin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mocluster
+
+import (
+	"testing"
+
+	"github.com/matrixorigin/matrixone-operator/api/core/v1alpha1"
+	"github.com/matrixorigin/matrixone-operator/pkg/controllers/common"
+	. "github.com/onsi/gomega"
+	kruisev1 "github.com/openkruise/kruise-api/apps/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestRenderManifests(t *testing.T) {
+	g := NewGomegaWithT(t)
+	mo := &v1alpha1.MatrixOneCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: "default",
+		},
+		Spec: v1alpha1.MatrixOneClusterSpec{
+			LogService: v1alpha1.LogSetBasic{
+				PodSet: v1alpha1.PodSet{
+					Replicas: 3,
+				},
+				Volume: v1alpha1.Volume{
+					Size: resource.MustParse("10Gi"),
+				},
+				SharedStorage: v1alpha1.SharedStorageProvider{
+					S3: &v1alpha1.S3Provider{Path: "test/data"},
+				},
+			},
+			DN: v1alpha1.DNSetBasic{
+				PodSet: v1alpha1.PodSet{
+					Replicas: 2,
+				},
+			},
+			TP: v1alpha1.CNSetBasic{
+				PodSet: v1alpha1.PodSet{
+					Replicas: 2,
+				},
+			},
+			Version:         "test",
+			ImageRepository: "test",
+		},
+	}
+	origin := mo.DeepCopy()
+
+	policy := v1alpha1.DefaultWebhookPolicy()
+	objs, err := RenderManifests(mo, &policy)
+	g.Expect(err).To(Succeed())
+	g.Expect(mo).To(Equal(origin), "rendering should not mutate the cluster")
+
+	var sts []*kruisev1.StatefulSet
+	var cms []*corev1.ConfigMap
+	for _, o := range objs {
+		switch obj := o.(type) {
+		case *kruisev1.StatefulSet:
+			sts = append(sts, obj)
+		case *corev1.ConfigMap:
+			cms = append(cms, obj)
+		}
+	}
+	// logset, dnset and tp cnset
+	g.Expect(sts).To(HaveLen(3))
+	for _, s := range sts {
+		g.Expect(s.Spec.Template.Labels[matrixoneClusterLabelKey]).To(Equal(mo.Name))
+		g.Expect(s.Spec.Template.Labels[common.ComponentLabelKey]).NotTo(BeEmpty())
+	}
+	// gossip config of logset and the configs of the sets
+	g.Expect(cms).To(HaveLen(4))
+}
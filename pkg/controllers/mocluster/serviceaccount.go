@@ -0,0 +1,103 @@
+// Copyright 2023 Matrix Origin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mocluster
+
+import (
+	"fmt"
+
+	recon "github.com/matrixorigin/controller-runtime/pkg/reconciler"
+	"github.com/matrixorigin/controller-runtime/pkg/util"
+	"github.com/matrixorigin/matrixone-operator/api/core/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// awsRoleARNAnnotation binds a ServiceAccount to an IAM role through IRSA
+	awsRoleARNAnnotation = "eks.amazonaws.com/role-arn"
+	// gcpServiceAccountAnnotation binds a ServiceAccount to a GCP service account through GKE workload identity
+	gcpServiceAccountAnnotation = "iam.gke.io/gcp-service-account"
+)
+
+// the components that a ServiceAccount is created for, which are also the name suffixes of the ServiceAccounts
+const (
+	logServiceAccount = "log"
+	dnServiceAccount  = "dn"
+	tpServiceAccount  = "tp"
+	apServiceAccount  = "ap"
+)
+
+// syncServiceAccounts creates or updates the ServiceAccounts of the components if required, the
+// ServiceAccounts that are no longer required are deleted
+func syncServiceAccounts(ctx *recon.Context[*v1alpha1.MatrixOneCluster]) error {
+	mo := ctx.Obj
+	for _, component := range []string{logServiceAccount, dnServiceAccount, tpServiceAccount, apServiceAccount} {
+		sa := &corev1.ServiceAccount{ObjectMeta: serviceAccountKey(mo, component)}
+		if mo.Spec.ServiceAccount == nil || (component == apServiceAccount && mo.Spec.AP == nil) {
+			exist, err := ctx.Exist(client.ObjectKeyFromObject(sa), &corev1.ServiceAccount{})
+			if err != nil {
+				return err
+			}
+			if exist {
+				if err := util.Ignore(apierrors.IsNotFound, ctx.Delete(sa)); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if err := recon.CreateOwnedOrUpdate(ctx, sa, func() error {
+			setCommonLabels(sa, mo)
+			syncServiceAccountIdentity(mo.Spec.ServiceAccount, sa)
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// syncServiceAccountIdentity annotates the ServiceAccount with the cloud identities, the annotations
+// of the identities that are unset are removed
+func syncServiceAccountIdentity(msa *v1alpha1.ManagedServiceAccount, sa *corev1.ServiceAccount) {
+	if sa.Annotations == nil {
+		sa.Annotations = map[string]string{}
+	}
+	setOrDelete := func(k, v string) {
+		if v == "" {
+			delete(sa.Annotations, k)
+			return
+		}
+		sa.Annotations[k] = v
+	}
+	setOrDelete(awsRoleARNAnnotation, msa.AWSRoleARN)
+	setOrDelete(gcpServiceAccountAnnotation, msa.GCPServiceAccount)
+}
+
+// setManagedServiceAccount runs the pods of the set with the ServiceAccount created for the component
+// if the set does not specify its own
+func setManagedServiceAccount(ps *v1alpha1.PodSet, mo *v1alpha1.MatrixOneCluster, component string) {
+	if mo.Spec.ServiceAccount != nil && ps.ServiceAccountName == "" {
+		ps.ServiceAccountName = serviceAccountKey(mo, component).Name
+	}
+}
+
+func serviceAccountKey(mo *v1alpha1.MatrixOneCluster, component string) metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		Name:      fmt.Sprintf("%s-%s", mo.Name, component),
+		Namespace: mo.Namespace,
+	}
+}
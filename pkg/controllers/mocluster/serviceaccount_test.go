@@ -0,0 +1,62 @@
+// Copyright 2023 Matrix Origin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mocluster
+
+import (
+	"testing"
+
+	"github.com/matrixorigin/matrixone-operator/api/core/v1alpha1"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSyncServiceAccountIdentity(t *testing.T) {
+	g := NewGomegaWithT(t)
+	sa := &corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{
+		Annotations: map[string]string{"foo": "bar"},
+	}}
+	syncServiceAccountIdentity(&v1alpha1.ManagedServiceAccount{
+		AWSRoleARN: "arn:aws:iam::123456789012:role/mo",
+	}, sa)
+	g.Expect(sa.Annotations).To(Equal(map[string]string{
+		"foo":                "bar",
+		awsRoleARNAnnotation: "arn:aws:iam::123456789012:role/mo",
+	}))
+
+	syncServiceAccountIdentity(&v1alpha1.ManagedServiceAccount{
+		GCPServiceAccount: "matrixone@my-project.iam.gserviceaccount.com",
+	}, sa)
+	g.Expect(sa.Annotations).To(Equal(map[string]string{
+		"foo":                       "bar",
+		gcpServiceAccountAnnotation: "matrixone@my-project.iam.gserviceaccount.com",
+	}))
+}
+
+func TestSetManagedServiceAccount(t *testing.T) {
+	g := NewGomegaWithT(t)
+	mo := &v1alpha1.MatrixOneCluster{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"}}
+	ps := &v1alpha1.PodSet{}
+	setManagedServiceAccount(ps, mo, dnServiceAccount)
+	g.Expect(ps.ServiceAccountName).To(BeEmpty())
+
+	mo.Spec.ServiceAccount = &v1alpha1.ManagedServiceAccount{AWSRoleARN: "arn:aws:iam::123456789012:role/mo"}
+	setManagedServiceAccount(ps, mo, dnServiceAccount)
+	g.Expect(ps.ServiceAccountName).To(Equal("test-dn"))
+
+	ps.ServiceAccountName = "custom"
+	setManagedServiceAccount(ps, mo, dnServiceAccount)
+	g.Expect(ps.ServiceAccountName).To(Equal("custom"))
+}
@@ -19,6 +19,7 @@ import (
 	"github.com/matrixorigin/controller-runtime/pkg/util"
 	"github.com/matrixorigin/matrixone-operator/api/core/v1alpha1"
 	"github.com/matrixorigin/matrixone-operator/pkg/controllers/common"
+	"github.com/matrixorigin/matrixone-operator/pkg/metrics"
 	"github.com/pkg/errors"
 	"github.com/samber/lo"
 	"go.uber.org/multierr"
@@ -94,7 +95,10 @@ func (w *Actor) Observe(ctx *recon.Context[*v1alpha1.WebUI]) (recon.Action[*v1al
 		return nil, errors.Wrap(err, "list webui pods")
 	}
 
-	common.CollectStoreStatus(&wi.Status.FailoverStatus, podList.Items)
+	common.CollectStoreStatus(&wi.Status.FailoverStatus, podList.Items, 0)
+	wi.Status.PodSummary = common.CollectPodSummary(podList.Items)
+	common.CollectImagePullStatus(&wi.Status.ConditionalStatus, podList.Items, wi.Spec.GetImagePullDeadline())
+	wi.Status.ResolvedImage = common.ResolvedImage(&dp.Spec.Template)
 
 	if len(wi.Status.AvailableStores) >= int(wi.Spec.Replicas) {
 		wi.Status.SetCondition(metav1.Condition{
@@ -146,29 +150,14 @@ func (w *Actor) Finalize(ctx *recon.Context[*v1alpha1.WebUI]) (bool, error) {
 }
 
 func (w *Actor) Create(ctx *recon.Context[*v1alpha1.WebUI]) error {
-	wi := ctx.Obj
 	ctx.Log.Info("create webui service")
-
-	wiObj := buildWebUI(wi)
-	wiSvc := buildService(wi)
-	syncReplicas(wi, wiObj)
-	syncPodMeta(wi, wiObj)
-	syncPodSpec(wi, wiObj)
-
-	configMap, err := buildConfigMap(wi)
+	objs, err := RenderManifests(ctx.Obj)
 	if err != nil {
 		return err
 	}
 
-	if err := common.SyncConfigMap(ctx, &wiObj.Spec.Template.Spec, configMap); err != nil {
-		return err
-	}
-
 	// create all resources
-	err = lo.Reduce[client.Object, error]([]client.Object{
-		wiSvc,
-		wiObj,
-	}, func(errs error, o client.Object, _ int) error {
+	err = lo.Reduce[client.Object, error](objs, func(errs error, o client.Object, _ int) error {
 		err := ctx.CreateOwned(o)
 		return multierr.Append(errs, util.Ignore(apierrors.IsAlreadyExists, err))
 	}, nil)
@@ -179,6 +168,25 @@ func (w *Actor) Create(ctx *recon.Context[*v1alpha1.WebUI]) error {
 	return nil
 }
 
+// RenderManifests renders the underlying objects of the WebUI without touching the cluster
+func RenderManifests(wi *v1alpha1.WebUI) ([]client.Object, error) {
+	wiObj := buildWebUI(wi)
+	wiSvc := buildService(wi)
+	syncReplicas(wi, wiObj)
+	syncPodMeta(wi, wiObj)
+	syncPodSpec(wi, wiObj)
+
+	configMap, err := buildConfigMap(wi)
+	if err != nil {
+		return nil, err
+	}
+	cm, err := common.RenderConfigMap(&wiObj.Spec.Template.Spec, configMap, common.ConfigFile)
+	if err != nil {
+		return nil, err
+	}
+	return []client.Object{cm, wiSvc, wiObj}, nil
+}
+
 func (r *WithResource) Update(ctx *recon.Context[*v1alpha1.WebUI]) error {
 	return ctx.Update(r.dp)
 }
@@ -192,7 +200,7 @@ func (r *WithResource) SvcUpdate(ctx *recon.Context[*v1alpha1.WebUI]) error {
 }
 
 func (w *Actor) Reconcile(mgr manager.Manager) error {
-	err := recon.Setup[*v1alpha1.WebUI](&v1alpha1.WebUI{}, "webui", mgr, w,
+	err := recon.Setup[*v1alpha1.WebUI](&v1alpha1.WebUI{}, "webui", mgr, metrics.Instrument[*v1alpha1.WebUI]("webui", w),
 		recon.WithBuildFn(func(b *builder.Builder) {
 			b.Owns(&appsv1.Deployment{}).
 				Owns(&corev1.Service{})
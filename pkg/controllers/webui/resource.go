@@ -38,6 +38,11 @@ const (
 	rootPassword = "111"
 )
 
+// Ports returns the ports that the WebUI pods listen on
+func Ports() []int32 {
+	return []int32{serverPort, frontendPort}
+}
+
 func syncReplicas(wi *v1alpha1.WebUI, dp *appsv1.Deployment) {
 	dp.Spec.Replicas = &wi.Spec.Replicas
 }
@@ -76,13 +81,16 @@ func syncPodSpec(wi *v1alpha1.WebUI, dp *appsv1.Deployment) {
 
 	dp.Spec.Strategy = updateStrategy
 	dp.Spec.Replicas = &wi.Spec.Replicas
+	dp.Spec.RevisionHistoryLimit = wi.Spec.RevisionHistoryLimit
 
 	specRef.Containers = []corev1.Container{bi, fi}
 	specRef.ReadinessGates = []corev1.PodReadinessGate{{
 		ConditionType: pub.InPlaceUpdateReady,
 	}}
 	specRef.NodeSelector = wi.Spec.NodeSelector
-	common.SyncTopology(wi.Spec.TopologyEvenSpread, specRef)
+	specRef.ServiceAccountName = wi.Spec.ServiceAccountName
+	common.SyncTopology(wi.Spec.TopologyEvenSpread, wi.Spec.TopologySpreadPolicy, wi, specRef)
+	common.SyncSysctls(wi.Spec.Sysctls, specRef)
 	wi.Spec.Overlay.OverlayPodSpec(specRef)
 }
 
@@ -139,7 +147,7 @@ func syncPods(ctx *recon.Context[*v1alpha1.WebUI], dp *appsv1.Deployment) error
 	syncPodMeta(ctx.Obj, dp)
 	syncPodSpec(ctx.Obj, dp)
 
-	return common.SyncConfigMap(ctx, &dp.Spec.Template.Spec, cm)
+	return common.SyncConfigMap(ctx, &dp.Spec.Template.Spec, cm, common.ConfigFile)
 }
 
 func syncServiceType(wi *v1alpha1.WebUI, svc *corev1.Service) {
@@ -14,7 +14,10 @@
 
 package webui
 
-import "github.com/matrixorigin/matrixone-operator/api/core/v1alpha1"
+import (
+	"github.com/matrixorigin/matrixone-operator/api/core/v1alpha1"
+	"github.com/matrixorigin/matrixone-operator/pkg/controllers/common"
+)
 
 const (
 	objSuffix    = "-webui"
@@ -22,9 +25,16 @@ const (
 )
 
 func webUIName(wi *v1alpha1.WebUI) string {
-	return wi.Name + objSuffix
+	return common.ResourceName(resourceName(wi), "", common.MaxServiceNameLength)
 }
 
 func configMapName(wi *v1alpha1.WebUI) string {
-	return webUIName(wi) + configSuffix
+	return common.ResourceName(resourceName(wi), configSuffix, common.MaxConfigMapNameLength)
+}
+
+func resourceName(wi *v1alpha1.WebUI) string {
+	if wi.Spec.NameOverride != "" {
+		return wi.Spec.NameOverride
+	}
+	return wi.Name + objSuffix
 }
@@ -0,0 +1,160 @@
+// Copyright 2023 Matrix Origin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"sync"
+	"time"
+
+	recon "github.com/matrixorigin/controller-runtime/pkg/reconciler"
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	controllermetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	namespace = "matrixone"
+	subsystem = "operator"
+)
+
+var (
+	reconcileDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "reconcile_duration_seconds",
+		Help:      "Duration of the reconciliations of each object, including the observation and the action taken",
+		Buckets:   prometheus.ExponentialBuckets(0.005, 2, 14),
+	}, []string{"kind", "namespace", "name"})
+
+	reconcileErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "reconcile_errors_total",
+		Help:      "Total number of the reconciliations of each object that failed with an error",
+	}, []string{"kind", "namespace", "name"})
+
+	reconcileRequeues = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "reconcile_requeues_total",
+		Help:      "Total number of the reconciliations of each object that requested a resync",
+	}, []string{"kind", "namespace", "name"})
+
+	phaseTransitionTime = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "phase_transition_timestamp_seconds",
+		Help:      "Unix timestamp when the object entered its current phase, time in phase is time() minus this value",
+	}, []string{"kind", "namespace", "name", "phase"})
+)
+
+func init() {
+	controllermetrics.Registry.MustRegister(reconcileDuration, reconcileErrors, reconcileRequeues, phaseTransitionTime)
+}
+
+// Phased is implemented by the objects that expose a phase in status
+type Phased interface {
+	GetPhase() string
+}
+
+// Instrument wraps the actor to record the reconcile metrics of each object it reconciles
+func Instrument[T client.Object](kind string, actor recon.Actor[T]) recon.Actor[T] {
+	return &instrumentedActor[T]{
+		Actor:  actor,
+		kind:   kind,
+		phases: map[string]string{},
+	}
+}
+
+type instrumentedActor[T client.Object] struct {
+	recon.Actor[T]
+	kind string
+
+	sync.Mutex
+	// phases caches the last observed phase of each object
+	phases map[string]string
+}
+
+func (a *instrumentedActor[T]) Observe(ctx *recon.Context[T]) (recon.Action[T], error) {
+	start := time.Now()
+	action, err := a.Actor.Observe(ctx)
+	a.observePhase(ctx.Obj)
+	if err != nil || action == nil {
+		a.record(ctx.Obj, start, err)
+		return action, err
+	}
+	// the action will be executed right after the observation in the same reconciliation
+	return func(ctx *recon.Context[T]) error {
+		err := action(ctx)
+		a.record(ctx.Obj, start, err)
+		return err
+	}, nil
+}
+
+func (a *instrumentedActor[T]) Finalize(ctx *recon.Context[T]) (bool, error) {
+	done, err := a.Actor.Finalize(ctx)
+	if done && err == nil {
+		a.forget(ctx.Obj)
+	}
+	return done, err
+}
+
+func (a *instrumentedActor[T]) record(obj client.Object, start time.Time, err error) {
+	labels := []string{a.kind, obj.GetNamespace(), obj.GetName()}
+	reconcileDuration.WithLabelValues(labels...).Observe(time.Since(start).Seconds())
+	if err == nil {
+		return
+	}
+	if _, ok := err.(*recon.ReSync); ok {
+		reconcileRequeues.WithLabelValues(labels...).Inc()
+	} else {
+		reconcileErrors.WithLabelValues(labels...).Inc()
+	}
+}
+
+func (a *instrumentedActor[T]) observePhase(obj client.Object) {
+	p, ok := any(obj).(Phased)
+	if !ok {
+		return
+	}
+	phase := p.GetPhase()
+	key := client.ObjectKeyFromObject(obj).String()
+	a.Lock()
+	defer a.Unlock()
+	last, ok := a.phases[key]
+	if ok && last == phase {
+		return
+	}
+	if ok {
+		phaseTransitionTime.DeleteLabelValues(a.kind, obj.GetNamespace(), obj.GetName(), last)
+	}
+	a.phases[key] = phase
+	phaseTransitionTime.WithLabelValues(a.kind, obj.GetNamespace(), obj.GetName(), phase).SetToCurrentTime()
+}
+
+// forget deletes the metrics of the object after it is finalized
+func (a *instrumentedActor[T]) forget(obj client.Object) {
+	labels := []string{a.kind, obj.GetNamespace(), obj.GetName()}
+	reconcileDuration.DeleteLabelValues(labels...)
+	reconcileErrors.DeleteLabelValues(labels...)
+	reconcileRequeues.DeleteLabelValues(labels...)
+	key := client.ObjectKeyFromObject(obj).String()
+	a.Lock()
+	defer a.Unlock()
+	if last, ok := a.phases[key]; ok {
+		phaseTransitionTime.DeleteLabelValues(append(labels, last)...)
+		delete(a.phases, key)
+	}
+}
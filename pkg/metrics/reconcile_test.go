@@ -0,0 +1,76 @@
+// Copyright 2023 Matrix Origin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"errors"
+	"testing"
+
+	recon "github.com/matrixorigin/controller-runtime/pkg/reconciler"
+	"github.com/matrixorigin/matrixone-operator/api/core/v1alpha1"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type fakeActor struct {
+	action recon.Action[*v1alpha1.MatrixOneCluster]
+	err    error
+}
+
+func (f *fakeActor) Observe(ctx *recon.Context[*v1alpha1.MatrixOneCluster]) (recon.Action[*v1alpha1.MatrixOneCluster], error) {
+	ctx.Obj.Status.Phase = "Ready"
+	return f.action, f.err
+}
+
+func (f *fakeActor) Finalize(ctx *recon.Context[*v1alpha1.MatrixOneCluster]) (bool, error) {
+	return true, nil
+}
+
+func TestInstrument(t *testing.T) {
+	g := NewGomegaWithT(t)
+	mo := &v1alpha1.MatrixOneCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      "test",
+		},
+	}
+	ctx := &recon.Context[*v1alpha1.MatrixOneCluster]{Obj: mo}
+	labels := []string{"matrixonecluster", "default", "test"}
+
+	fake := &fakeActor{err: recon.ErrReSync("wait")}
+	a := Instrument[*v1alpha1.MatrixOneCluster]("matrixonecluster", fake)
+	_, err := a.Observe(ctx)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(testutil.ToFloat64(reconcileRequeues.WithLabelValues(labels...))).To(Equal(1.0))
+	g.Expect(testutil.ToFloat64(reconcileErrors.WithLabelValues(labels...))).To(Equal(0.0))
+	g.Expect(testutil.ToFloat64(phaseTransitionTime.WithLabelValues(append(labels, "Ready")...))).To(BeNumerically(">", 0))
+
+	fake.err = nil
+	fake.action = func(ctx *recon.Context[*v1alpha1.MatrixOneCluster]) error {
+		return errors.New("failed")
+	}
+	action, err := a.Observe(ctx)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(action(ctx)).ToNot(Succeed())
+	g.Expect(testutil.ToFloat64(reconcileErrors.WithLabelValues(labels...))).To(Equal(1.0))
+	g.Expect(testutil.CollectAndCount(reconcileDuration)).To(Equal(1))
+
+	done, err := a.Finalize(ctx)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(done).To(BeTrue())
+	g.Expect(testutil.CollectAndCount(reconcileDuration)).To(Equal(0))
+	g.Expect(testutil.CollectAndCount(phaseTransitionTime)).To(Equal(0))
+}
@@ -22,6 +22,7 @@ import (
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"strings"
 )
 
 func CheckVolumeMount(key string, list []corev1.VolumeMount) bool {
@@ -80,3 +81,19 @@ func mutate(f func() error, key client.ObjectKey, obj client.Object) error {
 	}
 	return nil
 }
+
+// WatchNamespaces parses the comma separated namespaces that the operator is scoped to,
+// nil means all the namespaces are watched
+func WatchNamespaces(s string) []string {
+	var namespaces []string
+	seen := map[string]bool{}
+	for _, ns := range strings.Split(s, ",") {
+		ns = strings.TrimSpace(ns)
+		if ns == "" || seen[ns] {
+			continue
+		}
+		seen[ns] = true
+		namespaces = append(namespaces, ns)
+	}
+	return namespaces
+}
@@ -0,0 +1,47 @@
+// Copyright 2023 Matrix Origin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestWatchNamespaces(t *testing.T) {
+	tests := []struct {
+		name string
+		env  string
+		want []string
+	}{{
+		name: "all namespaces",
+		env:  "",
+		want: nil,
+	}, {
+		name: "single namespace",
+		env:  "tenant-a",
+		want: []string{"tenant-a"},
+	}, {
+		name: "multiple namespaces",
+		env:  "tenant-a, tenant-b,,tenant-a",
+		want: []string{"tenant-a", "tenant-b"},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			g.Expect(WatchNamespaces(tt.env)).To(Equal(tt.want))
+		})
+	}
+}
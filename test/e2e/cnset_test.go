@@ -69,6 +69,8 @@ var _ = Describe("MatrixOneCluster test", func() {
 			ObjectMeta: metav1.ObjectMeta{
 				Namespace: env.Namespace,
 				Name:      "cn",
+				// the cache volumes are smaller than the minimum cache size to save resources
+				Annotations: map[string]string{v1alpha1.SkipCacheSizeValidationAnnotation: "true"},
 			},
 			Spec: v1alpha1.DNSetSpec{
 				DNSetBasic: v1alpha1.DNSetBasic{
@@ -98,6 +100,8 @@ var _ = Describe("MatrixOneCluster test", func() {
 			ObjectMeta: metav1.ObjectMeta{
 				Namespace: env.Namespace,
 				Name:      "cn-" + rand.String(6),
+				// the cache volumes are smaller than the minimum cache size to save resources
+				Annotations: map[string]string{v1alpha1.SkipCacheSizeValidationAnnotation: "true"},
 			},
 			Spec: v1alpha1.CNSetSpec{
 				Role: v1alpha1.CNRoleTP,
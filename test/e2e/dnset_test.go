@@ -67,6 +67,8 @@ var _ = Describe("MatrixOneCluster test", func() {
 			ObjectMeta: metav1.ObjectMeta{
 				Namespace: env.Namespace,
 				Name:      "dn-" + rand.String(6),
+				// the cache volumes are smaller than the minimum cache size to save resources
+				Annotations: map[string]string{v1alpha1.SkipCacheSizeValidationAnnotation: "true"},
 			},
 			Spec: v1alpha1.DNSetSpec{
 				DNSetBasic: v1alpha1.DNSetBasic{
@@ -62,6 +62,8 @@ var _ = Describe("MatrixOneCluster test", func() {
 			ObjectMeta: metav1.ObjectMeta{
 				Namespace: env.Namespace,
 				Name:      "test",
+				// the cache volumes are smaller than the minimum cache size to save resources
+				Annotations: map[string]string{v1alpha1.SkipCacheSizeValidationAnnotation: "true"},
 			},
 			Spec: v1alpha1.MatrixOneClusterSpec{
 				TP: v1alpha1.CNSetBasic{